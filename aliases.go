@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"goversion/pkg/awsssm"
+)
+
+// Account aliases almost never change, so resolved values are kept for a day
+const aliasCacheTTL = 24 * time.Hour
+
+// Group header used for profiles whose account could not be resolved
+const unknownAccountAlias = "(unknown account)"
+
+type aliasCacheEntry struct {
+	Alias     string    `json:"alias"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type profileGroup struct {
+	Alias    string
+	Profiles []string
+}
+
+func aliasCachePath() string {
+	return filepath.Join(cacheDir(), "aliases.json")
+}
+
+func readAliasCache() map[string]aliasCacheEntry {
+	entries := map[string]aliasCacheEntry{}
+	data, err := os.ReadFile(aliasCachePath())
+	if err != nil {
+		return entries
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return map[string]aliasCacheEntry{}
+	}
+	return entries
+}
+
+func writeAliasCache(entries map[string]aliasCacheEntry) error {
+	if err := os.MkdirAll(cacheDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(aliasCachePath(), data, 0644)
+}
+
+// resolveAccountAlias returns the IAM account alias for profile, falling back
+// to the account ID when the account has no alias.
+func resolveAccountAlias(ctx context.Context, profile string) (string, error) {
+	out, err := runAWS(ctx, "iam", "list-account-aliases", "--profile", profile, "--output", "json")
+	if err == nil {
+		var result struct {
+			AccountAliases []string `json:"AccountAliases"`
+		}
+		if err := awsssm.DecodeOutput(out, &result); err == nil && len(result.AccountAliases) > 0 {
+			return result.AccountAliases[0], nil
+		}
+	}
+	out, err = runAWS(ctx, "sts", "get-caller-identity", "--profile", profile, "--output", "json")
+	if err != nil {
+		return "", err
+	}
+	var identity struct {
+		Account string `json:"Account"`
+	}
+	if err := awsssm.DecodeOutput(out, &identity); err != nil {
+		return "", err
+	}
+	return identity.Account, nil
+}
+
+// Maximum number of profiles resolved at once; each takes up to two AWS CLI
+// processes
+const aliasWorkers = 8
+
+// resolveAccountAliases resolves the profiles concurrently, at most
+// aliasWorkers at a time, consulting the on-disk alias cache first. Profiles
+// that fail to resolve are omitted.
+func resolveAccountAliases(ctx context.Context, profiles []string) map[string]string {
+	cache := map[string]aliasCacheEntry{}
+	if !demoMode {
+		cache = readAliasCache()
+	}
+	aliases := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, aliasWorkers)
+	for _, p := range profiles {
+		// Workers already started update both maps
+		mu.Lock()
+		entry, ok := cache[p]
+		fresh := ok && time.Since(entry.Timestamp) < aliasCacheTTL
+		if fresh {
+			aliases[p] = entry.Alias
+		}
+		mu.Unlock()
+		if fresh {
+			continue
+		}
+		wg.Add(1)
+		go func(profile string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			alias, err := resolveAccountAlias(ctx, profile)
+			if err != nil || alias == "" {
+				return
+			}
+			mu.Lock()
+			aliases[profile] = alias
+			cache[profile] = aliasCacheEntry{Alias: alias, Timestamp: time.Now()}
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+	if !demoMode {
+		_ = writeAliasCache(cache)
+	}
+	return aliases
+}
+
+// groupProfilesByAlias buckets profiles under their account alias. Groups are
+// sorted by alias with unresolved profiles last; profile order within a group
+// is preserved.
+func groupProfilesByAlias(profiles []string, aliases map[string]string) []profileGroup {
+	index := map[string]int{}
+	groups := []profileGroup{}
+	for _, p := range profiles {
+		alias, ok := aliases[p]
+		if !ok {
+			alias = unknownAccountAlias
+		}
+		i, seen := index[alias]
+		if !seen {
+			i = len(groups)
+			index[alias] = i
+			groups = append(groups, profileGroup{Alias: alias})
+		}
+		groups[i].Profiles = append(groups[i].Profiles, p)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Alias == unknownAccountAlias || groups[j].Alias == unknownAccountAlias {
+			return groups[j].Alias == unknownAccountAlias && groups[i].Alias != unknownAccountAlias
+		}
+		return groups[i].Alias < groups[j].Alias
+	})
+	return groups
+}
+
+// flattenProfileGroups returns the profiles in grouped display order so the
+// cursor can keep indexing a flat slice.
+func flattenProfileGroups(groups []profileGroup) []string {
+	out := []string{}
+	for _, g := range groups {
+		out = append(out, g.Profiles...)
+	}
+	return out
+}
+
+func aliasesCmd(profiles []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(discovery, loadTimeout)
+		defer cancel()
+		return struct {
+			aliases map[string]string
+		}{resolveAccountAliases(ctx, profiles)}
+	}
+}
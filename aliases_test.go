@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test grouping profiles under their account alias
+func TestGroupProfilesByAlias(t *testing.T) {
+	profiles := []string{"prod-admin", "dev-admin", "prod-readonly", "sandbox", "dev-readonly"}
+	aliases := map[string]string{
+		"prod-admin":    "acme-prod",
+		"prod-readonly": "acme-prod",
+		"dev-admin":     "acme-dev",
+		"dev-readonly":  "acme-dev",
+	}
+
+	groups := groupProfilesByAlias(profiles, aliases)
+
+	require.Len(t, groups, 3)
+	assert.Equal(t, profileGroup{Alias: "acme-dev", Profiles: []string{"dev-admin", "dev-readonly"}}, groups[0])
+	assert.Equal(t, profileGroup{Alias: "acme-prod", Profiles: []string{"prod-admin", "prod-readonly"}}, groups[1])
+	assert.Equal(t, profileGroup{Alias: unknownAccountAlias, Profiles: []string{"sandbox"}}, groups[2])
+	assert.Equal(t, []string{"dev-admin", "dev-readonly", "prod-admin", "prod-readonly", "sandbox"}, flattenProfileGroups(groups))
+}
+
+// Test alias resolution and the alias cache
+func TestResolveAccountAliases(t *testing.T) {
+	setTempHome(t)
+	calls := 0
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls++
+		profile := args[3]
+		switch {
+		case args[0] == "iam" && profile == "prod":
+			return []byte(`{"AccountAliases": ["acme-prod"]}`), nil
+		case args[0] == "iam":
+			return []byte(`{"AccountAliases": []}`), nil
+		case args[0] == "sts" && profile == "dev":
+			return []byte(`{"Account": "123456789012"}`), nil
+		}
+		return nil, fmt.Errorf("unable to locate credentials")
+	})
+
+	aliases := resolveAccountAliases(context.Background(), []string{"prod", "dev", "broken"})
+	assert.Equal(t, map[string]string{"prod": "acme-prod", "dev": "123456789012"}, aliases)
+
+	// Resolved aliases are served from the cache on the next lookup
+	calls = 0
+	aliases = resolveAccountAliases(context.Background(), []string{"prod", "dev"})
+	assert.Equal(t, map[string]string{"prod": "acme-prod", "dev": "123456789012"}, aliases)
+	assert.Equal(t, 0, calls)
+}
+
+// Test that many profiles don't start an AWS CLI process each at once
+func TestResolveAccountAliasesBounded(t *testing.T) {
+	setTempHome(t)
+	var running, peak atomic.Int32
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return []byte(`{"AccountAliases": ["acme"]}`), nil
+	})
+	profiles := []string{}
+	for i := range 50 {
+		profiles = append(profiles, fmt.Sprintf("role-%d", i))
+	}
+
+	aliases := resolveAccountAliases(context.Background(), profiles)
+	assert.Len(t, aliases, 50)
+	assert.LessOrEqual(t, int(peak.Load()), aliasWorkers)
+}
+
+// Test toggling the grouped profile view
+func TestGroupedProfileView(t *testing.T) {
+	m := model{
+		step:             stateProfile,
+		profiles:         []string{"prod-admin", "dev-admin", "prod-readonly"},
+		filteredProfiles: []string{"prod-admin", "dev-admin", "prod-readonly"},
+		accountAliases: map[string]string{
+			"prod-admin":    "acme-prod",
+			"prod-readonly": "acme-prod",
+			"dev-admin":     "acme-dev",
+		},
+	}
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	result := updatedModel.(model)
+
+	assert.True(t, result.groupProfiles)
+	assert.Equal(t, []string{"dev-admin", "prod-admin", "prod-readonly"}, result.filteredProfiles)
+	view := result.View()
+	assert.Contains(t, view, "acme-dev")
+	assert.Contains(t, view, "acme-prod")
+
+	t.Run("aliases are resolved on first toggle", func(t *testing.T) {
+		m := model{step: stateProfile, profiles: []string{"prod-admin"}, filteredProfiles: []string{"prod-admin"}}
+
+		updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		result := updatedModel.(model)
+
+		assert.True(t, result.loading)
+		assert.NotNil(t, cmd)
+	})
+}
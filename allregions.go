@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Set by -all-regions: list instances from every region in one merged view
+var allRegions bool
+
+// Maximum number of regions queried at once, to stay clear of API throttling
+const allRegionsWorkers = 4
+
+// regionErrors collects the regions whose instance lookup failed.
+type regionErrors map[string]error
+
+func (e regionErrors) Error() string {
+	regions := e.regions()
+	parts := make([]string, len(regions))
+	for i, r := range regions {
+		parts[i] = fmt.Sprintf("%s: %v", r, e[r])
+	}
+	return "failed to list instances in " + strings.Join(parts, "; ")
+}
+
+// Unwrap exposes the per-region errors to errors.Is and errors.As.
+func (e regionErrors) Unwrap() []error {
+	errs := []error{}
+	for _, r := range e.regions() {
+		errs = append(errs, e[r])
+	}
+	return errs
+}
+
+// regions returns the failed regions in sorted order.
+func (e regionErrors) regions() []string {
+	regions := make([]string, 0, len(e))
+	for r := range e {
+		regions = append(regions, r)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// getInstancesAllRegions lists instances across regions using a bounded pool
+// of workers. Each instance is stamped with its region, and the merged list
+// keeps the order of regions. Regions that fail or exceed loadTimeout are
+// reported in the returned regionErrors; the rest are still listed. progress,
+// when it isn't nil, is called with the number of regions done, failed ones
+// included, each time a region finishes.
+func getInstancesAllRegions(ctx context.Context, profile string, regions []string, refresh bool, progress func(done int)) ([]Instance, regionErrors) {
+	results := make([][]Instance, len(regions))
+	errs := regionErrors{}
+	done := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	workers := allRegionsWorkers
+	if len(regions) < workers {
+		workers = len(regions)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				instances, err := getRegionInstances(ctx, profile, regions[i], refresh)
+				for j := range instances {
+					instances[j].Region = regions[i]
+				}
+				mu.Lock()
+				if err != nil {
+					errs[regions[i]] = err
+				}
+				results[i] = instances
+				done++
+				if progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range regions {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	merged := []Instance{}
+	for _, instances := range results {
+		merged = append(merged, instances...)
+	}
+	return merged, errs
+}
+
+// getRegionInstances bounds a single region's lookup by loadTimeout so one
+// unreachable region can't stall the whole scan.
+func getRegionInstances(ctx context.Context, profile, region string, refresh bool) ([]Instance, error) {
+	ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+	instances, err := getInstances(ctx, profile, region, refresh)
+	if err != nil {
+		return nil, lookupErr(ctx, err, "instances", loadTimeout)
+	}
+	return instances, nil
+}
+
+// allRegionsCmd resolves the region list (configured or from describe-regions)
+// and lists instances across all of them. Like instancesCmd it streams its
+// progress, with a message each time a region finishes. The whole listing
+// only fails when no region could be listed.
+func allRegionsCmd(ctx context.Context, profile string, configured []string, refresh bool) tea.Cmd {
+	next, send := progressStream(ctx)
+	scan := func() tea.Msg {
+		regions := configured
+		if len(regions) == 0 {
+			regionsCtx, cancel := context.WithTimeout(ctx, loadTimeout)
+			defer cancel()
+			var err error
+			if regions, err = loadRegionList(regionsCtx, profile, refresh); err != nil {
+				err = lookupErr(regionsCtx, err, "regions", loadTimeout)
+				return struct {
+					regionInstances []Instance
+					regionErrs      regionErrors
+					err             error
+				}{nil, nil, err}
+			}
+		}
+		instances, errs := getInstancesAllRegions(ctx, profile, regions, refresh, func(done int) {
+			send(struct {
+				regionsDone, regionsTotal int
+				nextRegion                tea.Cmd
+			}{done, len(regions), next})
+		})
+		var err error
+		if len(regions) > 0 && len(errs) == len(regions) {
+			err = errs
+		}
+		return struct {
+			regionInstances []Instance
+			regionErrs      regionErrors
+			err             error
+		}{instances, errs, err}
+	}
+	return func() tea.Msg {
+		go func() { send(scan()) }()
+		return next()
+	}
+}
+
+// Width of the all-regions progress bar, unless the terminal is narrower
+const progressBarWidth = 40
+
+// progressBar renders done out of total as a bar width cells wide.
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(done*width/total, width)
+	}
+	return spinnerStyle.Copy().UnsetPadding().Render(strings.Repeat("█", filled)) +
+		mutedStyle.Copy().UnsetPadding().Render(strings.Repeat("░", width-filled))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that regions are scanned with bounded concurrency and errors aggregated
+func TestGetInstancesAllRegions(t *testing.T) {
+	setTempHome(t)
+	regions := []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-west-1", "eu-central-1", "ap-south-1", "ap-northeast-1"}
+	var mu sync.Mutex
+	running, peak := 0, 0
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		region := argValue(args, "--region")
+		if region == "ap-south-1" {
+			return nil, errors.New("UnauthorizedOperation")
+		}
+		return []byte(fmt.Sprintf(`{"Reservations":[{"Instances":[{"InstanceId":"i-%s","State":{"Name":"running"}}]}]}`, region)), nil
+	})
+
+	instances, errs := getInstancesAllRegions(context.Background(), "default", regions, false, nil)
+	assert.LessOrEqual(t, peak, allRegionsWorkers)
+	require.Len(t, instances, len(regions)-1)
+	// Merged in region order, each stamped with its region
+	assert.Equal(t, "i-us-east-1", instances[0].ID)
+	assert.Equal(t, "us-east-1", instances[0].Region)
+	assert.Equal(t, "i-ap-northeast-1 [running] @ ap-northeast-1", instances[len(instances)-1].Display())
+	assert.Equal(t, []string{"ap-south-1"}, errs.regions())
+	assert.ErrorContains(t, errs, "ap-south-1: UnauthorizedOperation")
+}
+
+// Test the all-regions flow from profile to a selected instance
+func TestAllRegionsSelection(t *testing.T) {
+	setTempHome(t)
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		switch region := argValue(args, "--region"); region {
+		case "us-east-1":
+			return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-east","State":{"Name":"running"}}]}]}`), nil
+		case "eu-west-1":
+			return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-west","State":{"Name":"running"}}]}]}`), nil
+		default:
+			return nil, errors.New("timed out")
+		}
+	})
+
+	m := model{
+		profiles:         []string{"default"},
+		filteredProfiles: []string{"default"},
+		step:             stateProfile,
+		allRegions:       true,
+		config:           Config{Regions: []string{"us-east-1", "eu-west-1", "ap-south-1"}},
+	}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	result := finishScan(t, updatedModel.(model), cmd)
+	require.NoError(t, result.err)
+	assert.Equal(t, stateInstance, result.step)
+	require.Len(t, result.instances, 2)
+	assert.Contains(t, result.View(), "Skipped 1 region(s) that failed: ap-south-1")
+	assert.Contains(t, result.View(), "i-west  running  eu-west-1")
+
+	// Selecting an instance picks up its region
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result = updatedModel.(model)
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, "i-west", result.selectedInstance)
+	assert.Equal(t, "eu-west-1", result.selectedRegion)
+
+	// Back from the merged list skips the region step
+	back := updatedModel.(model)
+	back.step = stateInstance
+	assert.Equal(t, stateProfile, back.back().step)
+}
+
+// Test that the listing fails only when every region fails
+func TestAllRegionsAllFailed(t *testing.T) {
+	setTempHome(t)
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, errors.New("AccessDenied")
+	})
+
+	m := finishScan(t, model{allRegions: true, loading: true}, allRegionsCmd(context.Background(), "default", []string{"us-east-1", "eu-west-1"}, false))
+	assert.ErrorContains(t, m.err, "us-east-1: AccessDenied")
+	assert.ErrorContains(t, m.err, "eu-west-1: AccessDenied")
+}
+
+// Helper function for following an all-regions scan's progress messages to
+// the end
+func finishScan(t *testing.T, m model, cmd tea.Cmd) model {
+	for cmd != nil && m.loading {
+		updated, next := m.Update(cmd())
+		m, cmd = updated.(model), next
+	}
+	require.False(t, m.loading, "the scan finished")
+	return m
+}
+
+// Test the progress bar counting regions, failed ones included
+func TestAllRegionsProgress(t *testing.T) {
+	setTempHome(t)
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		if argValue(args, "--region") == "eu-west-1" {
+			return nil, errors.New("AccessDenied")
+		}
+		return []byte(`{"Reservations": []}`), nil
+	})
+	regions := []string{"us-east-1", "eu-west-1", "ap-south-1"}
+	m := model{allRegions: true, loading: true, width: 80, height: 20}
+	cmd := allRegionsCmd(context.Background(), "default", regions, false)
+
+	done := []int{}
+	for m.loading {
+		updated, next := m.Update(cmd())
+		m, cmd = updated.(model), next
+		if m.loading {
+			done = append(done, m.regionsDone)
+			assert.Equal(t, len(regions), m.regionsTotal)
+			assert.Contains(t, m.View(), fmt.Sprintf("Scanned %d of 3 regions...", m.regionsDone))
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, done)
+	assert.Equal(t, 0, m.regionsTotal, "the bar is cleared once the scan ends")
+	assert.Equal(t, []string{"eu-west-1"}, m.regionErrs.regions())
+
+	assert.Equal(t, "██░░", progressBar(1, 2, 4))
+	assert.Equal(t, "████", progressBar(3, 3, 4))
+}
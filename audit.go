@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditEntry is one line of the audit log: who connected to what, and when.
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Profile    string    `json:"profile"`
+	Region     string    `json:"region"`
+	InstanceId string    `json:"instance_id"`
+	Name       string    `json:"name"`
+	// shell, port-forward, eice or command
+	Kind string `json:"kind"`
+}
+
+// auditLogPath returns where sessions are recorded: the config's audit_log,
+// with ~ and environment variables expanded, or audit.log in the config
+// directory.
+func auditLogPath(cfg Config) string {
+	if cfg.AuditLog == "" {
+		return filepath.Join(configDir(), "audit.log")
+	}
+	return expandPath(cfg.AuditLog)
+}
+
+// expandPath expands a leading ~ and environment variables in a path from the
+// config file.
+func expandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = "$HOME" + path[1:]
+	}
+	return os.ExpandEnv(path)
+}
+
+// localUsername returns the name of the user running ssmssh.
+func localUsername() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// appendAudit appends entries to the audit log at path as JSON lines,
+// creating the file and its directory if needed.
+func appendAudit(path string, entries ...auditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// auditSessions records a session of kind on each target. The audit log is
+// best-effort: a failed write is reported on stderr and the session goes ahead.
+// Demo sessions connect to nothing and aren't recorded.
+func auditSessions(stderr io.Writer, cfg Config, profile, kind string, targets []Instance) {
+	if demoMode {
+		return
+	}
+	now := time.Now().UTC()
+	username := localUsername()
+	entries := make([]auditEntry, 0, len(targets))
+	for _, inst := range targets {
+		entries = append(entries, auditEntry{
+			Time:       now,
+			User:       username,
+			Profile:    profile,
+			Region:     inst.Region,
+			InstanceId: inst.ID,
+			Name:       inst.Name,
+			Kind:       kind,
+		})
+	}
+	if err := appendAudit(auditLogPath(cfg), entries...); err != nil {
+		fmt.Fprintln(stderr, "Warning: couldn't write the audit log:", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for reading the entries of an audit log
+func readAudit(t *testing.T, path string) []auditEntry {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entries []auditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e auditEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Test recording started sessions
+func TestAuditSessions(t *testing.T) {
+	setTempHome(t)
+	var stderr bytes.Buffer
+
+	auditSessions(&stderr, Config{}, "prod", "shell", []Instance{{ID: "i-123", Name: "web-1", Region: "us-east-1"}})
+	auditSessions(&stderr, Config{}, "dev", "command", []Instance{
+		{ID: "i-456", Name: "db-1", Region: "eu-west-1"},
+		{ID: "i-789", Region: "eu-west-1"},
+	})
+	assert.Empty(t, stderr.String())
+
+	entries := readAudit(t, filepath.Join(configDir(), "audit.log"))
+	require.Len(t, entries, 3)
+	assert.Equal(t, "prod", entries[0].Profile)
+	assert.Equal(t, "us-east-1", entries[0].Region)
+	assert.Equal(t, "i-123", entries[0].InstanceId)
+	assert.Equal(t, "web-1", entries[0].Name)
+	assert.Equal(t, "shell", entries[0].Kind)
+	assert.NotEmpty(t, entries[0].User)
+	assert.False(t, entries[0].Time.IsZero())
+	assert.Equal(t, "i-789", entries[2].InstanceId)
+	assert.Equal(t, "command", entries[2].Kind)
+}
+
+// Test that a failed write only warns
+func TestAuditSessionsWarns(t *testing.T) {
+	home := setTempHome(t)
+	// A file where the log's directory should be
+	blocker := filepath.Join(home, "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0644))
+	var stderr bytes.Buffer
+
+	auditSessions(&stderr, Config{AuditLog: filepath.Join(blocker, "audit.log")}, "prod", "shell", []Instance{{ID: "i-123"}})
+	assert.Contains(t, stderr.String(), "Warning: couldn't write the audit log")
+}
+
+// Test resolving the configured audit log path
+func TestAuditLogPath(t *testing.T) {
+	home := setTempHome(t)
+	assert.Equal(t, filepath.Join(home, ".config", "ssmssh", "audit.log"), auditLogPath(Config{}))
+	assert.Equal(t, filepath.Join(home, "logs", "ssmssh.log"), auditLogPath(Config{AuditLog: "~/logs/ssmssh.log"}))
+	assert.Equal(t, "/var/log/ssmssh.log", auditLogPath(Config{AuditLog: "/var/log/ssmssh.log"}))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// awsCredentialsPath returns the AWS shared credentials file, honoring
+// AWS_SHARED_CREDENTIALS_FILE.
+func awsCredentialsPath() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	return os.ExpandEnv("$HOME/.aws/credentials")
+}
+
+// profileFiles returns the files profiles are listed from: the shared
+// credentials file, then the comma-separated SSMSSH_PROFILE_FILES and the
+// config's profile_files, which may be other credentials files or AWS CLI
+// config files. Each file is listed once.
+func profileFiles(configured []string) []string {
+	files := []string{awsCredentialsPath()}
+	extra := configured
+	if v := os.Getenv("SSMSSH_PROFILE_FILES"); v != "" {
+		extra = append(strings.Split(v, ","), configured...)
+	}
+	for _, path := range extra {
+		if path = expandPath(strings.TrimSpace(path)); path != "" && !slices.Contains(files, path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// awsConfigPath returns the AWS CLI config file, honoring AWS_CONFIG_FILE.
+func awsConfigPath() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	return os.ExpandEnv("$HOME/.aws/config")
+}
+
+// awsProfileSection returns the section of profile in the AWS CLI config
+// file: [default], or [profile name] for every other profile. ok is false
+// when the file can't be read or has no such section.
+func awsProfileSection(profile string) (section *ini.Section, ok bool) {
+	cfg, err := ini.Load(awsConfigPath())
+	if err != nil {
+		return nil, false
+	}
+	name := "profile " + profile
+	if profile == "default" {
+		name = "default"
+	}
+	section, err = cfg.GetSection(name)
+	if err != nil {
+		return nil, false
+	}
+	return section, true
+}
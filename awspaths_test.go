@@ -0,0 +1,36 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test resolving the AWS credentials and config files
+func TestAWSPaths(t *testing.T) {
+	home := setTempHome(t)
+
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "")
+	t.Setenv("AWS_CONFIG_FILE", "")
+	assert.Equal(t, filepath.Join(home, ".aws", "credentials"), awsCredentialsPath())
+	assert.Equal(t, filepath.Join(home, ".aws", "config"), awsConfigPath())
+
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/secrets/aws/credentials")
+	t.Setenv("AWS_CONFIG_FILE", "/secrets/aws/config")
+	assert.Equal(t, "/secrets/aws/credentials", awsCredentialsPath())
+	assert.Equal(t, "/secrets/aws/config", awsConfigPath())
+}
+
+// Test listing the extra files profiles are read from
+func TestProfileFiles(t *testing.T) {
+	home := setTempHome(t)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "")
+	t.Setenv("SSMSSH_PROFILE_FILES", "")
+	credentials := filepath.Join(home, ".aws", "credentials")
+	assert.Equal(t, []string{credentials}, profileFiles(nil))
+
+	t.Setenv("SSMSSH_PROFILE_FILES", "/work/credentials, ~/.aws/credentials,")
+	assert.Equal(t, []string{credentials, "/work/credentials", filepath.Join(home, "sso.ini")},
+		profileFiles([]string{"~/sso.ini", "/work/credentials"}))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Default lifetime of cached describe-instances results
+const defaultCacheTTL = 5 * time.Minute
+
+type instanceCache struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Instances []Instance `json:"instances"`
+}
+
+func cacheDir() string {
+	return os.ExpandEnv("$HOME/.cache/ssmssh")
+}
+
+// cacheTTL returns the cache lifetime, overridable via SSMSSH_CACHE_TTL (e.g. "30s", "10m").
+func cacheTTL() time.Duration {
+	if v := os.Getenv("SSMSSH_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultCacheTTL
+}
+
+// Lifetime of cached describe-regions results; enabled regions rarely change
+const regionCacheTTL = 24 * time.Hour
+
+type regionCache struct {
+	Timestamp time.Time `json:"timestamp"`
+	Regions   []string  `json:"regions"`
+}
+
+func instanceCachePath(profile, region string) string {
+	name := "instances-" + sanitizeCacheKey(profile) + "-" + sanitizeCacheKey(region) + filterCacheSuffix() + roleCacheSuffix() + ".json"
+	return filepath.Join(cacheDir(), name)
+}
+
+// sanitizeCacheKey keeps profile/region names safe for use in a file name.
+func sanitizeCacheKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// readInstanceCache returns the cached instance list if it exists and is younger than ttl.
+func readInstanceCache(profile, region string, ttl time.Duration) ([]Instance, bool) {
+	data, err := os.ReadFile(instanceCachePath(profile, region))
+	if err != nil {
+		return nil, false
+	}
+	var c instanceCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, false
+	}
+	if time.Since(c.Timestamp) > ttl {
+		return nil, false
+	}
+	return c.Instances, true
+}
+
+func writeInstanceCache(profile, region string, instances []Instance) error {
+	if err := os.MkdirAll(cacheDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(instanceCache{Timestamp: time.Now(), Instances: instances})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(instanceCachePath(profile, region), data, 0644)
+}
+
+// regionCachePath keys the region list by the profile's partition as well, so
+// a profile whose region moves to GovCloud or China, or -partition, doesn't
+// reuse the regions of another partition.
+func regionCachePath(profile string) string {
+	return filepath.Join(cacheDir(), "regions-"+sanitizeCacheKey(profile)+"-"+profilePartition(profile)+roleCacheSuffix()+".json")
+}
+
+// readRegionCache returns the cached region list of profile if it exists and
+// is younger than ttl.
+func readRegionCache(profile string, ttl time.Duration) ([]string, bool) {
+	data, err := os.ReadFile(regionCachePath(profile))
+	if err != nil {
+		return nil, false
+	}
+	var c regionCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, false
+	}
+	if time.Since(c.Timestamp) > ttl {
+		return nil, false
+	}
+	return c.Regions, true
+}
+
+func writeRegionCache(profile string, regions []string) error {
+	if err := os.MkdirAll(cacheDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(regionCache{Timestamp: time.Now(), Regions: regions})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(regionCachePath(profile), data, 0644)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+// Helper function for pointing HOME at a fresh temporary directory
+func setTempHome(t *testing.T) string {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	return tmpDir
+}
+
+// Helper function for stubbing out AWS CLI calls
+func stubAWS(t *testing.T, fn func(args ...string) ([]byte, error)) {
+	original := runAWS
+	runAWS = func(_ context.Context, args ...string) ([]byte, error) {
+		return fn(args...)
+	}
+	t.Cleanup(func() { runAWS = original })
+}
+
+// Test instance cache read/write and expiry
+func TestInstanceCache(t *testing.T) {
+	t.Run("fresh cache is returned", func(t *testing.T) {
+		setTempHome(t)
+		cached := []Instance{{ID: "i-123", Name: "web-server", State: "running", Tags: []Tag{{Key: "Name", Value: "web-server"}}}}
+		require.NoError(t, writeInstanceCache("default", "us-east-1", cached))
+
+		instances, ok := readInstanceCache("default", "us-east-1", time.Minute)
+		assert.True(t, ok)
+		assert.Equal(t, cached, instances)
+	})
+
+	t.Run("expired cache is ignored", func(t *testing.T) {
+		setTempHome(t)
+		data, err := json.Marshal(instanceCache{
+			Timestamp: time.Now().Add(-10 * time.Minute),
+			Instances: []Instance{{ID: "i-123"}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(cacheDir(), 0755))
+		require.NoError(t, os.WriteFile(instanceCachePath("default", "us-east-1"), data, 0644))
+
+		_, ok := readInstanceCache("default", "us-east-1", 5*time.Minute)
+		assert.False(t, ok)
+	})
+
+	t.Run("cache is keyed by profile and region", func(t *testing.T) {
+		setTempHome(t)
+		require.NoError(t, writeInstanceCache("default", "us-east-1", []Instance{{ID: "i-123"}}))
+
+		_, ok := readInstanceCache("default", "us-west-2", time.Minute)
+		assert.False(t, ok)
+		_, ok = readInstanceCache("production", "us-east-1", time.Minute)
+		assert.False(t, ok)
+	})
+
+	t.Run("corrupt cache is ignored", func(t *testing.T) {
+		setTempHome(t)
+		require.NoError(t, os.MkdirAll(cacheDir(), 0755))
+		require.NoError(t, os.WriteFile(instanceCachePath("default", "us-east-1"), []byte("not json"), 0644))
+
+		_, ok := readInstanceCache("default", "us-east-1", time.Minute)
+		assert.False(t, ok)
+	})
+}
+
+// Test that getInstances honors the cache and the refresh flag
+func TestGetInstancesCache(t *testing.T) {
+	setTempHome(t)
+	calls := 0
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls++
+		return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-123","Tags":[{"Key":"Name","Value":"web-server"}]}]}]}`), nil
+	})
+
+	instances, err := getInstances(context.Background(), "default", "us-east-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, []Instance{{ID: "i-123", Name: "web-server", Tags: []Tag{{Key: "Name", Value: "web-server"}}}}, instances)
+	assert.Equal(t, 1, calls)
+
+	// Second lookup is served from the cache
+	_, err = getInstances(context.Background(), "default", "us-east-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	// Refresh bypasses the cache
+	_, err = getInstances(context.Background(), "default", "us-east-1", true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+// Test cache TTL configuration
+func TestCacheTTL(t *testing.T) {
+	t.Setenv("SSMSSH_CACHE_TTL", "")
+	assert.Equal(t, defaultCacheTTL, cacheTTL())
+
+	t.Setenv("SSMSSH_CACHE_TTL", "30s")
+	assert.Equal(t, 30*time.Second, cacheTTL())
+
+	t.Setenv("SSMSSH_CACHE_TTL", "bogus")
+	assert.Equal(t, defaultCacheTTL, cacheTTL())
+}
+
+// Test region cache read/write and expiry
+func TestRegionCache(t *testing.T) {
+	setTempHome(t)
+	require.NoError(t, writeRegionCache("default", []string{"eu-west-1", "us-east-1"}))
+
+	regions, ok := readRegionCache("default", time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"eu-west-1", "us-east-1"}, regions)
+	_, ok = readRegionCache("prod", time.Hour)
+	assert.False(t, ok, "the cache is keyed by profile")
+	partition = awsssm.PartitionGovCloud
+	t.Cleanup(func() { partition = "" })
+	_, ok = readRegionCache("default", time.Hour)
+	assert.False(t, ok, "the cache is keyed by partition")
+	partition = ""
+
+	data, err := json.Marshal(regionCache{Timestamp: time.Now().Add(-25 * time.Hour), Regions: []string{"us-east-1"}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(regionCachePath("default"), data, 0644))
+	_, ok = readRegionCache("default", regionCacheTTL)
+	assert.False(t, ok, "a day-old cache has expired")
+}
+
+// Test that describe-regions results are reused and refreshed on demand
+func TestLoadRegionListCache(t *testing.T) {
+	setTempHome(t)
+	t.Setenv("SSMSSH_CACHE_TTL", "")
+	calls := 0
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls++
+		return []byte(`{"Regions": [{"RegionName": "us-east-1"}]}`), nil
+	})
+
+	for range 2 {
+		regions, err := loadRegionList(context.Background(), "default", false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"us-east-1"}, regions)
+	}
+	assert.Equal(t, 1, calls)
+
+	_, err := loadRegionList(context.Background(), "default", true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// r on the region step refreshes too
+	m := model{step: stateRegion, selectedProfile: "default", regions: []string{"us-east-1"}, filteredRegions: []string{"us-east-1"}}
+	updated, cmd := m.Update(altKey('r'))
+	require.NotNil(t, cmd)
+	assert.True(t, updated.(model).loading)
+	updated.(model).Update(cmd())
+	assert.Equal(t, 3, calls)
+
+	// SSMSSH_CACHE_TTL=0 turns it off
+	t.Setenv("SSMSSH_CACHE_TTL", "0")
+	_, err = loadRegionList(context.Background(), "default", false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+}
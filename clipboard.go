@@ -0,0 +1,51 @@
+package main
+
+import (
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long the "Copied" note stays in the footer
+const toastDuration = 2 * time.Second
+
+// copyToClipboard writes to the system clipboard. It is a variable so tests
+// don't touch the real clipboard.
+var copyToClipboard = clipboard.WriteAll
+
+// copyHighlighted copies the highlighted instance ID, or with command set its
+// full start-session command line, which isn't offered while a role is
+// assumed. Without a usable clipboard (e.g. over SSH or on a headless box)
+// the text is kept to print once the TUI exits.
+func (m model) copyHighlighted(command bool) (tea.Model, tea.Cmd) {
+	if len(m.filteredInstances) == 0 {
+		return m, nil
+	}
+	inst := m.filteredInstances[m.cursor]
+	text, what := inst.ID, "instance ID"
+	if command {
+		if currentRoleArn() != "" {
+			m.inlineErr = roleCommandMsg
+			return m, nil
+		}
+		text = "aws " + shellJoin(shellSessionArgs(m.selectedProfile, m.instanceRegion(inst), inst.ID))
+		what = "session command"
+	}
+	if err := copyToClipboard(text); err != nil {
+		m.pendingOutput = append(m.pendingOutput, text)
+		return m.showToast("No clipboard available; the " + what + " will be printed on exit")
+	}
+	return m.showToast("Copied " + what + "!")
+}
+
+// showToast shows a short note in the footer and schedules its removal.
+// Each note gets an ID so an older timer doesn't clear a newer note.
+func (m model) showToast(text string) (tea.Model, tea.Cmd) {
+	m.toast = text
+	m.toastId++
+	id := m.toastId
+	return m, tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return struct{ toastId int }{id}
+	})
+}
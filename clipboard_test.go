@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Helper function for capturing what would be copied to the clipboard
+func stubClipboard(t *testing.T, err error) *string {
+	var copied string
+	original := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = text
+		return err
+	}
+	t.Cleanup(func() { copyToClipboard = original })
+	return &copied
+}
+
+// Helper function for an instance list with one highlighted instance
+func clipboardModel() model {
+	instances := []Instance{{ID: "i-123", Name: "web-server", State: "running"}}
+	return model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "prod",
+		selectedRegion:    "us-east-1",
+	}
+}
+
+// Test copying the highlighted instance
+func TestCopyHighlighted(t *testing.T) {
+	t.Run("alt+y copies the instance ID", func(t *testing.T) {
+		copied := stubClipboard(t, nil)
+		updatedModel, cmd := clipboardModel().Update(altKey('y'))
+		result := updatedModel.(model)
+		assert.Equal(t, "i-123", *copied)
+		assert.NotNil(t, cmd)
+		assert.Contains(t, result.View(), "Copied instance ID!")
+
+		// The note goes away when its timer fires
+		updatedModel, _ = result.Update(struct{ toastId int }{result.toastId})
+		assert.Empty(t, updatedModel.(model).toast)
+	})
+
+	t.Run("alt+Y copies the session command", func(t *testing.T) {
+		copied := stubClipboard(t, nil)
+		clipboardModel().Update(altKey('Y'))
+		assert.Equal(t, "aws ssm start-session --profile prod --region us-east-1 --target i-123", *copied)
+	})
+
+	t.Run("without a clipboard the text is printed on exit", func(t *testing.T) {
+		stubClipboard(t, errors.New("no clipboard utilities available"))
+		updatedModel, _ := clipboardModel().Update(altKey('y'))
+		result := updatedModel.(model)
+		assert.Equal(t, []string{"i-123"}, result.pendingOutput)
+		assert.Contains(t, result.toast, "printed on exit")
+	})
+
+	t.Run("an older timer doesn't clear a newer note", func(t *testing.T) {
+		stubClipboard(t, nil)
+		result := typeKeys(clipboardModel(), altKey('y'), altKey('Y'))
+		updatedModel, _ := result.Update(struct{ toastId int }{result.toastId - 1})
+		assert.Equal(t, "Copied session command!", updatedModel.(model).toast)
+	})
+}
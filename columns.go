@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"goversion/pkg/awsssm"
+)
+
+// Gap between instance list columns
+const columnGap = "  "
+
+// Share of the row each instance list column is held to when the row doesn't
+// fit: ID, state, Name, key tags, and region in the all-regions view
+var columnShares = []int{25, 20, 35, 20, 15}
+
+// instanceCells returns the columns of an instance's list row: ID, state,
+// Name, the values of its tag matrix tags, and its region when it has one.
+func instanceCells(inst Instance) []string {
+	tags := []string{}
+	for _, key := range tagColumns() {
+		if v := awsssm.TagValue(inst.Tags, key); v != "" && key != "Name" {
+			tags = append(tags, v)
+		}
+	}
+	cells := []string{inst.ID, inst.State, inst.Name, strings.Join(tags, " ")}
+	if inst.Region != "" {
+		cells = append(cells, inst.Region)
+	}
+	return cells
+}
+
+// columnWidths returns the width of each column so the rows of instances line
+// up within width cells. Columns keep their natural width when everything
+// fits; otherwise the columns too wide for their share of width are cut to it,
+// sharing what the others leave unused. A width of 0 or less is unbounded.
+func columnWidths(instances []Instance, width int) []int {
+	natural := []int{}
+	for _, inst := range instances {
+		for i, cell := range instanceCells(inst) {
+			if i == len(natural) {
+				natural = append(natural, 0)
+			}
+			natural[i] = max(natural[i], lipgloss.Width(cell))
+		}
+	}
+	total, shown := 0, 0
+	for _, w := range natural {
+		total += w
+		if w > 0 {
+			shown++
+		}
+	}
+	available := width - lipgloss.Width(columnGap)*max(shown-1, 0)
+	if width <= 0 || total <= available {
+		return natural
+	}
+	// Columns that fit in their share keep their natural width, leaving the
+	// rest to be shared by the others, until only columns that must be cut
+	// remain
+	widths := make([]int, len(natural))
+	remaining := max(available, 0)
+	open := []int{}
+	for i, w := range natural {
+		if w > 0 {
+			open = append(open, i)
+		}
+	}
+	for fitted := true; fitted && len(open) > 0; {
+		fitted = false
+		shares := 0
+		for _, i := range open {
+			shares += columnShares[i]
+		}
+		cut := []int{}
+		for _, i := range open {
+			if natural[i] <= remaining*columnShares[i]/shares {
+				widths[i] = natural[i]
+				remaining -= natural[i]
+				fitted = true
+			} else {
+				cut = append(cut, i)
+			}
+		}
+		open = cut
+	}
+	shares := 0
+	for _, i := range open {
+		shares += columnShares[i]
+	}
+	spare := remaining
+	for _, i := range open {
+		widths[i] = remaining * columnShares[i] / shares
+		spare -= widths[i]
+	}
+	if len(open) > 0 {
+		widths[open[0]] += spare
+	}
+	return widths
+}
+
+// instanceRow renders inst as a list row with its columns padded to widths.
+// Columns that are empty in every row are left out, and rows don't end in
+// blank space.
+func instanceRow(inst Instance, widths []int) string {
+	out := []string{}
+	for i, cell := range instanceCells(inst) {
+		if i >= len(widths) || widths[i] == 0 {
+			continue
+		}
+		cell = truncate(cell, widths[i])
+		out = append(out, cell+strings.Repeat(" ", widths[i]-lipgloss.Width(cell)))
+	}
+	return strings.TrimRight(strings.Join(out, columnGap), " ")
+}
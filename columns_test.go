@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test lining up instance rows in columns
+func TestInstanceRows(t *testing.T) {
+	instances := []Instance{
+		{ID: "i-1234567890abcdef0", State: "running", Name: "web", Tags: []Tag{{Key: "Environment", Value: "prod"}}},
+		{ID: "i-123", State: "stopped", Name: "batch-worker-long"},
+	}
+
+	widths := columnWidths(instances, 0)
+	assert.Equal(t, []int{19, 7, 17, 4}, widths)
+	assert.Equal(t, "i-1234567890abcdef0  running  web                prod", instanceRow(instances[0], widths))
+	assert.Equal(t, "i-123                stopped  batch-worker-long", instanceRow(instances[1], widths))
+
+	// Columns are cut to their share when the row doesn't fit
+	widths = columnWidths(instances, 40)
+	assert.Equal(t, []int{10, 7, 13, 4}, widths)
+	assert.Equal(t, "i-1234567…  running  web            prod", instanceRow(instances[0], widths))
+	assert.Equal(t, "i-123       stopped  batch-worker…", instanceRow(instances[1], widths))
+
+	// Empty columns are left out
+	bare := []Instance{{ID: "i-1"}, {ID: "i-22", Region: "us-east-1"}}
+	widths = columnWidths(bare, 0)
+	assert.Equal(t, "i-1", instanceRow(bare[0], widths))
+	assert.Equal(t, "i-22  us-east-1", instanceRow(bare[1], widths))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the optional user configuration read from
+// ~/.config/ssmssh/config.yaml.
+type Config struct {
+	// Regions, when set, is offered directly instead of calling describe-regions
+	Regions []string `yaml:"regions"`
+	// EnvironmentColors maps Environment tag values (matched as
+	// case-insensitive prefixes) to row colors, overriding the defaults
+	EnvironmentColors map[string]string `yaml:"environment_colors"`
+	// HidePreview hides the instance details pane; toggled with t
+	HidePreview bool `yaml:"hide_preview"`
+	// Favorites are saved targets offered at launch; toggled with f
+	Favorites []Favorite `yaml:"favorites"`
+	// Keys rebinds actions (up, down, select, quit, filter-clear, back) to
+	// lists of keys, replacing the platform defaults for those actions
+	Keys map[string][]string `yaml:"keys"`
+	// UseProfileRegion skips the region step for profiles with a region in
+	// ~/.aws/config, like -profile-region
+	UseProfileRegion bool `yaml:"use_profile_region"`
+	// InstanceSort is the initial order of the instance list: name, id,
+	// launch-time or state
+	InstanceSort string `yaml:"instance_sort"`
+	// Connections are named shortcuts launched with `ssmssh <name>`
+	Connections map[string]Connection `yaml:"connections"`
+	// AuditLog is where started sessions are recorded; defaults to audit.log
+	// in the config directory
+	AuditLog string `yaml:"audit_log"`
+	// PinnedRegions are listed above the other regions; toggled with tab
+	PinnedRegions []string `yaml:"pinned_regions"`
+	// Accounts are listed with -org-role instead of asking AWS Organizations
+	Accounts []Account `yaml:"accounts"`
+	// Spinner is the loading spinner style: braille, dots, or line
+	Spinner string `yaml:"spinner"`
+	// PreviewTemplate is a Go text/template that renders the details pane
+	// instead of the built-in details and tag list
+	PreviewTemplate string `yaml:"preview_template"`
+	// SSHUsers maps profiles to the OS user for SSH over SSM to their
+	// instances, like -user
+	SSHUsers map[string]string `yaml:"ssh_users"`
+	// ConnectionMethods are tried in order for shell sessions while they
+	// can't reach the instance, like -methods
+	ConnectionMethods []string `yaml:"connection_methods"`
+	// Quiet hides the command being run and the session starting screen,
+	// like -quiet
+	Quiet bool `yaml:"quiet"`
+	// ProfileFiles are read for profiles besides the shared credentials
+	// file, like SSMSSH_PROFILE_FILES
+	ProfileFiles []string `yaml:"profile_files"`
+	// TagColumns are the tags shown in the tag matrix and the instance list,
+	// unless SSMSSH_TAG_COLUMNS is set
+	TagColumns []string `yaml:"tag_columns"`
+}
+
+func configPath() string {
+	return filepath.Join(configDir(), "config.yaml")
+}
+
+// loadConfig reads the config file. A missing file is not an error and
+// yields the zero Config.
+func loadConfig() (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(configPath())
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+	}
+	if err := validateKeys(cfg.Keys); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+	}
+	if _, err := parseInstanceSort(cfg.InstanceSort); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+	}
+	if err := validateConnections(cfg.Connections); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+	}
+	if cfg.Spinner != "" {
+		if _, err := spinnerFramesFor(cfg.Spinner); err != nil {
+			return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+		}
+	}
+	for _, a := range cfg.Accounts {
+		if a.ID == "" {
+			return Config{}, fmt.Errorf("invalid config %s: account %q has no id", configPath(), a.Name)
+		}
+	}
+	if cfg.PreviewTemplate != "" {
+		if _, err := parsePreviewTemplate(cfg.PreviewTemplate); err != nil {
+			return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+		}
+	}
+	if err := validateMethods(cfg.ConnectionMethods); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", configPath(), err)
+	}
+	for _, key := range cfg.TagColumns {
+		if strings.TrimSpace(key) == "" {
+			return Config{}, fmt.Errorf("invalid config %s: tag_columns has an empty tag key", configPath())
+		}
+	}
+	for profile, user := range cfg.SSHUsers {
+		if err := validateSSHUser(user); err != nil {
+			return Config{}, fmt.Errorf("invalid config %s: ssh_users %s: %w", configPath(), profile, err)
+		}
+	}
+	return cfg, nil
+}
+
+// saveConfigValue sets a single top-level key in the config file, creating
+// the file if needed. The rest of the file, comments included, is kept as is.
+func saveConfigValue(key string, value any) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(configPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid config %s: %w", configPath(), err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config %s: not a mapping", configPath())
+	}
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			// Keep any comment attached to the old value
+			valueNode.LineComment = root.Content[i+1].LineComment
+			root.Content[i+1] = &valueNode
+			found = true
+		}
+	}
+	if !found {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(configPath(), out, 0644)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for writing a config file under the temporary HOME
+func writeConfig(t *testing.T, content string) {
+	require.NoError(t, os.MkdirAll(configDir(), 0755))
+	require.NoError(t, os.WriteFile(configPath(), []byte(content), 0644))
+}
+
+// Test config file loading
+func TestLoadConfig(t *testing.T) {
+	t.Run("missing config is empty", func(t *testing.T) {
+		setTempHome(t)
+		cfg, err := loadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, Config{}, cfg)
+	})
+
+	t.Run("regions list", func(t *testing.T) {
+		setTempHome(t)
+		writeConfig(t, "regions:\n  - us-east-1\n  - eu-west-1\n")
+		cfg, err := loadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"us-east-1", "eu-west-1"}, cfg.Regions)
+	})
+
+	t.Run("tag columns", func(t *testing.T) {
+		setTempHome(t)
+		writeConfig(t, "tag_columns:\n  - Service\n  - CostCenter\n")
+		cfg, err := loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Service", "CostCenter"}, cfg.TagColumns)
+
+		configTagColumns = cfg.TagColumns
+		t.Cleanup(func() { configTagColumns = nil })
+		t.Setenv("SSMSSH_TAG_COLUMNS", "")
+		assert.Equal(t, []string{"Service", "CostCenter"}, tagColumns())
+		// The environment variable overrides the config
+		t.Setenv("SSMSSH_TAG_COLUMNS", "Owner")
+		assert.Equal(t, []string{"Owner"}, tagColumns())
+
+		writeConfig(t, "tag_columns:\n  - \"\"\n")
+		_, err = loadConfig()
+		assert.ErrorContains(t, err, "tag_columns has an empty tag key")
+	})
+
+	t.Run("malformed config is an error", func(t *testing.T) {
+		setTempHome(t)
+		writeConfig(t, "regions: [us-east-1\n")
+		_, err := loadConfig()
+		assert.ErrorContains(t, err, "invalid config")
+	})
+}
+
+// Test that configured regions skip describe-regions
+func TestConfiguredRegions(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		t.Errorf("unexpected AWS call: %v", args)
+		return nil, assert.AnError
+	})
+	m := model{
+		step:             stateProfile,
+		filteredProfiles: []string{"default"},
+		config:           Config{Regions: []string{"us-east-1", "eu-west-1"}},
+	}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result := updatedModel.(model)
+
+	assert.NoError(t, result.err)
+	assert.Equal(t, stateRegion, result.step)
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, result.regions)
+}
+
+// Test updating a single config value in place
+func TestSaveConfigValue(t *testing.T) {
+	t.Run("creates the config file", func(t *testing.T) {
+		setTempHome(t)
+		require.NoError(t, saveConfigValue("hide_preview", true))
+		cfg, err := loadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.HidePreview)
+	})
+
+	t.Run("keeps the rest of the file", func(t *testing.T) {
+		setTempHome(t)
+		writeConfig(t, "# my regions\nregions:\n  - us-east-1\nhide_preview: true\n")
+		require.NoError(t, saveConfigValue("hide_preview", false))
+
+		data, err := os.ReadFile(configPath())
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "# my regions")
+		cfg, err := loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"us-east-1"}, cfg.Regions)
+		assert.False(t, cfg.HidePreview)
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Set by -confirm: ask before starting a session on the selected instance
+var confirmSession bool
+
+var warningStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).Background(lipgloss.Color("#CC0000")).Padding(0, 1)
+
+// startSelected moves on from a completed selection. A shell session to an
+// instance in a VPC first looks for an EC2 Instance Connect Endpoint, so the
+// connection method can be chosen when there is one.
+func (m model) startSelected() (tea.Model, tea.Cmd) {
+	// -eval and -describe only print the selection; there is no session to
+	// prepare
+	if evalOutput || describeOutput {
+		m.step = stateDone
+		return m, tea.Quit
+	}
+	// A -cmd command always goes through SSM
+	if m.sessionMode == sessionShell && remoteCommand == "" && m.selectedInstanceInfo().VpcId != "" {
+		m.loading = true
+		m.loadingMsg = "Checking for EC2 Instance Connect Endpoints..."
+		return m, tea.Batch(endpointsCmd(m.lookupContext(), m.selectedProfile, m.selectedRegion), spinnerTick())
+	}
+	return m.startSSM()
+}
+
+// startSSM moves on to an SSM session, checking the SSM agent first when
+// -precheck is set.
+func (m model) startSSM() (tea.Model, tea.Cmd) {
+	if m.precheck {
+		m.loading = true
+		m.loadingMsg = "Checking the SSM agent status..."
+		return m, tea.Batch(precheckCmd(m.lookupContext(), m.selectedProfile, m.sessionTargets()), spinnerTick())
+	}
+	return m.proceed()
+}
+
+// proceed goes to the confirmation screen when -confirm is set, otherwise
+// straight to starting the session.
+func (m model) proceed() (tea.Model, tea.Cmd) {
+	if m.confirm {
+		m.step = stateConfirm
+		return m, nil
+	}
+	m.step = stateDone
+	return m, tea.Quit
+}
+
+// updateConfirm handles key input on the confirmation screen.
+func (m model) updateConfirm(s string) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	switch {
+	case s == "y", s == "Y":
+		m.step = stateDone
+		return m, tea.Quit
+	case s == "n", s == "N", keys.matches(actionBack, s), keys.matches(actionFilterClear, s):
+		return m.back(), nil
+	}
+	return m, nil
+}
+
+// selectedInstanceInfo returns the listed instance matching selectedInstance.
+func (m model) selectedInstanceInfo() Instance {
+	for _, inst := range m.instances {
+		if inst.ID == m.selectedInstance {
+			return inst
+		}
+	}
+	return Instance{ID: m.selectedInstance, Name: m.selectedName}
+}
+
+// isProductionEnvironment reports whether an Environment tag value names a
+// production environment, e.g. "production", "prod", or "prod-eu".
+func isProductionEnvironment(env string) bool {
+	return strings.HasPrefix(strings.ToLower(env), "prod")
+}
+
+func (m model) confirmView() string {
+	if m.sessionMode == sessionMulti {
+		return m.confirmMultiView()
+	}
+	inst := m.selectedInstanceInfo()
+	w := m.contentWidth()
+	content := headerStyle.Render(fit("Confirm session", w, 2)) + "\n"
+	env := instanceEnvironment(inst.Tags)
+	if isProductionEnvironment(env) {
+		content += warningStyle.Render(fit("⚠ Environment: "+env, w, 2)) + "\n"
+	}
+	name := inst.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	content += selectedStyle.Render(fit(name, w, 2)) + "\n"
+	content += infoStyle.Render(fit("Instance: "+inst.ID, w, 2)) + "\n"
+	content += infoStyle.Render(fit("Profile: "+m.selectedProfile, w, 2)) + "\n"
+	content += infoStyle.Render(fit("Region: "+m.selectedRegion, w, 2)) + "\n"
+	if env != "" && !isProductionEnvironment(env) {
+		content += infoStyle.Render(fit("Environment: "+env, w, 2)) + "\n"
+	}
+	if m.sessionMode == sessionPortForward {
+		content += infoStyle.Render(fit(fmt.Sprintf("Forward localhost:%s to port %s", m.localPort, m.remotePort), w, 2)) + "\n"
+	} else if m.sessionMode == sessionEICE {
+		content += infoStyle.Render(fit("Via: EC2 Instance Connect Endpoint as "+eiceUser, w, 2)) + "\n"
+	} else if remoteCommand != "" {
+		content += infoStyle.Render(fit("Command: "+remoteCommand, w, 2)) + "\n"
+	} else if sessionDocument != "" {
+		content += infoStyle.Render(fit("Document: "+sessionDocument, w, 2)) + "\n"
+	}
+	content += quitStyle.Render(fit("y: connect • n/esc: back", w, 0))
+	return borderStyle.Render(content)
+}
+
+// confirmMultiView lists every marked instance, flagging production ones.
+func (m model) confirmMultiView() string {
+	w := m.contentWidth()
+	content := headerStyle.Render(fit(fmt.Sprintf("Confirm %d sessions", len(m.targets)), w, 2)) + "\n"
+	content += infoStyle.Render(fit("Profile: "+m.selectedProfile, w, 2)) + "\n"
+	if remoteCommand != "" {
+		content += infoStyle.Render(fit("Command: "+remoteCommand, w, 2)) + "\n"
+	}
+	for _, inst := range m.targets {
+		if isProductionEnvironment(instanceEnvironment(inst.Tags)) {
+			content += warningStyle.Render(fit("⚠ "+inst.Display(), w, 2)) + "\n"
+			continue
+		}
+		content += itemStyle.Render(fit(inst.Display(), w, 4)) + "\n"
+	}
+	content += quitStyle.Render(fit("y: connect • n/esc: back", w, 0))
+	return borderStyle.Render(content)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// Helper function for building an instance list with a production instance highlighted
+func confirmModel() model {
+	instances := []Instance{
+		{ID: "i-123", Name: "web-server", State: "running"},
+		{ID: "i-456", Name: "db-primary", State: "running", Tags: []Tag{{Key: "Environment", Value: "production"}}},
+	}
+	return model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		cursor:            1,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+		confirm:           true,
+	}
+}
+
+// Test the confirmation step between instance selection and the session
+func TestConfirmSession(t *testing.T) {
+	t.Run("enter asks for confirmation", func(t *testing.T) {
+		updatedModel, cmd := confirmModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result := updatedModel.(model)
+		assert.Nil(t, cmd)
+		assert.Equal(t, stateConfirm, result.step)
+		assert.Equal(t, "i-456", result.selectedInstance)
+
+		view := result.View()
+		assert.Contains(t, view, "db-primary")
+		assert.Contains(t, view, "⚠ Environment: production")
+		assert.Contains(t, view, "y: connect")
+	})
+
+	t.Run("y starts the session", func(t *testing.T) {
+		updatedModel, _ := confirmModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+		updatedModel, cmd := updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		assert.Equal(t, stateDone, updatedModel.(model).step)
+		assert.NotNil(t, cmd)
+	})
+
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("n")}, {Type: tea.KeyEsc}} {
+		t.Run(key.String()+" goes back to the instance list", func(t *testing.T) {
+			updatedModel, _ := confirmModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+			updatedModel, cmd := updatedModel.(model).Update(key)
+			result := updatedModel.(model)
+			assert.Nil(t, cmd)
+			assert.Equal(t, stateInstance, result.step)
+			assert.Equal(t, 1, result.cursor)
+			assert.Equal(t, "", result.selectedInstance)
+		})
+	}
+
+	t.Run("port forward returns to the port prompt", func(t *testing.T) {
+		m := confirmModel()
+		m.cursor = 0
+		updatedModel, _ := m.Update(altKey('p'))
+		updatedModel = typeKeys(updatedModel.(model), runeKeys("5432")...)
+		updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result := updatedModel.(model)
+		assert.Equal(t, stateConfirm, result.step)
+		assert.NotContains(t, result.View(), "⚠")
+		assert.Contains(t, result.View(), "Forward localhost:5432 to port 5432")
+
+		updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		result = updatedModel.(model)
+		assert.Equal(t, statePortForward, result.step)
+		assert.Equal(t, "5432", result.remotePort)
+	})
+
+	t.Run("without -confirm enter starts immediately", func(t *testing.T) {
+		m := confirmModel()
+		m.confirm = false
+		updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, stateDone, updatedModel.(model).step)
+		assert.NotNil(t, cmd)
+	})
+}
+
+// Test which Environment tag values get the production warning
+func TestIsProductionEnvironment(t *testing.T) {
+	assert.True(t, isProductionEnvironment("production"))
+	assert.True(t, isProductionEnvironment("Prod"))
+	assert.False(t, isProductionEnvironment("staging"))
+	assert.False(t, isProductionEnvironment(""))
+}
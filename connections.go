@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"goversion/pkg/awsssm"
+)
+
+// Set from the first argument: the connection to launch, e.g. `ssmssh prod-web`
+var connectionName string
+
+// Connection is a named shortcut to an instance, launched with `ssmssh <name>`.
+// Unlike a favorite it is matched by tag on every launch, so it keeps working
+// when the instance behind it is replaced.
+type Connection struct {
+	Profile string `yaml:"profile"`
+	Region  string `yaml:"region"`
+	// Tag is Key=Value; the value may use * and ? wildcards
+	Tag string `yaml:"tag"`
+}
+
+// tagMatcher splits the connection's tag into its key and value pattern.
+func (c Connection) tagMatcher() (key, value string) {
+	key, value, _ = strings.Cut(c.Tag, "=")
+	return key, value
+}
+
+func (c Connection) Display() string {
+	return c.Tag + " in " + c.Profile + "/" + c.Region
+}
+
+// globMatch reports whether s matches pattern the way EC2 filters match tag
+// values: * stands for any characters and ? for any one, and a backslash
+// escapes them. Unlike path.Match, * also matches /, which values such as
+// team/platform and ARNs contain.
+func globMatch(pattern, s string) bool {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case runes[i] == '*':
+			b.WriteString(".*")
+		case runes[i] == '?':
+			b.WriteString(".")
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(s)
+}
+
+// matches reports whether inst carries the connection's tag.
+func (c Connection) matches(inst Instance) bool {
+	key, pattern := c.tagMatcher()
+	for _, tag := range inst.Tags {
+		if tag.Key != key {
+			continue
+		}
+		if globMatch(pattern, tag.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the running instances the connection matches.
+func (c Connection) resolve(instances []Instance) []Instance {
+	matches := []Instance{}
+	for _, inst := range instances {
+		if c.matches(inst) && inst.Connectable() {
+			matches = append(matches, inst)
+		}
+	}
+	return matches
+}
+
+// validateConnections checks that every connection names a profile, a region
+// and a Key=Value tag.
+func validateConnections(connections map[string]Connection) error {
+	for name, c := range connections {
+		if name == resolveCommand {
+			return fmt.Errorf("%q is a subcommand and can't name a connection", name)
+		}
+		if c.Profile == "" || c.Region == "" {
+			return fmt.Errorf("connection %q needs a profile and a region", name)
+		}
+		if key, _ := c.tagMatcher(); key == "" || !strings.Contains(c.Tag, "=") {
+			return fmt.Errorf("connection %q needs a tag like Name=web-server", name)
+		}
+	}
+	return nil
+}
+
+// launchConnection starts the model on resolving the named connection
+// instead of on the profile list.
+func (m model) launchConnection(name string) model {
+	c := m.config.Connections[name]
+	m.connection = name
+	m.selectedProfile, m.selectedRegion = c.Profile, c.Region
+	m.step = stateProfile
+	m.loading = true
+	m.loadingMsg = "Finding " + name + " (" + c.Display() + ")..."
+	return m
+}
+
+// resolveConnectionCmd lists the instances carrying the connection's tag.
+// The lookup always goes to AWS, filtered by the tag, rather than to the
+// instance cache, so a replaced instance is found straight away.
+func resolveConnectionCmd(ctx context.Context, c Connection) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		key, value := c.tagMatcher()
+		instances, err := awsssm.FindInstances(ctx, describeRunner, c.Profile, c.Region, awsssm.TagFilter(key, value))
+		return struct {
+			connectionInstances []Instance
+			err                 error
+		}{instances, lookupErr(ctx, err, "instances", loadTimeout)}
+	}
+}
+
+// updateConnectionInstances connects straight away when the connection
+// matches a single running instance, and lists the matches to pick from when
+// it matches several.
+func (m model) updateConnectionInstances(instances []Instance, err error) (tea.Model, tea.Cmd) {
+	m.loading = false
+	c := m.config.Connections[m.connection]
+	if isExpiredCredentials(err) {
+		return m.promptReauth(err, resolveConnectionCmd(m.lookupContext(), c)), nil
+	}
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	matches := c.resolve(instances)
+	if len(matches) == 0 {
+		m.err = fmt.Errorf("no running instance matches %s (%s)", m.connection, c.Display())
+		return m, nil
+	}
+	m.step = stateInstance
+	m.instances = sortInstances(matches, m.instanceSort)
+	m.filteredInstances = m.instances
+	m.filter = ""
+	m.cursor = 0
+	if len(matches) > 1 {
+		m, preview := m.preview()
+		updated, toast := m.showToast(fmt.Sprintf("%d instances match %s; pick one", len(matches), m.connection))
+		return updated, tea.Batch(preview, toast)
+	}
+	m = m.selectInstance(matches[0])
+	if sshConfigOutput {
+		m.sessionMode = sessionSSHProxy
+		m.step = stateDone
+		return m, tea.Quit
+	}
+	return m.startSelected()
+}
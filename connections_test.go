@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for launching a connection against canned describe-instances output
+func launchTestConnection(t *testing.T, connection Connection, output string) (model, [][]string) {
+	var calls [][]string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(output), nil
+	})
+	m := model{config: Config{Connections: map[string]Connection{"prod-web": connection}}}.launchConnection("prod-web")
+	require.True(t, m.loading)
+	updatedModel, _ := m.Update(resolveConnectionCmd(context.Background(), connection)())
+	return updatedModel.(model), calls
+}
+
+// Test matching instances by a connection's tag
+func TestConnectionResolve(t *testing.T) {
+	c := Connection{Profile: "prod", Region: "us-east-1", Tag: "Role=web*"}
+	instances := []Instance{
+		{ID: "i-1", Name: "web-2", State: "running", Tags: []Tag{{Key: "Role", Value: "web-frontend"}}},
+		{ID: "i-2", Name: "web-1", State: "running", Tags: []Tag{{Key: "Role", Value: "web"}}},
+		{ID: "i-3", Name: "web-3", State: "stopped", Tags: []Tag{{Key: "Role", Value: "web"}}},
+		{ID: "i-4", Name: "db-1", State: "running", Tags: []Tag{{Key: "Role", Value: "db"}}},
+	}
+
+	matches := c.resolve(instances)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "i-1", matches[0].ID)
+	assert.Equal(t, "i-2", matches[1].ID)
+}
+
+// Test wildcards in tag values, which may contain slashes
+func TestGlobMatch(t *testing.T) {
+	assert.True(t, globMatch("team/*", "team/platform"))
+	assert.True(t, globMatch("*platform", "team/platform"))
+	assert.True(t, globMatch("arn:aws:iam::*:role/ops", "arn:aws:iam::123456789012:role/ops"))
+	assert.True(t, globMatch("web-?", "web-1"))
+	assert.False(t, globMatch("web-?", "web-12"))
+	assert.True(t, globMatch(`a\*b`, "a*b"))
+	assert.False(t, globMatch(`a\*b`, "axb"))
+	assert.False(t, globMatch("web.*", "webserver"), "regexp characters are literal")
+
+	c := Connection{Tag: "Team=team/*"}
+	assert.True(t, c.matches(Instance{Tags: []Tag{{Key: "Team", Value: "team/platform"}}}))
+}
+
+// Test that a connection with a single match connects straight away
+func TestConnectionSingleMatch(t *testing.T) {
+	c := Connection{Profile: "prod", Region: "us-east-1", Tag: "Name=web-server"}
+	result, calls := launchTestConnection(t, c, `{"Reservations": [{"Instances": [
+		{"InstanceId": "i-123", "State": {"Name": "running"}, "Tags": [{"Key": "Name", "Value": "web-server"}]}
+	]}]}`)
+
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, "i-123", result.selectedInstance)
+	assert.Equal(t, "prod", result.selectedProfile)
+	assert.Equal(t, "us-east-1", result.selectedRegion)
+	require.Len(t, calls, 1)
+	assert.Contains(t, strings.Join(calls[0], " "), "--filters Name=tag:Name,Values=web-server")
+}
+
+// Test picking between several matches
+func TestConnectionSeveralMatches(t *testing.T) {
+	c := Connection{Profile: "prod", Region: "us-east-1", Tag: "Name=web-*"}
+	result, _ := launchTestConnection(t, c, `{"Reservations": [{"Instances": [
+		{"InstanceId": "i-1", "State": {"Name": "running"}, "Tags": [{"Key": "Name", "Value": "web-b"}]},
+		{"InstanceId": "i-2", "State": {"Name": "running"}, "Tags": [{"Key": "Name", "Value": "web-a"}]}
+	]}]}`)
+
+	assert.Equal(t, stateInstance, result.step)
+	require.Len(t, result.filteredInstances, 2)
+	assert.Equal(t, "i-2", result.filteredInstances[0].ID)
+	assert.Equal(t, "2 instances match prod-web; pick one", result.toast)
+
+	updatedModel, _ := result.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "i-1", updatedModel.(model).selectedInstance)
+}
+
+// Test that a connection without a running match is an error
+func TestConnectionNoMatch(t *testing.T) {
+	c := Connection{Profile: "prod", Region: "us-east-1", Tag: "Name=web-server"}
+	result, _ := launchTestConnection(t, c, `{"Reservations": [{"Instances": [
+		{"InstanceId": "i-123", "State": {"Name": "stopped"}, "Tags": [{"Key": "Name", "Value": "web-server"}]}
+	]}]}`)
+
+	require.Error(t, result.err)
+	assert.Equal(t, "no running instance matches prod-web (Name=web-server in prod/us-east-1)", result.err.Error())
+}
+
+// Test validating connections in the config file
+func TestLoadConfigConnections(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "connections:\n  prod-web:\n    profile: prod\n    region: us-east-1\n    tag: Name=web-server\n")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Connection{Profile: "prod", Region: "us-east-1", Tag: "Name=web-server"}, cfg.Connections["prod-web"])
+
+	writeConfig(t, "connections:\n  prod-web:\n    profile: prod\n    region: us-east-1\n    tag: web-server\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, `connection "prod-web" needs a tag like Name=web-server`)
+
+	writeConfig(t, "connections:\n  prod-web:\n    region: us-east-1\n    tag: Name=web\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "needs a profile and a region")
+
+	writeConfig(t, "connections:\n  resolve:\n    profile: prod\n    region: us-east-1\n    tag: Name=web\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, `"resolve" is a subcommand`)
+}
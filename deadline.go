@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Set by -deadline: the longest the whole discovery flow, from launch until a
+// target is chosen, may take. 0 means no limit
+var discoveryDeadline time.Duration
+
+// discovery is the context every discovery lookup derives from, so that the
+// -deadline stops the AWS CLI processes still running when it passes.
+var discovery = context.Background()
+
+// deadlineError reports that -deadline passed before a target was chosen.
+type deadlineError struct {
+	after time.Duration
+}
+
+func (e deadlineError) Error() string {
+	return fmt.Sprintf("gave up after the -deadline of %s", e.after)
+}
+
+// startDeadline bounds discovery by d, when it is positive. The returned
+// function releases the deadline once discovery is over, so it doesn't cut
+// the session short.
+func startDeadline(d time.Duration) context.CancelFunc {
+	if d <= 0 {
+		return func() {}
+	}
+	var cancel context.CancelFunc
+	discovery, cancel = context.WithTimeout(context.Background(), d)
+	return cancel
+}
+
+// deadlineExceeded reports whether the -deadline has passed.
+func deadlineExceeded() bool {
+	return errors.Is(discovery.Err(), context.DeadlineExceeded)
+}
+
+// deadlineErr returns err, replaced by the deadline error when the -deadline
+// passing is what ended the lookup.
+func deadlineErr(err error) error {
+	if err != nil && deadlineExceeded() {
+		return deadlineError{discoveryDeadline}
+	}
+	return err
+}
+
+// waitDeadline sends the deadline error to the program through send once the
+// -deadline passes. It returns without sending when discovery ends in time.
+func waitDeadline(send func(msg tea.Msg)) {
+	<-discovery.Done()
+	if deadlineExceeded() {
+		send(struct {
+			deadlineErr error
+		}{deadlineError{discoveryDeadline}})
+	}
+}
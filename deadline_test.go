@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for setting -deadline for the length of a test
+func setDeadline(t *testing.T, d time.Duration) {
+	discoveryDeadline = d
+	stop := startDeadline(d)
+	t.Cleanup(func() {
+		stop()
+		discoveryDeadline = 0
+		discovery = context.Background()
+	})
+}
+
+// Test that -deadline kills a hung lookup and reports the deadline rather
+// than the per-lookup timeout
+func TestDeadlineStopsLookups(t *testing.T) {
+	originalExec := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "30")
+	}
+	t.Cleanup(func() { execCommand = originalExec })
+	setTempHome(t)
+	setDeadline(t, 20*time.Millisecond)
+
+	m := initialModel()
+	start := time.Now()
+	msg := regionsCmd(m.lookupContext(), "default", false)()
+	assert.Less(t, time.Since(start), 5*time.Second, "the lookup waited for the process")
+	m.step, m.loading = stateProfile, true
+	updatedModel, _ := m.Update(msg)
+	result := updatedModel.(model)
+	require.Error(t, result.err)
+	assert.Equal(t, "gave up after the -deadline of 20ms", result.err.Error())
+}
+
+// Test that the deadline quits the TUI with an error, unless a target was
+// already chosen
+func TestDeadlineQuits(t *testing.T) {
+	setDeadline(t, time.Millisecond)
+	var msg tea.Msg
+	waitDeadline(func(m tea.Msg) { msg = m })
+	require.NotNil(t, msg)
+
+	m := model{step: stateInstance}
+	updatedModel, cmd := m.Update(msg)
+	assert.ErrorContains(t, updatedModel.(model).err, "-deadline of 1ms")
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+
+	m.step = stateDone
+	updatedModel, cmd = m.Update(msg)
+	assert.NoError(t, updatedModel.(model).err)
+	assert.Nil(t, cmd)
+}
+
+// Test that a deadline released in time sends nothing
+func TestDeadlineStopped(t *testing.T) {
+	discoveryDeadline = time.Hour
+	stop := startDeadline(time.Hour)
+	t.Cleanup(func() {
+		discoveryDeadline = 0
+		discovery = context.Background()
+	})
+	stop()
+	sent := false
+	waitDeadline(func(tea.Msg) { sent = true })
+	assert.False(t, sent)
+	assert.NoError(t, deadlineErr(nil))
+}
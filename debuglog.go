@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	debugOnce   sync.Once
+	debugLogger *slog.Logger
+)
+
+// debugEnabled reports whether SSMSSH_DEBUG=1 asks for a debug log.
+func debugEnabled() bool {
+	return os.Getenv("SSMSSH_DEBUG") == "1"
+}
+
+func debugLogDir() string {
+	return filepath.Join(cacheDir(), "logs")
+}
+
+// debugLog returns the debug logger, creating a timestamped log file under
+// debugLogDir on first use. It returns nil when debugging is off or the file
+// can't be created; logging must never get in the way of connecting.
+func debugLog() *slog.Logger {
+	debugOnce.Do(func() {
+		if !debugEnabled() {
+			return
+		}
+		if err := os.MkdirAll(debugLogDir(), 0755); err != nil {
+			return
+		}
+		name := "ssmssh-" + time.Now().Format("20060102-150405") + ".log"
+		f, err := os.OpenFile(filepath.Join(debugLogDir(), name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return
+		}
+		debugLogger = slog.New(slog.NewJSONHandler(f, nil))
+	})
+	return debugLogger
+}
+
+// logCommand records a finished subprocess: its arguments, how long it took,
+// its exit code, and whatever it wrote to stderr.
+func logCommand(name string, args []string, start time.Time, err error, stderr string) {
+	logger := debugLog()
+	if logger == nil {
+		return
+	}
+	attrs := []any{
+		"command", name,
+		"args", args,
+		"duration_ms", time.Since(start).Milliseconds(),
+		"exit_code", exitCode(err),
+	}
+	if stderr != "" {
+		attrs = append(attrs, "stderr", stderr)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+		logger.Error("exec", attrs...)
+		return
+	}
+	logger.Info("exec", attrs...)
+}
+
+// exitCode returns the process exit code for err, or -1 if the process
+// couldn't be started or was killed.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for starting each test with a fresh debug logger
+func resetDebugLog(t *testing.T) {
+	debugOnce, debugLogger = sync.Once{}, nil
+	t.Cleanup(func() { debugOnce, debugLogger = sync.Once{}, nil })
+}
+
+// Helper function for stubbing the process the AWS CLI runs as
+func stubExec(t *testing.T, script string) {
+	original := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", script)
+	}
+	t.Cleanup(func() { execCommand = original })
+}
+
+// Test that AWS CLI invocations are written to the debug log
+func TestDebugLog(t *testing.T) {
+	setTempHome(t)
+	resetDebugLog(t)
+	t.Setenv("SSMSSH_DEBUG", "1")
+	stubExec(t, `echo "AccessDenied" >&2; exit 3`)
+
+	_, err := runAWS(context.Background(), "ec2", "describe-regions", "--profile", "default")
+	require.Error(t, err)
+
+	files, err := filepath.Glob(filepath.Join(debugLogDir(), "ssmssh-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var entry struct {
+		Level      string   `json:"level"`
+		Command    string   `json:"command"`
+		Args       []string `json:"args"`
+		DurationMs *int64   `json:"duration_ms"`
+		ExitCode   int      `json:"exit_code"`
+		Stderr     string   `json:"stderr"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "ERROR", entry.Level)
+	assert.Equal(t, "aws", entry.Command)
+	assert.Equal(t, []string{"ec2", "describe-regions", "--profile", "default"}, entry.Args)
+	assert.NotNil(t, entry.DurationMs)
+	assert.Equal(t, 3, entry.ExitCode)
+	assert.Equal(t, "AccessDenied", entry.Stderr)
+}
+
+// Test that nothing is logged unless SSMSSH_DEBUG=1
+func TestDebugLogDisabled(t *testing.T) {
+	setTempHome(t)
+	resetDebugLog(t)
+	t.Setenv("SSMSSH_DEBUG", "")
+	stubExec(t, `echo "{}"`)
+
+	_, err := runAWS(context.Background(), "ec2", "describe-regions")
+	require.NoError(t, err)
+	assert.NoDirExists(t, debugLogDir())
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Canned profiles, regions, and instances served by -demo
+//
+//go:embed fixtures/demo.json
+var demoFixtures []byte
+
+// Set by the -demo flag; all AWS calls are answered from demoFixtures
+var demoMode bool
+
+type demoInstance struct {
+	InstanceId string `json:"InstanceId"`
+	State      struct {
+		Name string `json:"Name"`
+	} `json:"State"`
+	InstanceType     string `json:"InstanceType,omitempty"`
+	PrivateIpAddress string `json:"PrivateIpAddress,omitempty"`
+	PrivateDnsName   string `json:"PrivateDnsName,omitempty"`
+	PublicIpAddress  string `json:"PublicIpAddress,omitempty"`
+	LaunchTime       string `json:"LaunchTime,omitempty"`
+	Placement        struct {
+		AvailabilityZone string `json:"AvailabilityZone,omitempty"`
+	} `json:"Placement"`
+	Tags []Tag `json:"Tags"`
+}
+
+type demoData struct {
+	Profiles  []string                  `json:"profiles"`
+	Aliases   map[string]string         `json:"aliases"`
+	Regions   []string                  `json:"regions"`
+	Instances map[string][]demoInstance `json:"instances"`
+}
+
+func loadDemoData() (demoData, error) {
+	var d demoData
+	if err := json.Unmarshal(demoFixtures, &d); err != nil {
+		return demoData{}, fmt.Errorf("invalid demo fixtures: %w", err)
+	}
+	return d, nil
+}
+
+// enableDemoMode routes every AWS CLI call to the embedded fixtures.
+func enableDemoMode() {
+	demoMode = true
+	runAWS = demoRunAWS
+}
+
+// argValue returns the value following flag in args.
+func argValue(args []string, flag string) string {
+	for i, a := range args {
+		if a == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// argValues returns the values following flag in args, up to the next flag.
+func argValues(args []string, flag string) []string {
+	values := []string{}
+	for i, a := range args {
+		if a != flag {
+			continue
+		}
+		for _, v := range args[i+1:] {
+			if strings.HasPrefix(v, "--") {
+				break
+			}
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// matchesFilters applies the tag and instance-state-name filters of
+// describe-instances; others are ignored.
+func (inst demoInstance) matchesFilters(filters []string) bool {
+	for _, f := range filters {
+		name, values, _ := strings.Cut(strings.TrimPrefix(f, "Name="), ",Values=")
+		var actual []string
+		switch {
+		case name == "instance-state-name":
+			actual = []string{inst.State.Name}
+		case strings.HasPrefix(name, "tag:"):
+			for _, tag := range inst.Tags {
+				if tag.Key == strings.TrimPrefix(name, "tag:") {
+					actual = append(actual, tag.Value)
+				}
+			}
+		default:
+			continue
+		}
+		matched := false
+		for _, pattern := range strings.Split(values, ",") {
+			for _, v := range actual {
+				if globMatch(pattern, v) {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// demoRunAWS answers AWS CLI invocations with JSON shaped like the real
+// responses, so the normal parsing code paths are exercised.
+func demoRunAWS(_ context.Context, args ...string) ([]byte, error) {
+	d, err := loadDemoData()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 2 {
+		return nil, fmt.Errorf("demo: unsupported command %q", strings.Join(args, " "))
+	}
+	switch args[0] + " " + args[1] {
+	case "ec2 describe-regions":
+		type region struct {
+			RegionName string `json:"RegionName"`
+		}
+		regions := []region{}
+		for _, r := range d.Regions {
+			regions = append(regions, region{r})
+		}
+		return json.Marshal(map[string]interface{}{"Regions": regions})
+	case "ec2 describe-instances":
+		instances := d.Instances[argValue(args, "--region")]
+		if ids := argValues(args, "--instance-ids"); len(ids) > 0 {
+			wanted := map[string]bool{}
+			for _, id := range ids {
+				wanted[id] = true
+			}
+			matched := []demoInstance{}
+			for _, inst := range instances {
+				if wanted[inst.InstanceId] {
+					matched = append(matched, inst)
+				}
+			}
+			instances = matched
+		}
+		if filters := argValues(args, "--filters"); len(filters) > 0 {
+			matched := []demoInstance{}
+			for _, inst := range instances {
+				if inst.matchesFilters(filters) {
+					matched = append(matched, inst)
+				}
+			}
+			instances = matched
+		}
+		if instances == nil {
+			instances = []demoInstance{}
+		}
+		return json.Marshal(map[string]interface{}{
+			"Reservations": []map[string]interface{}{{"Instances": instances}},
+		})
+	case "iam list-account-aliases":
+		aliases := []string{}
+		if alias, ok := d.Aliases[argValue(args, "--profile")]; ok {
+			aliases = append(aliases, alias)
+		}
+		return json.Marshal(map[string]interface{}{"AccountAliases": aliases})
+	case "sts get-caller-identity":
+		return []byte(`{"Account": "000000000000"}`), nil
+	}
+	return nil, fmt.Errorf("demo: unsupported command %q", strings.Join(args, " "))
+}
+
+func demoProfiles() ([]string, error) {
+	d, err := loadDemoData()
+	if err != nil {
+		return nil, err
+	}
+	return d.Profiles, nil
+}
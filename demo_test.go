@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+// Helper function for enabling demo mode and failing on any subprocess
+func withDemoMode(t *testing.T) {
+	originalRun, originalExec := runAWS, execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		t.Errorf("demo mode invoked exec: %s %v", name, arg)
+		return exec.CommandContext(ctx, "false")
+	}
+	enableDemoMode()
+	t.Cleanup(func() {
+		demoMode = false
+		runAWS, execCommand = originalRun, originalExec
+	})
+}
+
+// Test that demo fixtures drive the full selection flow
+func TestDemoModeSelectionFlow(t *testing.T) {
+	setTempHome(t)
+	withDemoMode(t)
+
+	m := initialModel()
+	require.NoError(t, m.err)
+	assert.Equal(t, []string{"demo-dev", "demo-staging", "demo-prod"}, m.profiles)
+
+	// Profile -> regions
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result := updatedModel.(model)
+	require.NoError(t, result.err)
+	assert.Equal(t, stateRegion, result.step)
+	assert.Equal(t, []string{"us-east-1", "us-west-2", "eu-west-1"}, result.regions)
+
+	// Region -> instances
+	updatedModel, cmd = result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result = updatedModel.(model)
+	require.NoError(t, result.err)
+	assert.Equal(t, stateInstance, result.step)
+	assert.Len(t, result.instances, 4)
+	// Sorted by name, unnamed instances last
+	assert.Equal(t, "i-0a1b2c3d4e5f60003 (batch-worker) [stopped]", result.instances[0].Display())
+	assert.Equal(t, "i-0a1b2c3d4e5f60001 (web-server-1) [running]", result.instances[1].Display())
+
+	// Instance details preview is served from fixtures too
+	details, err := getInstanceDetails(context.Background(), "demo-dev", "us-east-1", "i-0a1b2c3d4e5f60001")
+	require.NoError(t, err)
+	assert.Equal(t, "production", awsssm.TagValue(details.Tags, "Environment"))
+	assert.Equal(t, "us-east-1a", details.AvailabilityZone)
+
+	// Instance -> done
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result = updatedModel.(model)
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, "i-0a1b2c3d4e5f60001", result.selectedInstance)
+
+	// The session is printed, not started
+	assert.NoError(t, startSession(result.selectedProfile, result.selectedRegion, result.selectedInstance))
+}
+
+// Test demo account aliases and unsupported commands
+func TestDemoRunAWS(t *testing.T) {
+	setTempHome(t)
+	withDemoMode(t)
+
+	aliases := resolveAccountAliases(context.Background(), []string{"demo-dev", "demo-prod"})
+	assert.Equal(t, map[string]string{"demo-dev": "acme-nonprod", "demo-prod": "acme-prod"}, aliases)
+
+	instances, err := getInstances(context.Background(), "demo-dev", "eu-west-1", false)
+	assert.NoError(t, err)
+	assert.Empty(t, instances)
+
+	_, err = runAWS(context.Background(), "s3", "ls")
+	assert.Error(t, err)
+}
+
+// Test that demo tag filters match values containing slashes
+func TestDemoMatchesFilters(t *testing.T) {
+	inst := demoInstance{Tags: []Tag{{Key: "Team", Value: "team/platform"}}}
+	assert.True(t, inst.matchesFilters([]string{"Name=tag:Team,Values=team/*"}))
+	assert.False(t, inst.matchesFilters([]string{"Name=tag:Team,Values=team/web*"}))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"goversion/pkg/awsssm"
+)
+
+// Set by -describe: print the describe-instances JSON of the selection instead
+// of starting a session. With -target, -profile and -region the picker is
+// skipped.
+var (
+	describeOutput bool
+	describeTarget string
+)
+
+// describeInstances writes the describe-instances output for targets to w,
+// indented. Targets in several regions, as marked in the all-regions view,
+// are described with one call per region, and their reservations are merged
+// into one document in the order the regions first appear, so the output
+// stays valid JSON.
+func describeInstances(ctx context.Context, w io.Writer, profile string, targets []Instance) error {
+	regions := []string{}
+	ids := map[string][]string{}
+	for _, inst := range targets {
+		if _, ok := ids[inst.Region]; !ok {
+			regions = append(regions, inst.Region)
+		}
+		ids[inst.Region] = append(ids[inst.Region], inst.ID)
+	}
+	merged := struct {
+		Reservations []json.RawMessage `json:"Reservations"`
+	}{Reservations: []json.RawMessage{}}
+	for _, region := range regions {
+		args := []string{"ec2", "describe-instances", "--profile", profile, "--region", region, "--instance-ids"}
+		args = append(args, ids[region]...)
+		out, err := describeAWS(ctx, append(args, "--output", "json")...)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Reservations []json.RawMessage `json:"Reservations"`
+		}
+		if err := awsssm.DecodeOutput(out, &result); err != nil {
+			return fmt.Errorf("unexpected describe-instances output: %w", err)
+		}
+		merged.Reservations = append(merged.Reservations, result.Reservations...)
+	}
+	out, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// describeTargetFlag describes -target in -region of -profile without the
+// picker.
+func describeTargetFlag(w io.Writer) error {
+	if listProfile == "" || listRegion == "" {
+		return fmt.Errorf("-describe -target requires -profile and -region")
+	}
+	return describeInstances(discovery, w, listProfile, []Instance{{ID: describeTarget, Region: listRegion}})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test printing indented describe-instances output, one call per region
+func TestDescribeInstances(t *testing.T) {
+	var calls [][]string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"` + args[len(args)-3] + `"}]}]}` + "\n"), nil
+	})
+	var out bytes.Buffer
+	err := describeInstances(context.Background(), &out, "prod", []Instance{
+		{ID: "i-1", Region: "eu-west-1"},
+		{ID: "i-2", Region: "us-east-1"},
+		{ID: "i-3", Region: "eu-west-1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	assert.Equal(t, []string{"ec2", "describe-instances", "--profile", "prod", "--region", "eu-west-1", "--instance-ids", "i-1", "i-3", "--output", "json"}, calls[0])
+	assert.Equal(t, "us-east-1", calls[1][5])
+	assert.Contains(t, out.String(), "{\n  \"Reservations\": [\n    {\n      \"Instances\": [")
+
+	// The regions' reservations are merged into one JSON document
+	var merged struct {
+		Reservations []struct {
+			Instances []struct{ InstanceId string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &merged))
+	require.Len(t, merged.Reservations, 2)
+	assert.Equal(t, "i-3", merged.Reservations[0].Instances[0].InstanceId)
+	assert.Equal(t, "i-2", merged.Reservations[1].Instances[0].InstanceId)
+}
+
+// Test that CLI warnings before the JSON don't break -describe
+func TestDescribeInstancesNoise(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return []byte("WARNING: this version of the AWS CLI is deprecated\n" + `{"Reservations":[{"Instances":[{"InstanceId":"i-1"}]}]}`), nil
+	})
+	var out bytes.Buffer
+	require.NoError(t, describeInstances(context.Background(), &out, "prod", []Instance{{ID: "i-1", Region: "us-east-1"}}))
+	assert.NotContains(t, out.String(), "WARNING")
+	assert.Contains(t, out.String(), `"InstanceId": "i-1"`)
+}
+
+// Test -describe -target without the picker
+func TestDescribeTargetFlag(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return []byte(`{"Reservations":[]}`), nil
+	})
+	describeTarget = "i-123"
+	t.Cleanup(func() { describeTarget, listProfile, listRegion = "", "", "" })
+
+	var out bytes.Buffer
+	assert.EqualError(t, describeTargetFlag(&out), "-describe -target requires -profile and -region")
+	listProfile, listRegion = "prod", "us-east-1"
+	require.NoError(t, describeTargetFlag(&out))
+	assert.Equal(t, "{\n  \"Reservations\": []\n}\n", out.String())
+}
+
+// Test that -describe finishes on selection without preparing a session
+func TestDescribeSkipsSession(t *testing.T) {
+	describeOutput = true
+	t.Cleanup(func() { describeOutput = false })
+	instances := []Instance{{ID: "i-123", Name: "web", State: "running", VpcId: "vpc-1"}}
+	m := model{step: stateInstance, instances: instances, filteredInstances: instances, selectedProfile: "prod", selectedRegion: "us-east-1", precheck: true}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result := updatedModel.(model)
+	assert.Equal(t, stateDone, result.step)
+	assert.False(t, result.loading)
+	assert.Equal(t, tea.Quit(), cmd())
+}
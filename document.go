@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Set by -document-name and -parameters: the SSM document shell sessions run
+// instead of the default SSM-SessionManagerRunShell, and its parameters
+var (
+	sessionDocument   string
+	sessionParameters string
+)
+
+// Set by -idle-timeout: minutes of inactivity before SSM ends the session,
+// passed to -document-name as its idleSessionTimeout parameter
+var idleTimeout int
+
+// Parameter a custom session document declares to take -idle-timeout
+const idleTimeoutParameter = "idleSessionTimeout"
+
+// validateSessionDocument rejects -parameters without -document-name, which
+// the default shell document would not accept, and an -idle-timeout that
+// can't be passed on.
+func validateSessionDocument() error {
+	if sessionParameters != "" && sessionDocument == "" {
+		return errors.New("-parameters requires -document-name")
+	}
+	if idleTimeout == 0 {
+		return nil
+	}
+	if idleTimeout < 1 || idleTimeout > 60 {
+		return errors.New("-idle-timeout must be between 1 and 60 minutes")
+	}
+	// The default document takes its timeout from the account's Session
+	// Manager preferences and has no parameter for it
+	if sessionDocument == "" {
+		return errors.New("-idle-timeout requires -document-name with an idleSessionTimeout parameter")
+	}
+	_, err := shellSessionParameters()
+	return err
+}
+
+// shellSessionParameters returns -parameters with -idle-timeout added, in
+// the same form -parameters was given: JSON, or AWS CLI shorthand.
+func shellSessionParameters() (string, error) {
+	if idleTimeout == 0 {
+		return sessionParameters, nil
+	}
+	timeout := strconv.Itoa(idleTimeout)
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(sessionParameters), "{"):
+		params := map[string]any{}
+		if err := json.Unmarshal([]byte(sessionParameters), &params); err != nil {
+			return "", fmt.Errorf("invalid -parameters JSON: %w", err)
+		}
+		params[idleTimeoutParameter] = []string{timeout}
+		data, err := json.Marshal(params)
+		return string(data), err
+	case sessionParameters != "":
+		return sessionParameters + "," + idleTimeoutParameter + "=" + timeout, nil
+	}
+	data, err := json.Marshal(map[string][]string{idleTimeoutParameter: {timeout}})
+	return string(data), err
+}
+
+// shellSessionArgs returns the start-session arguments for an interactive
+// session, using -document-name, -parameters and -idle-timeout when set.
+func shellSessionArgs(profile, region, instanceId string) []string {
+	args := sessionArgs(profile, region, instanceId)
+	if sessionDocument != "" {
+		args = append(args, "--document-name", sessionDocument)
+	}
+	// validateSessionDocument has already rejected parameters that don't parse
+	if params, _ := shellSessionParameters(); params != "" {
+		args = append(args, "--parameters", params)
+	}
+	return args
+}
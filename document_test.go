@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// Helper function for setting -document-name and -parameters for one test
+func withSessionDocument(t *testing.T, document, parameters string) {
+	sessionDocument, sessionParameters = document, parameters
+	t.Cleanup(func() { sessionDocument, sessionParameters = "", "" })
+}
+
+// Test the start-session arguments for custom session documents
+func TestShellSessionArgs(t *testing.T) {
+	t.Run("default shell", func(t *testing.T) {
+		assert.Equal(t, sessionArgs("prod", "us-east-1", "i-123"), shellSessionArgs("prod", "us-east-1", "i-123"))
+	})
+
+	t.Run("custom document with parameters", func(t *testing.T) {
+		withSessionDocument(t, "Corp-BashAsAppUser", `{"user":["app"]}`)
+		assert.Equal(t, []string{
+			"ssm", "start-session", "--profile", "prod", "--region", "us-east-1", "--target", "i-123",
+			"--document-name", "Corp-BashAsAppUser", "--parameters", `{"user":["app"]}`,
+		}, shellSessionArgs("prod", "us-east-1", "i-123"))
+	})
+
+	t.Run("port forwarding keeps its own document", func(t *testing.T) {
+		withSessionDocument(t, "Corp-BashAsAppUser", "")
+		assert.Contains(t, portForwardArgs("prod", "us-east-1", "i-123", 8080, 80), portForwardDocument)
+		assert.NotContains(t, portForwardArgs("prod", "us-east-1", "i-123", 8080, 80), "Corp-BashAsAppUser")
+	})
+}
+
+// Test flag validation for -parameters
+func TestValidateSessionDocument(t *testing.T) {
+	withSessionDocument(t, "", "command=bash")
+	assert.EqualError(t, validateSessionDocument(), "-parameters requires -document-name")
+
+	withSessionDocument(t, "Corp-Shell", "command=bash")
+	assert.NoError(t, validateSessionDocument())
+}
+
+// Helper function for setting -idle-timeout for one test
+func withIdleTimeout(t *testing.T, minutes int) {
+	original := idleTimeout
+	idleTimeout = minutes
+	t.Cleanup(func() { idleTimeout = original })
+}
+
+// Test passing -idle-timeout to a custom session document
+func TestIdleTimeout(t *testing.T) {
+	withIdleTimeout(t, 20)
+
+	t.Run("requires a custom document", func(t *testing.T) {
+		withSessionDocument(t, "", "")
+		assert.ErrorContains(t, validateSessionDocument(), "-idle-timeout requires -document-name")
+	})
+
+	t.Run("range", func(t *testing.T) {
+		withSessionDocument(t, "Corp-Shell", "")
+		withIdleTimeout(t, 90)
+		assert.EqualError(t, validateSessionDocument(), "-idle-timeout must be between 1 and 60 minutes")
+	})
+
+	t.Run("added to the parameters", func(t *testing.T) {
+		tests := []struct {
+			parameters string
+			expected   string
+		}{
+			{"", `{"idleSessionTimeout":["20"]}`},
+			{`{"user":["app"]}`, `{"idleSessionTimeout":["20"],"user":["app"]}`},
+			{"user=app", "user=app,idleSessionTimeout=20"},
+		}
+		for _, tt := range tests {
+			withSessionDocument(t, "Corp-Shell", tt.parameters)
+			assert.NoError(t, validateSessionDocument())
+			args := shellSessionArgs("prod", "us-east-1", "i-123")
+			assert.Equal(t, []string{"--parameters", tt.expected}, args[len(args)-2:])
+		}
+	})
+
+	t.Run("malformed JSON parameters", func(t *testing.T) {
+		withSessionDocument(t, "Corp-Shell", `{"user":`)
+		assert.ErrorContains(t, validateSessionDocument(), "invalid -parameters JSON")
+	})
+}
+
+// Test that the confirmation screen names the custom document
+func TestConfirmShowsDocument(t *testing.T) {
+	withSessionDocument(t, "Corp-BashAsAppUser", "")
+	updatedModel, _ := confirmModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, updatedModel.(model).View(), "Document: Corp-BashAsAppUser")
+}
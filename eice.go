@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"goversion/pkg/awsssm"
+)
+
+// Set by -eice-user: the login used for SSH through an EC2 Instance Connect Endpoint
+var eiceUser = "ec2-user"
+
+// Ways to connect offered on the connection method step, in display order
+var connectionMethods = []string{"SSM Session Manager", "EC2 Instance Connect Endpoint"}
+
+// endpointsCmd lists the EC2 Instance Connect Endpoints of the selected
+// instance's region.
+func endpointsCmd(ctx context.Context, profile, region string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		endpoints, err := awsssm.GetInstanceConnectEndpoints(ctx, describeRunner, profile, region)
+		return struct {
+			endpoints    []awsssm.InstanceConnectEndpoint
+			endpointsErr error
+		}{endpoints, lookupErr(ctx, err, "instance connect endpoints", loadTimeout)}
+	}
+}
+
+// reachableByEndpoint reports whether one of endpoints can tunnel to inst.
+func reachableByEndpoint(inst Instance, endpoints []awsssm.InstanceConnectEndpoint) bool {
+	for _, e := range endpoints {
+		if e.Ready() && inst.VpcId != "" && e.VpcId == inst.VpcId {
+			return true
+		}
+	}
+	return false
+}
+
+// updateEndpoints offers the choice of connection method when the selected
+// instance is reachable through an endpoint. Otherwise, including when the
+// endpoints can't be listed (e.g. missing permissions), SSM is used as before.
+func (m model) updateEndpoints(endpoints []awsssm.InstanceConnectEndpoint, err error) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if err != nil || !reachableByEndpoint(m.selectedInstanceInfo(), endpoints) {
+		return m.startSSM()
+	}
+	m.step = stateMethod
+	m.cursor = 0
+	return m, nil
+}
+
+// updateMethod handles key input on the connection method step.
+func (m model) updateMethod(s string) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	switch {
+	case keys.matches(actionUp, s):
+		m.cursor = max(m.cursor-1, 0)
+	case keys.matches(actionDown, s):
+		m.cursor = min(m.cursor+1, len(connectionMethods)-1)
+	case keys.matches(actionBack, s):
+		return m.back(), nil
+	case keys.matches(actionSelect, s):
+		if m.cursor == 0 {
+			return m.startSSM()
+		}
+		m.sessionMode = sessionEICE
+		// The SSM agent plays no part in an endpoint tunnel, so skip -precheck
+		return m.proceed()
+	}
+	return m, nil
+}
+
+func (m model) methodView() string {
+	w := m.contentWidth()
+	content := headerStyle.Render(fit("Connect with", w, 2)) + "\n"
+	content += infoStyle.Render(fit("Instance: "+m.selectedInstanceInfo().Display(), w, 2)) + "\n"
+	for i, method := range connectionMethods {
+		if m.cursor == i {
+			content += selectedStyle.Render("> "+fit(method, w, 4)) + "\n"
+		} else {
+			content += itemStyle.Render("  "+fit(method, w, 4)) + "\n"
+		}
+	}
+	content += quitStyle.Render(fit("enter: connect • ←/h: back • ?: help • esc: quit", w, 0))
+	return borderStyle.Render(content)
+}
+
+// openTunnelArgs returns the AWS CLI arguments that tunnel stdin/stdout to
+// the instance's SSH port through its EC2 Instance Connect Endpoint.
+func openTunnelArgs(profile, region, instanceId string) []string {
+	return []string{"ec2-instance-connect", "open-tunnel", "--instance-id", instanceId, "--profile", profile, "--region", region}
+}
+
+// eiceSSHArgs returns the ssh arguments for a shell over an endpoint tunnel.
+func eiceSSHArgs(profile, region, instanceId string) []string {
+	return []string{"-o", "ProxyCommand=aws " + shellJoin(openTunnelArgs(profile, region, instanceId)), eiceUser + "@" + instanceId}
+}
+
+// startEICESession runs ssh attached to the terminal, tunnelled through the
+// instance's EC2 Instance Connect Endpoint.
+func startEICESession(profile, region, instanceId string) error {
+	return runSSH(eiceSSHArgs(profile, region, instanceId), os.Stderr)
+}
+
+// runSSH runs ssh attached to the terminal, with its stderr going to stderr.
+func runSSH(args []string, stderr io.Writer) error {
+	if demoMode {
+		fmt.Printf("Demo mode, would run: ssh %s\n", shellJoin(args))
+		return nil
+	}
+	cmd := execCommand(context.Background(), "ssh", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = stderr
+	cmd.Stdin = os.Stdin
+	if !quiet {
+		fmt.Printf("Running: ssh %s\n", shellJoin(args))
+	}
+	start := time.Now()
+	err := runAttached(cmd)
+	logCommand("ssh", args, start, err, "")
+	return err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+const testInstanceConnectEndpoints = `{"InstanceConnectEndpoints": [
+	{"InstanceConnectEndpointId": "eice-1", "VpcId": "vpc-app", "State": "create-complete"},
+	{"InstanceConnectEndpointId": "eice-2", "VpcId": "vpc-data", "State": "create-in-progress"}
+]}`
+
+// Helper function for pressing enter on an instance in vpc and answering the
+// endpoint lookup
+func selectInVpc(t *testing.T, vpc string) model {
+	instances := []Instance{{ID: "i-123", Name: "web-1", State: "running", VpcId: vpc}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+	}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	result := updatedModel.(model)
+	require.True(t, result.loading)
+	msg := endpointsCmd(context.Background(), result.selectedProfile, result.selectedRegion)()
+	updatedModel, _ = result.Update(msg)
+	return updatedModel.(model)
+}
+
+// Test choosing between SSM and an EC2 Instance Connect Endpoint
+func TestConnectionMethod(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		if args[1] != "describe-instance-connect-endpoints" {
+			return nil, errors.New("unexpected call")
+		}
+		return []byte(testInstanceConnectEndpoints), nil
+	})
+
+	t.Run("reachable instance offers both methods", func(t *testing.T) {
+		result := selectInVpc(t, "vpc-app")
+		require.Equal(t, stateMethod, result.step)
+		view := result.View()
+		assert.Contains(t, view, "SSM Session Manager")
+		assert.Contains(t, view, "EC2 Instance Connect Endpoint")
+
+		updatedModel, _ := result.Update(tea.KeyMsg{Type: tea.KeyDown})
+		updatedModel, cmd := updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result = updatedModel.(model)
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, sessionEICE, result.sessionMode)
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("SSM stays the default", func(t *testing.T) {
+		updatedModel, _ := selectInVpc(t, "vpc-app").Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result := updatedModel.(model)
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, sessionShell, result.sessionMode)
+	})
+
+	t.Run("back returns to the instance list", func(t *testing.T) {
+		result := selectInVpc(t, "vpc-app").back()
+		assert.Equal(t, stateInstance, result.step)
+	})
+
+	t.Run("no ready endpoint in the VPC connects with SSM", func(t *testing.T) {
+		result := selectInVpc(t, "vpc-data")
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, sessionShell, result.sessionMode)
+	})
+}
+
+// Test that a failed endpoint lookup falls back to SSM
+func TestConnectionMethodLookupFails(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, errors.New("UnauthorizedOperation")
+	})
+	result := selectInVpc(t, "vpc-app")
+	assert.Nil(t, result.err)
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, sessionShell, result.sessionMode)
+}
+
+// Test the ssh command line for an endpoint tunnel
+func TestEICESSHArgs(t *testing.T) {
+	args := eiceSSHArgs("prod", "eu-west-1", "i-123")
+	assert.Equal(t, "-o 'ProxyCommand=aws ec2-instance-connect open-tunnel --instance-id i-123 --profile prod --region eu-west-1' ec2-user@i-123", shellJoin(args))
+	assert.True(t, reachableByEndpoint(Instance{VpcId: "vpc-1"}, []awsssm.InstanceConnectEndpoint{{VpcId: "vpc-1", State: "create-complete"}}))
+	assert.False(t, reachableByEndpoint(Instance{}, []awsssm.InstanceConnectEndpoint{{State: "create-complete"}}))
+}
+
+// Test that -cmd skips the connection method step, since it always uses SSM
+func TestConnectionMethodSkippedForCommand(t *testing.T) {
+	remoteCommand = "uptime"
+	t.Cleanup(func() { remoteCommand = "" })
+	instances := []Instance{{ID: "i-123", State: "running", VpcId: "vpc-app"}}
+	m := model{step: stateInstance, instances: instances, filteredInstances: instances}
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stateDone, updatedModel.(model).step)
+	assert.Contains(t, updatedModel.(model).View(), "Running uptime...")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Row colors for common Environment tag values, matched as case-insensitive
+// prefixes so "prod" also covers "production" and "prod-eu"
+var defaultEnvironmentColors = map[string]string{
+	"prod":  "#FF5555",
+	"stag":  "#FFD700",
+	"uat":   "#FFD700",
+	"qa":    "#FFB86C",
+	"test":  "#FFB86C",
+	"dev":   "#50FA7B",
+	"sandb": "#8BE9FD",
+}
+
+// instanceEnvironment returns the value of the instance's Environment (or
+// env) tag, matching the key case-insensitively.
+func instanceEnvironment(tags []Tag) string {
+	for _, tag := range tags {
+		switch strings.ToLower(tag.Key) {
+		case "environment", "env":
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// environmentColor returns the row color for an environment value. Entries in
+// overrides win over the defaults; within each, the longest matching prefix
+// wins.
+func environmentColor(env string, overrides map[string]string) (lipgloss.Color, bool) {
+	if env == "" {
+		return "", false
+	}
+	env = strings.ToLower(env)
+	for _, colors := range []map[string]string{overrides, defaultEnvironmentColors} {
+		prefixes := make([]string, 0, len(colors))
+		for prefix := range colors {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Slice(prefixes, func(i, j int) bool {
+			if len(prefixes[i]) != len(prefixes[j]) {
+				return len(prefixes[i]) > len(prefixes[j])
+			}
+			return prefixes[i] < prefixes[j]
+		})
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(env, strings.ToLower(prefix)) {
+				return lipgloss.Color(colors[prefix]), true
+			}
+		}
+	}
+	return "", false
+}
+
+// instanceRowStyle returns the style for an unselected, connectable instance
+// row, colored by its environment when one is recognised.
+func (m model) instanceRowStyle(inst Instance) lipgloss.Style {
+	if color, ok := environmentColor(instanceEnvironment(inst.Tags), m.config.EnvironmentColors); ok {
+		return itemStyle.Copy().Foreground(color)
+	}
+	return itemStyle
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Environment tag lookup
+func TestInstanceEnvironment(t *testing.T) {
+	assert.Equal(t, "production", instanceEnvironment([]Tag{{Key: "Name", Value: "web"}, {Key: "Environment", Value: "production"}}))
+	assert.Equal(t, "dev", instanceEnvironment([]Tag{{Key: "env", Value: "dev"}}))
+	assert.Equal(t, "", instanceEnvironment([]Tag{{Key: "Team", Value: "data"}}))
+}
+
+// Test environment color defaults and config overrides
+func TestEnvironmentColor(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		overrides map[string]string
+		color     lipgloss.Color
+		ok        bool
+	}{
+		{"production is red", "production", nil, "#FF5555", true},
+		{"case insensitive prefix", "Staging-EU", nil, "#FFD700", true},
+		{"development is green", "development", nil, "#50FA7B", true},
+		{"unknown environment", "perf", nil, "", false},
+		{"no environment", "", nil, "", false},
+		{"override wins", "production", map[string]string{"prod": "#FF00FF"}, "#FF00FF", true},
+		{"new environment", "perf", map[string]string{"perf": "201"}, "201", true},
+		{"longest override prefix wins", "prod-eu", map[string]string{"prod": "1", "prod-eu": "2"}, "2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color, ok := environmentColor(tt.env, tt.overrides)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.color, color)
+		})
+	}
+}
+
+// Test environment colors configured in the config file
+func TestEnvironmentColorsConfig(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "environment_colors:\n  prod: \"#FF00FF\"\n  perf: \"201\"\n")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"prod": "#FF00FF", "perf": "201"}, cfg.EnvironmentColors)
+
+	m := model{config: cfg}
+	inst := Instance{ID: "i-123", Tags: []Tag{{Key: "Environment", Value: "perf"}}}
+	assert.Equal(t, lipgloss.Color("201"), m.instanceRowStyle(inst).GetForeground())
+	assert.Equal(t, itemStyle.GetForeground(), m.instanceRowStyle(Instance{ID: "i-456"}).GetForeground())
+}
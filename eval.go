@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set by -eval: print the selection as shell exports, for
+// eval "$(ssmssh -eval)", instead of starting a session
+var evalOutput bool
+
+// evalExports renders the selected profile, region, and targets as export
+// statements. Several marked targets are exported space-separated in
+// SSM_TARGET; the region is the first target's when none was selected, as
+// in the all-regions view. With the credentials of an assumed role, those are
+// exported in place of the profile.
+func evalExports(profile, region string, targets []Instance, creds roleCredentials) string {
+	ids := make([]string, len(targets))
+	for i, inst := range targets {
+		ids[i] = inst.ID
+	}
+	if region == "" && len(targets) > 0 {
+		region = targets[0].Region
+	}
+	var b strings.Builder
+	if creds.AccessKeyId == "" {
+		fmt.Fprintf(&b, "export AWS_PROFILE=%s;\n", shellJoin([]string{profile}))
+	} else {
+		b.WriteString("unset AWS_PROFILE;\n")
+		for _, kv := range creds.env() {
+			name, value, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(&b, "export %s=%s;\n", name, shellJoin([]string{value}))
+		}
+	}
+	fmt.Fprintf(&b, "export AWS_REGION=%s;\n", shellJoin([]string{region}))
+	fmt.Fprintf(&b, "export SSM_TARGET=%s;\n", shellJoin([]string{strings.Join(ids, " ")}))
+	return b.String()
+}
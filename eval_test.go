@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test rendering the selection as shell exports
+func TestEvalExports(t *testing.T) {
+	assert.Equal(t, "export AWS_PROFILE=prod;\nexport AWS_REGION=us-east-1;\nexport SSM_TARGET=i-123;\n",
+		evalExports("prod", "us-east-1", []Instance{{ID: "i-123"}}, roleCredentials{}))
+
+	// Marked instances in the all-regions view, and a profile that needs quoting
+	assert.Equal(t, "export AWS_PROFILE='my profile';\nexport AWS_REGION=eu-west-1;\nexport SSM_TARGET='i-1 i-2';\n",
+		evalExports("my profile", "", []Instance{{ID: "i-1", Region: "eu-west-1"}, {ID: "i-2", Region: "us-east-1"}}, roleCredentials{}))
+}
+
+// Test exporting an assumed role's credentials in place of the profile
+func TestEvalExportsRole(t *testing.T) {
+	creds := roleCredentials{AccessKeyId: "ASIAEXAMPLE", SecretAccessKey: "secret/key+1", SessionToken: "token"}
+	assert.Equal(t, "unset AWS_PROFILE;\n"+
+		"export AWS_ACCESS_KEY_ID=ASIAEXAMPLE;\n"+
+		"export AWS_SECRET_ACCESS_KEY=secret/key+1;\n"+
+		"export AWS_SESSION_TOKEN=token;\n"+
+		"export AWS_REGION=us-east-1;\nexport SSM_TARGET=i-123;\n",
+		evalExports("prod", "us-east-1", []Instance{{ID: "i-123"}}, creds))
+}
+
+// Test that -eval finishes on selection without preparing a session
+func TestEvalSkipsSession(t *testing.T) {
+	evalOutput = true
+	t.Cleanup(func() { evalOutput = false })
+	instances := []Instance{{ID: "i-123", Name: "web", State: "running", VpcId: "vpc-1"}}
+	m := model{step: stateInstance, instances: instances, filteredInstances: instances, selectedProfile: "prod", selectedRegion: "us-east-1", precheck: true, confirm: true}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result := updatedModel.(model)
+	assert.Equal(t, stateDone, result.step)
+	assert.False(t, result.loading, "no endpoint or agent lookups")
+	assert.Equal(t, tea.Quit(), cmd())
+	assert.Equal(t, "export AWS_PROFILE=prod;\nexport AWS_REGION=us-east-1;\nexport SSM_TARGET=i-123;\n",
+		evalExports(result.selectedProfile, result.selectedRegion, result.sessionTargets(), roleCredentials{}))
+}
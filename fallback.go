@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Connection methods that can be chained with -methods or connection_methods
+const (
+	methodSSMSession = "ssm-session"
+	methodSSMSSH     = "ssm-ssh"
+	methodEICE       = "ec2-instance-connect"
+)
+
+var connectionMethodNames = []string{methodSSMSession, methodSSMSSH, methodEICE}
+
+// Set by -methods: the connection methods a shell session tries in order,
+// overriding connection_methods in the config
+var methodsFlag string
+
+// Fragments of the errors printed when a method can't reach the instance at
+// all, as opposed to a session that started and then failed: the SSM agent
+// being offline, and ssh's ProxyCommand (an SSM or endpoint tunnel) exiting
+// before the connection was made.
+var unreachableMarkers = []string{
+	"TargetNotConnected",
+	"Connection closed by UNKNOWN port 65535",
+}
+
+// parseMethods splits a comma-separated list of connection methods and
+// validates it.
+func parseMethods(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	methods := strings.Split(s, ",")
+	for i := range methods {
+		methods[i] = strings.TrimSpace(methods[i])
+	}
+	return methods, validateMethods(methods)
+}
+
+// validateMethods rejects unknown and repeated connection methods.
+func validateMethods(methods []string) error {
+	seen := map[string]bool{}
+	for _, method := range methods {
+		if !slices.Contains(connectionMethodNames, method) {
+			return fmt.Errorf("unknown connection method %q; use %s", method, strings.Join(connectionMethodNames, ", "))
+		}
+		if seen[method] {
+			return fmt.Errorf("connection method %q is listed twice", method)
+		}
+		seen[method] = true
+	}
+	return nil
+}
+
+// connectionChain returns the methods a shell session tries: -methods, else
+// connection_methods from the config. Nil means a plain SSM session.
+func connectionChain(cfg Config) []string {
+	if methods, _ := parseMethods(methodsFlag); len(methods) > 0 {
+		return methods
+	}
+	return cfg.ConnectionMethods
+}
+
+// stderrTail keeps the end of what a session writes to stderr while passing
+// it on, so a failure can be classified after the session exits.
+type stderrTail struct {
+	w    io.Writer
+	tail []byte
+}
+
+// Bytes of stderr kept; the errors of interest are the last lines written
+const stderrTailSize = 4096
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.tail = append(t.tail, p...)
+	if len(t.tail) > stderrTailSize {
+		t.tail = t.tail[len(t.tail)-stderrTailSize:]
+	}
+	return t.w.Write(p)
+}
+
+// isUnreachable reports whether a session that ended with err and wrote
+// stderr never reached the instance, so the next method is worth a try.
+func isUnreachable(err error, stderr string) bool {
+	if err == nil {
+		return false
+	}
+	for _, marker := range unreachableMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// startMethod starts a shell on the instance with method, sending the
+// session's stderr to stderr.
+func startMethod(method, profile, region, instanceId, user string, stderr io.Writer) error {
+	switch method {
+	case methodSSMSSH:
+		target := instanceId
+		if user != "" {
+			target = user + "@" + instanceId
+		}
+		return runSSH([]string{"-o", "ProxyCommand=" + shellJoin(sshProxyArgs(profile, region, instanceId)), target}, stderr)
+	case methodEICE:
+		return runSSH(eiceSSHArgs(profile, region, instanceId), stderr)
+	}
+	return runSessionTo(shellSessionArgs(profile, region, instanceId), stderr)
+}
+
+// connectWithFallback starts a shell with each of methods in turn, moving on
+// to the next only while they can't reach the instance. Each switch is
+// announced on w. The last method's error is returned.
+func connectWithFallback(w io.Writer, methods []string, profile, region, instanceId, user string) error {
+	var err error
+	for i, method := range methods {
+		if i > 0 {
+			fmt.Fprintf(w, "%s couldn't reach %s; trying %s\n", methods[i-1], instanceId, method)
+		}
+		stderr := &stderrTail{w: os.Stderr}
+		err = startMethod(method, profile, region, instanceId, user, stderr)
+		if !isUnreachable(err, string(stderr.tail)) {
+			return err
+		}
+	}
+	return err
+}
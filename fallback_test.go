@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test parsing -methods and connection_methods
+func TestParseMethods(t *testing.T) {
+	methods, err := parseMethods("ssm-session, ec2-instance-connect")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ssm-session", "ec2-instance-connect"}, methods)
+
+	methods, err = parseMethods("")
+	assert.NoError(t, err)
+	assert.Nil(t, methods)
+
+	_, err = parseMethods("ssm-session,telnet")
+	assert.ErrorContains(t, err, `unknown connection method "telnet"`)
+	_, err = parseMethods("ssm-ssh,ssm-ssh")
+	assert.ErrorContains(t, err, "listed twice")
+
+	setTempHome(t)
+	writeConfig(t, "connection_methods: [ssm-session, rdp]\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, `unknown connection method "rdp"`)
+
+	methodsFlag = "ssm-ssh"
+	t.Cleanup(func() { methodsFlag = "" })
+	assert.Equal(t, []string{"ssm-ssh"}, connectionChain(Config{ConnectionMethods: []string{"ssm-session"}}))
+}
+
+// Test telling an unreachable instance from a session that failed later
+func TestIsUnreachable(t *testing.T) {
+	failed := errors.New("exit status 254")
+	assert.True(t, isUnreachable(failed, "An error occurred (TargetNotConnected) when calling the StartSession operation: i-123 is not connected.\n"))
+	assert.True(t, isUnreachable(failed, "Connection closed by UNKNOWN port 65535\r\n"))
+	assert.False(t, isUnreachable(failed, "Exiting session with sessionId: me-0123.\n"))
+	assert.False(t, isUnreachable(nil, "TargetNotConnected"))
+}
+
+// Test moving down the chain while the instance can't be reached
+func TestConnectWithFallback(t *testing.T) {
+	var started []string
+	original := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		started = append(started, name+" "+strings.Join(arg, " "))
+		if name == "ssh" && strings.Contains(strings.Join(arg, " "), "open-tunnel") {
+			return exec.CommandContext(ctx, "sh", "-c", "exit 0")
+		}
+		// Both SSM methods fail while the agent is offline
+		return exec.CommandContext(ctx, "sh", "-c", `echo "An error occurred (TargetNotConnected)" >&2; exit 254`)
+	}
+	t.Cleanup(func() { execCommand = original })
+
+	var out bytes.Buffer
+	err := connectWithFallback(&out, []string{methodSSMSession, methodSSMSSH, methodEICE}, "prod", "us-east-1", "i-123", "ubuntu")
+	require.NoError(t, err)
+	require.Len(t, started, 3)
+	assert.Contains(t, started[0], "ssm start-session --profile prod --region us-east-1 --target i-123")
+	assert.Contains(t, started[1], "ssh -o ProxyCommand=aws ssm start-session")
+	assert.Contains(t, started[1], "ubuntu@i-123")
+	assert.Contains(t, started[2], "ec2-instance-connect open-tunnel")
+	assert.Equal(t, "ssm-session couldn't reach i-123; trying ssm-ssh\nssm-ssh couldn't reach i-123; trying ec2-instance-connect\n", out.String())
+
+	// A failure after reaching the instance ends the chain
+	started = nil
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		started = append(started, name)
+		return exec.CommandContext(ctx, "sh", "-c", "exit 1")
+	}
+	err = connectWithFallback(&bytes.Buffer{}, []string{methodSSMSession, methodEICE}, "prod", "us-east-1", "i-123", "")
+	assert.Error(t, err)
+	assert.Len(t, started, 1)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Favorite is a saved connection target. Instances are matched by ID first
+// and by Name tag when the ID is gone, e.g. after the instance was replaced.
+type Favorite struct {
+	Profile    string `yaml:"profile"`
+	Region     string `yaml:"region"`
+	InstanceID string `yaml:"instance_id,omitempty"`
+	Name       string `yaml:"name,omitempty"`
+}
+
+func (f Favorite) Display() string {
+	label := f.Name
+	if label == "" {
+		label = f.InstanceID
+	} else if f.InstanceID != "" {
+		label += " (" + f.InstanceID + ")"
+	}
+	return label + "  " + f.Profile + "/" + f.Region
+}
+
+// matches reports whether f was saved for inst in profile and region.
+func (f Favorite) matches(profile, region string, inst Instance) bool {
+	return f.Profile == profile && f.Region == region && f.InstanceID == inst.ID
+}
+
+// resolve finds the live instance f refers to: by ID, or else the first
+// running instance with the saved Name.
+func (f Favorite) resolve(instances []Instance) (Instance, bool) {
+	for _, inst := range instances {
+		if f.InstanceID != "" && inst.ID == f.InstanceID {
+			return inst, true
+		}
+	}
+	if f.Name == "" {
+		return Instance{}, false
+	}
+	var stopped *Instance
+	for i, inst := range instances {
+		if inst.Name != f.Name {
+			continue
+		}
+		if inst.Connectable() {
+			return inst, true
+		}
+		if stopped == nil {
+			stopped = &instances[i]
+		}
+	}
+	if stopped != nil {
+		return *stopped, true
+	}
+	return Instance{}, false
+}
+
+// isFavorite reports whether inst is saved as a favorite.
+func (m model) isFavorite(inst Instance) bool {
+	region := m.instanceRegion(inst)
+	for _, f := range m.config.Favorites {
+		if f.matches(m.selectedProfile, region, inst) {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleFavorite saves the highlighted instance as a favorite, or removes it
+// if it already is one, and writes the favorites to the config file.
+func (m model) toggleFavorite() (tea.Model, tea.Cmd) {
+	if len(m.filteredInstances) == 0 {
+		return m, nil
+	}
+	inst := m.filteredInstances[m.cursor]
+	region := m.instanceRegion(inst)
+	favorites := []Favorite{}
+	removed := false
+	for _, f := range m.config.Favorites {
+		if f.matches(m.selectedProfile, region, inst) {
+			removed = true
+			continue
+		}
+		favorites = append(favorites, f)
+	}
+	note := "Removed from favorites"
+	if !removed {
+		favorites = append(favorites, Favorite{Profile: m.selectedProfile, Region: region, InstanceID: inst.ID, Name: inst.Name})
+		note = "Added to favorites"
+	}
+	m.config.Favorites = favorites
+	updated, toast := m.showToast(note)
+	return updated, tea.Batch(saveFavoritesCmd(favorites), toast)
+}
+
+// saveFavoritesCmd writes the favorites in the background; like the preview
+// preference, failing to save only means they aren't remembered.
+func saveFavoritesCmd(favorites []Favorite) tea.Cmd {
+	return func() tea.Msg {
+		_ = saveConfigValue("favorites", favorites)
+		return nil
+	}
+}
+
+// resolveFavoriteCmd lists the instances of a favorite's profile and region,
+// from the cache when fresh, so the favorite can be matched to a live instance.
+func resolveFavoriteCmd(ctx context.Context, f Favorite) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		instances, err := getInstances(ctx, f.Profile, f.Region, false)
+		return struct {
+			favoriteInstances []Instance
+			err               error
+		}{instances, lookupErr(ctx, err, "instances", loadTimeout)}
+	}
+}
+
+// updateFavorites handles key input on the favorites screen.
+func (m model) updateFavorites(s string) (tea.Model, tea.Cmd) {
+	favorites := m.config.Favorites
+	keys := m.keys()
+	switch {
+	case keys.matches(actionUp, s):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case keys.matches(actionDown, s):
+		if m.cursor < len(favorites)-1 {
+			m.cursor++
+		}
+	case keys.matches(actionBack, s), s == "tab":
+		// Browse every profile instead
+		m.step = stateProfile
+		m.filteredProfiles = m.profileList()
+		m.cursor = 0
+	case s == "d":
+		if len(favorites) == 0 {
+			return m, nil
+		}
+		m.config.Favorites = append(append([]Favorite{}, favorites[:m.cursor]...), favorites[m.cursor+1:]...)
+		m.cursor = max(min(m.cursor, len(m.config.Favorites)-1), 0)
+		return m, saveFavoritesCmd(m.config.Favorites)
+	case keys.matches(actionSelect, s):
+		if len(favorites) == 0 {
+			return m, nil
+		}
+		f := favorites[m.cursor]
+		m.selectedProfile, m.selectedRegion = f.Profile, f.Region
+		m.loading = true
+		m.loadingMsg = "Finding " + f.Display() + "..."
+		return m, resolveFavoriteCmd(m.lookupContext(), f)
+	}
+	return m, nil
+}
+
+// updateFavoriteInstances connects to the highlighted favorite once its
+// region's instances are listed. The instance list stays behind it, so going
+// back from the session prompts lands there.
+func (m model) updateFavoriteInstances(instances []Instance, err error) (tea.Model, tea.Cmd) {
+	m.loading = false
+	f := m.config.Favorites[m.cursor]
+	if isExpiredCredentials(err) {
+		return m.promptReauth(err, resolveFavoriteCmd(m.lookupContext(), f)), nil
+	}
+	if err != nil {
+		m.inlineErr = err.Error()
+		return m, nil
+	}
+	inst, ok := f.resolve(instances)
+	if !ok {
+		m.inlineErr = fmt.Sprintf("No instance matches this favorite in %s/%s any more", f.Profile, f.Region)
+		return m, nil
+	}
+	if !inst.Connectable() {
+		m.inlineErr = fmt.Sprintf("Instance is %s; only running instances can be connected to", inst.State)
+		return m, nil
+	}
+	m.instances = sortInstances(instances, m.instanceSort)
+	m.filteredInstances = m.instances
+	m.filter = ""
+	m = m.selectInstance(inst)
+	if sshConfigOutput {
+		m.sessionMode = sessionSSHProxy
+		m.step = stateDone
+		return m, tea.Quit
+	}
+	return m.startSelected()
+}
+
+func (m model) favoritesView() string {
+	w := m.contentWidth()
+	content := headerStyle.Render(fit(fmt.Sprintf("Favorites (%d)", len(m.config.Favorites)), w, 2)) + "\n"
+	start, end := windowBounds(m.cursor, len(m.config.Favorites), m.windowSize())
+	for i := start; i < end; i++ {
+		display := fit("★ "+m.config.Favorites[i].Display(), w, 4)
+		if m.cursor == i {
+			content += selectedStyle.Render("> "+display) + "\n"
+		} else {
+			content += itemStyle.Render("  "+display) + "\n"
+		}
+	}
+	if len(m.config.Favorites) == 0 {
+		content += mutedStyle.Render(fit("No favorites left — press alt+f on an instance to add one", w, 2)) + "\n"
+	}
+	if m.inlineErr != "" {
+		content += errorStyle.Render(fit(m.inlineErr, w, 0)) + "\n"
+	}
+	content += quitStyle.Render(fit("enter: connect • d: remove • ←/tab: all profiles • ?: help • esc: quit", w, 0))
+	return borderStyle.Render(content)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for a favorites screen with one saved target
+func favoritesModel() model {
+	return model{
+		step:     stateFavorites,
+		profiles: []string{"default", "prod"},
+		config: Config{Favorites: []Favorite{
+			{Profile: "prod", Region: "eu-west-1", InstanceID: "i-old", Name: "api-gateway"},
+		}},
+	}
+}
+
+// Test matching favorites to live instances
+func TestFavoriteResolve(t *testing.T) {
+	f := Favorite{Profile: "prod", Region: "eu-west-1", InstanceID: "i-123", Name: "api-gateway"}
+
+	inst, ok := f.resolve([]Instance{{ID: "i-999", Name: "api-gateway", State: "running"}, {ID: "i-123", Name: "renamed", State: "running"}})
+	assert.True(t, ok)
+	assert.Equal(t, "i-123", inst.ID, "ID wins over the name")
+
+	inst, ok = f.resolve([]Instance{{ID: "i-777", Name: "api-gateway", State: "stopped"}, {ID: "i-888", Name: "api-gateway", State: "running"}})
+	assert.True(t, ok)
+	assert.Equal(t, "i-888", inst.ID, "replaced instance found by name, running first")
+
+	_, ok = f.resolve([]Instance{{ID: "i-555", Name: "other", State: "running"}})
+	assert.False(t, ok)
+}
+
+// Test starring instances from the instance list
+func TestToggleFavorite(t *testing.T) {
+	setTempHome(t)
+	instances := []Instance{{ID: "i-123", Name: "web-server", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "prod",
+		selectedRegion:    "us-east-1",
+	}
+
+	updatedModel, cmd := m.Update(altKey('f'))
+	result := updatedModel.(model)
+	require.NotNil(t, cmd)
+	assert.Equal(t, []Favorite{{Profile: "prod", Region: "us-east-1", InstanceID: "i-123", Name: "web-server"}}, result.config.Favorites)
+	assert.Contains(t, result.View(), "i-123  running  web-server ★")
+	assert.Contains(t, result.View(), "Added to favorites")
+
+	require.NoError(t, saveConfigValue("favorites", result.config.Favorites))
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, result.config.Favorites, cfg.Favorites)
+
+	updatedModel, _ = result.Update(altKey('f'))
+	assert.Empty(t, updatedModel.(model).config.Favorites)
+}
+
+// Test connecting from the favorites screen
+func TestFavoritesScreen(t *testing.T) {
+	t.Run("launch starts on favorites when there are some", func(t *testing.T) {
+		home := setTempHome(t)
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "")
+		require.NoError(t, os.MkdirAll(filepath.Join(home, ".aws"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte("[prod]\n"), 0600))
+		writeConfig(t, "favorites:\n  - profile: prod\n    region: eu-west-1\n    name: api-gateway\n")
+		m := initialModel()
+		assert.Equal(t, stateFavorites, m.step)
+		assert.Contains(t, m.View(), "★ api-gateway  prod/eu-west-1")
+	})
+
+	t.Run("enter connects to the replacement instance", func(t *testing.T) {
+		updatedModel, cmd := favoritesModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result := updatedModel.(model)
+		require.NotNil(t, cmd)
+		assert.True(t, result.loading)
+
+		updatedModel, cmd = result.Update(struct {
+			favoriteInstances []Instance
+			err               error
+		}{[]Instance{{ID: "i-new", Name: "api-gateway", State: "running"}}, nil})
+		result = updatedModel.(model)
+		assert.NotNil(t, cmd)
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, "prod", result.selectedProfile)
+		assert.Equal(t, "eu-west-1", result.selectedRegion)
+		assert.Equal(t, "i-new", result.selectedInstance)
+	})
+
+	t.Run("missing instance is reported", func(t *testing.T) {
+		updatedModel, _ := favoritesModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+		updatedModel, _ = updatedModel.(model).Update(struct {
+			favoriteInstances []Instance
+			err               error
+		}{[]Instance{}, nil})
+		result := updatedModel.(model)
+		assert.Equal(t, stateFavorites, result.step)
+		assert.Contains(t, result.View(), "No instance matches this favorite in prod/eu-west-1")
+	})
+
+	t.Run("left browses profiles and back returns", func(t *testing.T) {
+		result := typeKeys(favoritesModel(), tea.KeyMsg{Type: tea.KeyLeft})
+		assert.Equal(t, stateProfile, result.step)
+		assert.Contains(t, result.View(), "←: favorites")
+		result = typeKeys(result, tea.KeyMsg{Type: tea.KeyLeft})
+		assert.Equal(t, stateFavorites, result.step)
+	})
+
+	t.Run("d removes a favorite", func(t *testing.T) {
+		setTempHome(t)
+		result := typeKeys(favoritesModel(), runeKeys("d")...)
+		assert.Empty(t, result.config.Favorites)
+		assert.Contains(t, result.View(), "No favorites left")
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Set by -filter, which can be repeated: describe-instances filters in the
+// AWS CLI's Name=...,Values=... form, so AWS narrows large accounts down
+// server-side. Every filter must match.
+var instanceFilters []string
+
+// Set by -include-terminated: keep terminated and shutting-down instances,
+// which describe-instances reports for up to an hour, in the instance lists
+var includeTerminated bool
+
+// hideTerminated drops the instances that are terminated or shutting down,
+// which can never be connected to, unless -include-terminated is set.
+func hideTerminated(instances []Instance) []Instance {
+	if includeTerminated {
+		return instances
+	}
+	live := make([]Instance, 0, len(instances))
+	for _, inst := range instances {
+		if inst.State != "terminated" && inst.State != "shutting-down" {
+			live = append(live, inst)
+		}
+	}
+	return live
+}
+
+// filterFlag collects -filter values into instanceFilters, rejecting
+// malformed ones before any AWS call is made.
+type filterFlag struct{}
+
+func (filterFlag) String() string {
+	return strings.Join(instanceFilters, " ")
+}
+
+func (filterFlag) Set(s string) error {
+	filter, err := parseInstanceFilter(s)
+	if err != nil {
+		return err
+	}
+	instanceFilters = append(instanceFilters, filter)
+	return nil
+}
+
+// idFilterFlag adds a filter on network resource IDs, such as -sg or -subnet,
+// to instanceFilters. Several IDs, separated by commas, match any of them.
+type idFilterFlag struct {
+	// Filter name in describe-instances, e.g. subnet-id
+	name string
+	// Prefix of the IDs, e.g. subnet
+	prefix string
+}
+
+func (f idFilterFlag) String() string {
+	return ""
+}
+
+func (f idFilterFlag) Set(s string) error {
+	filter, err := parseIDFilter(f.name, f.prefix, s)
+	if err != nil {
+		return err
+	}
+	instanceFilters = append(instanceFilters, filter)
+	return nil
+}
+
+// parseIDFilter turns comma-separated IDs such as "sg-0123456789abcdef0" into
+// a describe-instances filter on name, checking that each is a well-formed
+// ID with the given prefix.
+func parseIDFilter(name, prefix, s string) (string, error) {
+	// Older resources have 8 hex digits, newer ones 17
+	id := regexp.MustCompile(`^` + prefix + `-([0-9a-f]{8}|[0-9a-f]{17})$`)
+	for _, v := range strings.Split(s, ",") {
+		if !id.MatchString(v) {
+			return "", fmt.Errorf("invalid ID %q; expected %s- followed by 8 or 17 hex digits", v, prefix)
+		}
+	}
+	return "Name=" + name + ",Values=" + s, nil
+}
+
+// parseInstanceFilter turns "tag:Environment=production" or
+// "instance-state-name=running,stopped" into the filter syntax of
+// describe-instances. Several values, separated by commas, match any of them.
+func parseInstanceFilter(s string) (string, error) {
+	name, values, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || name == "tag:" || strings.Contains(name, ",") {
+		return "", fmt.Errorf("invalid filter %q; use name=value, e.g. tag:Environment=production", s)
+	}
+	for _, v := range strings.Split(values, ",") {
+		if strings.TrimSpace(v) == "" {
+			return "", fmt.Errorf("invalid filter %q: empty value", s)
+		}
+	}
+	return "Name=" + name + ",Values=" + values, nil
+}
+
+// filterCacheSuffix keeps filtered instance lists apart from the full ones
+// in the cache.
+func filterCacheSuffix() string {
+	if len(instanceFilters) == 0 {
+		return ""
+	}
+	sum := sha1.Sum([]byte(strings.Join(instanceFilters, "\n")))
+	return "-" + hex.EncodeToString(sum[:6])
+}
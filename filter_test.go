@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for setting -filter for the length of a test
+func setInstanceFilters(t *testing.T, filters ...string) {
+	original := instanceFilters
+	instanceFilters = filters
+	t.Cleanup(func() { instanceFilters = original })
+}
+
+// Test parsing -filter values into describe-instances filters
+func TestParseInstanceFilter(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+		err      string
+	}{
+		{"tag:Environment=production", "Name=tag:Environment,Values=production", ""},
+		{"instance-state-name=running,stopped", "Name=instance-state-name,Values=running,stopped", ""},
+		{"tag:Name=web-*", "Name=tag:Name,Values=web-*", ""},
+		{"tag:Environment", "", "use name=value"},
+		{"=production", "", "use name=value"},
+		{"tag:=production", "", "use name=value"},
+		{"tag:Environment=", "", "empty value"},
+		{"tag:Environment=prod,", "", "empty value"},
+	}
+	for _, tt := range tests {
+		filter, err := parseInstanceFilter(tt.in)
+		if tt.err != "" {
+			assert.ErrorContains(t, err, tt.err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.expected, filter)
+	}
+}
+
+// Test that -filter can be repeated and rejects malformed values
+func TestFilterFlag(t *testing.T) {
+	setInstanceFilters(t)
+	fs := flag.NewFlagSet("ssmssh", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(filterFlag{}, "filter", "")
+
+	require.NoError(t, fs.Parse([]string{"-filter", "tag:Environment=production", "-filter", "instance-state-name=running"}))
+	assert.Equal(t, []string{"Name=tag:Environment,Values=production", "Name=instance-state-name,Values=running"}, instanceFilters)
+	assert.Error(t, fs.Parse([]string{"-filter", "production"}))
+}
+
+// Test that -sg and -subnet validate their IDs and add to the -filter filters
+func TestIDFilterFlags(t *testing.T) {
+	setInstanceFilters(t)
+	fs := flag.NewFlagSet("ssmssh", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(filterFlag{}, "filter", "")
+	fs.Var(idFilterFlag{"instance.group-id", "sg"}, "sg", "")
+	fs.Var(idFilterFlag{"subnet-id", "subnet"}, "subnet", "")
+
+	require.NoError(t, fs.Parse([]string{
+		"-filter", "tag:Environment=production",
+		"-sg", "sg-0123456789abcdef0",
+		"-subnet", "subnet-12345678,subnet-0fedcba9876543210",
+	}))
+	assert.Equal(t, []string{
+		"Name=tag:Environment,Values=production",
+		"Name=instance.group-id,Values=sg-0123456789abcdef0",
+		"Name=subnet-id,Values=subnet-12345678,subnet-0fedcba9876543210",
+	}, instanceFilters)
+
+	for _, bad := range [][]string{
+		{"-sg", "subnet-12345678"},
+		{"-sg", "sg-123"},
+		{"-sg", "sg-0123456789ABCDEF0"},
+		{"-subnet", "subnet-12345678,"},
+		{"-subnet", "subnet-12345678,Values=x"},
+	} {
+		assert.ErrorContains(t, fs.Parse(bad), "invalid ID", bad)
+	}
+}
+
+// Test that filters are passed to describe-instances and cached separately
+func TestInstanceFiltersPassedThrough(t *testing.T) {
+	setTempHome(t)
+	setInstanceFilters(t, "Name=tag:Environment,Values=production")
+	var calls []string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return []byte(`{"Reservations": []}`), nil
+	})
+
+	_, err := getInstances(context.Background(), "prod", "us-east-1", false)
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Contains(t, calls[0], "--filters Name=tag:Environment,Values=production")
+
+	filtered := instanceCachePath("prod", "us-east-1")
+	instanceFilters = nil
+	assert.NotEqual(t, instanceCachePath("prod", "us-east-1"), filtered)
+	_, ok := readInstanceCache("prod", "us-east-1", cacheTTL())
+	assert.False(t, ok, "the filtered list doesn't stand in for the full one")
+}
+
+// Test that demo mode applies tag filters
+func TestDemoInstanceFilters(t *testing.T) {
+	withDemoMode(t)
+	setInstanceFilters(t, "Name=tag:Name,Values=web-*", "Name=instance-state-name,Values=running")
+	instances, err := fetchInstances(context.Background(), "demo-dev", "us-east-1")
+	require.NoError(t, err)
+	assert.Len(t, instances, 2)
+}
+
+// Test that terminated and shutting-down instances are hidden unless
+// -include-terminated is set, while the cache keeps them
+func TestHideTerminated(t *testing.T) {
+	setTempHome(t)
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return []byte(`{"Reservations":[{"Instances":[
+			{"InstanceId":"i-1","State":{"Name":"running"}},
+			{"InstanceId":"i-2","State":{"Name":"terminated"}},
+			{"InstanceId":"i-3","State":{"Name":"shutting-down"}},
+			{"InstanceId":"i-4","State":{"Name":"stopped"}}
+		]}]}`), nil
+	})
+
+	instances, err := getInstances(context.Background(), "prod", "us-east-1", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i-1", "i-4"}, instanceIds(instances))
+
+	includeTerminated = true
+	t.Cleanup(func() { includeTerminated = false })
+	// Served from the cache written above
+	instances, err = getInstances(context.Background(), "prod", "us-east-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i-1", "i-2", "i-3", "i-4"}, instanceIds(instances))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyBinding is one row of the help overlay.
+type keyBinding struct {
+	keys string
+	desc string
+}
+
+// listBindings returns the bindings shared by the list screens.
+func listBindings(keys keyMap) []keyBinding {
+	return []keyBinding{
+		{keys.label(actionUp), "move up (letters only with an empty filter)"},
+		{keys.label(actionDown), "move down (letters only with an empty filter)"},
+		{"pgup/pgdn", "move a page"},
+		{"home/end", "first/last item"},
+		{"type", "fuzzy filter the list"},
+		{"backspace", "delete a filter character"},
+		{"ctrl+p/ctrl+n", "recall older/newer filters from earlier runs"},
+		{keys.label(actionSelect), "select"},
+		{"alt+1-9", "jump to that row of a list of up to 9 items, again to select it"},
+		{"mouse", "click to highlight, click again to select, wheel to scroll"},
+		{recentKey, "recently used instances"},
+	}
+}
+
+// helpBindings returns the key bindings that apply to step, showing the
+// configured keys for rebindable actions.
+func helpBindings(step state, keys keyMap) []keyBinding {
+	var bindings []keyBinding
+	switch step {
+	case stateProfile:
+		bindings = append(bindings, listBindings(keys)...)
+		bindings = append(bindings, keyBinding{"tab", "group profiles by account alias"})
+	case stateRegion:
+		bindings = append(bindings, listBindings(keys)...)
+		bindings = append(bindings,
+			keyBinding{"tab", "pin to or unpin from the top of the list"},
+			keyBinding{"alt+r", "refresh, bypassing the cache"},
+			keyBinding{keys.label(actionBack), "back to profiles, or to accounts with -org-role"},
+		)
+	case stateAccount:
+		bindings = append(bindings, listBindings(keys)...)
+		bindings = append(bindings, keyBinding{keys.label(actionBack), "back to profiles"})
+	case stateInstance:
+		bindings = append(bindings, listBindings(keys)...)
+		bindings = append(bindings,
+			keyBinding{"key:value", "filter by tag, e.g. env:prod"},
+			keyBinding{keys.label(actionBack), "back to regions"},
+			keyBinding{"tab", "mark for a multi-instance session"},
+			keyBinding{"alt+r", "refresh, bypassing the cache"},
+			keyBinding{"alt+f", "add to or remove from favorites"},
+			keyBinding{"alt+y/alt+Y", "copy the instance ID / its session command"},
+			keyBinding{"alt+t", "show or hide the details preview"},
+			keyBinding{"alt+v", "show the full tag values of the previewed instance"},
+			keyBinding{"alt+m", "toggle the tag matrix"},
+			keyBinding{"alt+o", "sort by name, ID, launch time (newest first) or state"},
+			keyBinding{"alt+p", "port forward to the instance"},
+			keyBinding{"alt+s", "print an SSH ProxyCommand and exit"},
+		)
+	case statePortForward:
+		bindings = []keyBinding{
+			{"0-9", "enter a port"},
+			{"tab, ↑/↓", "switch between remote and local port"},
+			{keys.label(actionSelect), "start port forwarding"},
+			{keys.label(actionBack), "back to instances (letters go to the port)"},
+		}
+	case stateReauth:
+		bindings = []keyBinding{
+			{keys.label(actionSelect) + ", l", "run aws sso login and retry"},
+			{keys.label(actionBack) + ", n", "go back"},
+		}
+	case stateFavorites:
+		bindings = []keyBinding{
+			{keys.label(actionUp), "move up"},
+			{keys.label(actionDown), "move down"},
+			{keys.label(actionSelect), "connect to the favorite"},
+			{"d", "remove the favorite"},
+			{keys.label(actionBack) + ", tab", "browse all profiles"},
+			{recentKey, "recently used instances"},
+		}
+	case stateRecent:
+		bindings = []keyBinding{
+			{keys.label(actionUp), "move up"},
+			{keys.label(actionDown), "move down"},
+			{keys.label(actionSelect), "connect to the instance"},
+			{keys.label(actionBack) + ", " + recentKey, "back to where you were"},
+		}
+	case stateMethod:
+		bindings = []keyBinding{
+			{keys.label(actionUp), "move up"},
+			{keys.label(actionDown), "move down"},
+			{keys.label(actionSelect), "connect with the highlighted method"},
+			{keys.label(actionBack), "back to instances"},
+		}
+	case stateConfirm:
+		return []keyBinding{
+			{"y", "start the session"},
+			{"n, " + keys.label(actionBack) + ", " + keys.label(actionFilterClear), "go back"},
+			{"?", "close this help"},
+			{keys.label(actionQuit), "quit"},
+		}
+	}
+	return append(bindings,
+		keyBinding{"?", "close this help"},
+		keyBinding{keys.label(actionFilterClear), "clear the filter, or quit when it is empty"},
+		keyBinding{keys.label(actionQuit), "quit"},
+	)
+}
+
+// updateHelp handles key input while the help overlay is shown: ? or the
+// filter-clear keys close it, the quit keys quit, everything else is ignored.
+func (m model) updateHelp(s string) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	switch {
+	case s == "?", keys.matches(actionFilterClear, s):
+		m.showHelp = false
+	case keys.matches(actionQuit, s):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) helpView() string {
+	w := m.contentWidth()
+	bindings := helpBindings(m.step, m.keys())
+	width := 0
+	for _, b := range bindings {
+		width = max(width, len([]rune(b.keys)))
+	}
+	content := headerStyle.Render(fit("Key bindings", w, 2)) + "\n"
+	for _, b := range bindings {
+		content += infoStyle.Render(fit(fmt.Sprintf("%-*s  %s", width, b.keys, b.desc), w, 2)) + "\n"
+	}
+	content += quitStyle.Render(fit("?/esc: close help", w, 0))
+	return borderStyle.Render(content)
+}
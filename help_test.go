@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test toggling the help overlay
+func TestHelpOverlay(t *testing.T) {
+	instances := []Instance{{ID: "i-123", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+	}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	result := updatedModel.(model)
+	assert.Nil(t, cmd)
+	assert.True(t, result.showHelp)
+	view := result.View()
+	assert.Contains(t, view, "Key bindings")
+	assert.Contains(t, view, "port forward to the instance")
+
+	// Other keys are swallowed while help is open
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result = updatedModel.(model)
+	assert.Equal(t, stateInstance, result.step)
+	assert.True(t, result.showHelp)
+
+	// ? closes it again
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	assert.False(t, updatedModel.(model).showHelp)
+
+	// esc closes it without quitting
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	result = updatedModel.(model)
+	assert.False(t, result.showHelp)
+	assert.NotContains(t, result.View(), "Key bindings")
+}
+
+// Test that ? is typed into a filter in progress instead of opening help
+func TestHelpKeyWhileFiltering(t *testing.T) {
+	instances := []Instance{{ID: "i-123", State: "running"}}
+	m := model{step: stateInstance, instances: instances, filteredInstances: instances, filter: "web"}
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	result := updatedModel.(model)
+	assert.False(t, result.showHelp)
+	assert.Equal(t, "web?", result.filter)
+}
+
+// Test that help lists the bindings of the current screen
+func TestHelpBindings(t *testing.T) {
+	keys := func(step state) []string {
+		ks := []string{}
+		for _, b := range helpBindings(step, defaultKeyMap("linux")) {
+			ks = append(ks, b.keys)
+		}
+		return ks
+	}
+	assert.Contains(t, keys(stateProfile), "tab")
+	assert.NotContains(t, keys(stateRegion), "alt+s")
+	assert.Contains(t, keys(stateInstance), "alt+s")
+	assert.Contains(t, keys(statePortForward), "0-9")
+	assert.Contains(t, keys(stateConfirm), "y")
+	for _, step := range []state{stateProfile, stateRegion, stateInstance, statePortForward, stateReauth, stateConfirm} {
+		assert.Contains(t, keys(step), "?")
+	}
+}
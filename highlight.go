@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// instanceMatchPositions returns the rune positions of display matched by
+// the bare terms of an instance query. key:value terms match tags, which
+// aren't displayed, so they highlight nothing.
+func instanceMatchPositions(display, query string) []int {
+	seen := map[int]bool{}
+	positions := []int{}
+	for _, term := range strings.Fields(query) {
+		if key, _, ok := strings.Cut(term, ":"); ok && key != "" {
+			continue
+		}
+		_, matched, ok := fuzzyMatch(display, term)
+		if !ok {
+			continue
+		}
+		for _, pos := range matched {
+			if !seen[pos] {
+				seen[pos] = true
+				positions = append(positions, pos)
+			}
+		}
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// offsetPositions shifts positions by n runes, for text rendered after a
+// prefix such as the multi-select mark.
+func offsetPositions(positions []int, n int) []int {
+	out := make([]int, len(positions))
+	for i, pos := range positions {
+		out[i] = pos + n
+	}
+	return out
+}
+
+// renderHighlighted renders a list row with style, underlining and bolding
+// the runes of text at positions so it's clear why the row matched the filter.
+// Each run is styled in full, so a highlight doesn't reset the row's colors.
+func renderHighlighted(prefix, text string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(prefix + text)
+	}
+	plain := style.Copy().UnsetPadding()
+	match := plain.Copy().Bold(true).Underline(true)
+	hit := map[int]bool{}
+	for _, pos := range positions {
+		hit[pos] = true
+	}
+	var b strings.Builder
+	b.WriteString(plain.Render(prefix))
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && hit[end] == hit[start] {
+			end++
+		}
+		if hit[start] {
+			b.WriteString(match.Render(string(runes[start:end])))
+		} else {
+			b.WriteString(plain.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return style.Render(b.String())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test the positions reported for fuzzy matches
+func TestFuzzyMatchPositions(t *testing.T) {
+	_, positions, ok := fuzzyMatch("us-west-2", "usw2")
+	assert.True(t, ok)
+	assert.Equal(t, []int{0, 1, 3, 8}, positions)
+
+	_, positions, ok = fuzzyMatch("us-west-2", "eu")
+	assert.False(t, ok)
+	assert.Nil(t, positions)
+}
+
+// Test which characters of an instance row a query highlights
+func TestInstanceMatchPositions(t *testing.T) {
+	display := "i-123 (web) [running]"
+	assert.Equal(t, []int{7, 8, 9}, instanceMatchPositions(display, "web"))
+	assert.Equal(t, []int{2, 7, 8, 9}, instanceMatchPositions(display, "web 1"), "terms are combined")
+	assert.Empty(t, instanceMatchPositions(display, "env:prod"), "tag terms highlight nothing")
+	assert.Empty(t, instanceMatchPositions(display, ""))
+}
+
+// Test rendering highlighted rows
+func TestRenderHighlighted(t *testing.T) {
+	withTrueColor(t)
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700")).Padding(0, 1)
+
+	plain := renderHighlighted("> ", "us-west-2", nil, style)
+	highlighted := renderHighlighted("> ", "us-west-2", []int{0, 1, 3, 8}, style)
+	assert.NotEqual(t, plain, highlighted)
+	// \x1b[4 starts an underline
+	assert.Contains(t, highlighted, ";4")
+	assert.Equal(t, lipgloss.Width(plain), lipgloss.Width(highlighted), "highlighting doesn't change the width")
+}
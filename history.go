@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Most filter strings remembered across runs
+const filterHistorySize = 20
+
+func filterHistoryPath() string {
+	return filepath.Join(configDir(), "history.json")
+}
+
+// loadFilterHistory returns the remembered filters, most recent first. A
+// missing or corrupt file yields no history.
+func loadFilterHistory() []string {
+	data, err := os.ReadFile(filterHistoryPath())
+	if err != nil {
+		return nil
+	}
+	var history []string
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil
+	}
+	return history
+}
+
+func saveFilterHistory(history []string) error {
+	if len(history) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filterHistoryPath(), data, 0644)
+}
+
+// rememberFilter moves the current filter to the front of the history.
+func (m model) rememberFilter() model {
+	if m.filter == "" {
+		return m
+	}
+	history := []string{m.filter}
+	for _, f := range m.filterHistory {
+		if f != m.filter && len(history) < filterHistorySize {
+			history = append(history, f)
+		}
+	}
+	m.filterHistory = history
+	m.historyPos = 0
+	return m
+}
+
+// recallFilter steps through the history, older for delta 1 and newer for
+// -1. historyPos counts entries back from the filter being typed (0), so
+// stepping past the newest entry clears the filter again.
+func (m model) recallFilter(delta int) model {
+	pos := m.historyPos + delta
+	if pos < 0 || pos > len(m.filterHistory) {
+		return m
+	}
+	m.historyPos = pos
+	if pos == 0 {
+		m.filter = ""
+	} else {
+		m.filter = m.filterHistory[pos-1]
+	}
+	return m
+}
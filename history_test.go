@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that filter history survives between runs
+func TestFilterHistoryPersistence(t *testing.T) {
+	setTempHome(t)
+	assert.Nil(t, loadFilterHistory())
+
+	require.NoError(t, saveFilterHistory([]string{"api-gateway", "prod"}))
+	assert.Equal(t, []string{"api-gateway", "prod"}, loadFilterHistory())
+}
+
+// Test recording filters on selection
+func TestRememberFilter(t *testing.T) {
+	m := model{filter: "api", filterHistory: []string{"web", "api", "db"}}
+	assert.Equal(t, []string{"api", "web", "db"}, m.rememberFilter().filterHistory)
+
+	m.filter = ""
+	assert.Equal(t, []string{"web", "api", "db"}, m.rememberFilter().filterHistory)
+
+	m.filterHistory = make([]string, filterHistorySize)
+	m.filter = "new"
+	assert.Len(t, m.rememberFilter().filterHistory, filterHistorySize)
+}
+
+// Test cycling through the history with ctrl+p and ctrl+n
+func TestRecallFilter(t *testing.T) {
+	m := model{
+		step:            stateRegion,
+		regions:         []string{"us-east-1", "us-west-2", "eu-west-1"},
+		filteredRegions: []string{"us-east-1", "us-west-2", "eu-west-1"},
+		filterHistory:   []string{"usw", "eu"},
+	}
+	ctrlP := tea.KeyMsg{Type: tea.KeyCtrlP}
+	ctrlN := tea.KeyMsg{Type: tea.KeyCtrlN}
+
+	result := typeKeys(m, ctrlP)
+	assert.Equal(t, "usw", result.filter)
+	assert.Equal(t, []string{"us-west-2"}, result.filteredRegions)
+
+	result = typeKeys(result, ctrlP, ctrlP)
+	assert.Equal(t, "eu", result.filter, "stops at the oldest entry")
+
+	result = typeKeys(result, ctrlN, ctrlN)
+	assert.Equal(t, "", result.filter, "newest entry is the empty filter")
+	assert.Len(t, result.filteredRegions, 3)
+
+	// Typing starts a new filter; ctrl+p then starts from the newest entry again
+	result = typeKeys(result, ctrlP, ctrlP)
+	result = typeKeys(result, runeKeys("x")...)
+	assert.Equal(t, "eux", result.filter)
+	assert.Equal(t, "usw", typeKeys(result, ctrlP).filter)
+}
+
+// Test that selecting with a filter records it
+func TestSelectRecordsFilter(t *testing.T) {
+	m := model{
+		step:            stateRegion,
+		regions:         []string{"us-east-1", "us-west-2"},
+		filteredRegions: []string{"us-east-1", "us-west-2"},
+		selectedProfile: "default",
+	}
+	result := typeKeys(m, runeKeys("usw")...)
+	updatedModel, _ := result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, []string{"usw"}, updatedModel.(model).filterHistory)
+}
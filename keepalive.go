@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Set by -keepalive: how often to open a connection through a port forward
+// so Session Manager doesn't end it as idle. 0 disables it.
+var keepaliveInterval time.Duration
+
+// keepAlive connects to the local end of a port forward every interval until
+// ctx is done. Each connection travels through the tunnel to the remote
+// port, which Session Manager counts as activity.
+func keepAlive(ctx context.Context, localPort int, interval time.Duration) {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			dialer := net.Dialer{Timeout: 5 * time.Second}
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				// The forward may still be starting; try again next time
+				continue
+			}
+			conn.Close()
+		}
+	}
+}
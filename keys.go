@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Actions that can be rebound in the keys section of the config file
+const (
+	actionUp          = "up"
+	actionDown        = "down"
+	actionSelect      = "select"
+	actionQuit        = "quit"
+	actionFilterClear = "filter-clear"
+	actionBack        = "back"
+)
+
+// keyMap maps each action to the keys, as named by Bubble Tea, that trigger it.
+type keyMap map[string][]string
+
+// defaultKeyMap returns the bindings used on goos for actions the config
+// doesn't set. macOS terminals that pass Command through report it as cmd+,
+// so those are added there; ctrl+c works everywhere.
+func defaultKeyMap(goos string) keyMap {
+	quit := []string{"ctrl+c", "ctrl+q"}
+	if goos == "darwin" {
+		quit = append(quit, "cmd+q", "cmd+c")
+	}
+	return keyMap{
+		actionUp:          {"up", "k"},
+		actionDown:        {"down", "j"},
+		actionSelect:      {"enter"},
+		actionQuit:        quit,
+		actionFilterClear: {"esc"},
+		actionBack:        {"left", "h"},
+	}
+}
+
+// validateKeys rejects unknown actions and empty bindings in the config's
+// keys section.
+func validateKeys(keys map[string][]string) error {
+	defaults := defaultKeyMap(runtime.GOOS)
+	for action, bound := range keys {
+		if _, ok := defaults[action]; !ok {
+			return fmt.Errorf("unknown key binding action %q", action)
+		}
+		if len(bound) == 0 {
+			return fmt.Errorf("no keys bound to %q", action)
+		}
+	}
+	return nil
+}
+
+// keys returns the platform defaults with the configured bindings applied.
+// A configured action replaces its default keys rather than adding to them.
+func (m model) keys() keyMap {
+	keys := defaultKeyMap(runtime.GOOS)
+	for action, bound := range m.config.Keys {
+		keys[action] = bound
+	}
+	return keys
+}
+
+// matches reports whether s is bound to action.
+func (k keyMap) matches(action, s string) bool {
+	for _, key := range k[action] {
+		if key == s {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the keys bound to action for display, e.g. "left, h".
+func (k keyMap) label(action string) string {
+	return strings.Join(k[action], ", ")
+}
+
+// isFilterKey reports whether s is a printable key that extends the filter.
+func isFilterKey(s string) bool {
+	return len(s) == 1 && s[0] >= 32 && s[0] <= 126
+}
+
+// isKey reports whether s triggers action on a list screen. Printable keys
+// such as j/k act only while the filter is empty; otherwise they are typed
+// into it.
+func (m model) isKey(action, s string) bool {
+	if m.filter != "" && isFilterKey(s) {
+		return false
+	}
+	return m.keys().matches(action, s)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the platform default quit keys
+func TestDefaultKeyMap(t *testing.T) {
+	linux := defaultKeyMap("linux")
+	assert.True(t, linux.matches(actionQuit, "ctrl+c"))
+	assert.True(t, linux.matches(actionQuit, "ctrl+q"))
+	assert.False(t, linux.matches(actionQuit, "cmd+q"))
+	assert.True(t, linux.matches(actionFilterClear, "esc"))
+
+	darwin := defaultKeyMap("darwin")
+	assert.True(t, darwin.matches(actionQuit, "cmd+q"))
+	assert.True(t, darwin.matches(actionQuit, "ctrl+c"))
+	assert.Equal(t, "left, h", darwin.label(actionBack))
+}
+
+// Test that configured bindings replace the defaults for their action
+func TestConfiguredKeys(t *testing.T) {
+	profiles := []string{"dev", "prod", "qa"}
+	m := model{
+		step:             stateProfile,
+		profiles:         profiles,
+		filteredProfiles: profiles,
+		config:           Config{Keys: map[string][]string{actionDown: {"ctrl+j"}, actionQuit: {"q"}}},
+	}
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	assert.Equal(t, 1, updatedModel.(model).cursor)
+
+	// j is no longer bound, so it filters
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	assert.Equal(t, "j", updatedModel.(model).filter)
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	require.NotNil(t, cmd)
+	_, isQuit := cmd().(tea.QuitMsg)
+	assert.True(t, isQuit)
+
+	// A letter bound to quit is typed into a non-empty filter instead
+	m.filter = "pro"
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	assert.Equal(t, "proq", updatedModel.(model).filter)
+
+	assert.Contains(t, helpBindings(stateProfile, m.keys()), keyBinding{"ctrl+j", "move down (letters only with an empty filter)"})
+}
+
+// Test that bound letters move the cursor without also filtering
+func TestBoundLettersDontFilter(t *testing.T) {
+	profiles := []string{"dev", "prod", "jump"}
+	m := model{step: stateProfile, profiles: profiles, filteredProfiles: profiles}
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	result := updatedModel.(model)
+	assert.Equal(t, "", result.filter)
+	assert.Equal(t, 1, result.cursor)
+}
+
+// Test that the config rejects unknown actions
+func TestLoadConfigKeys(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "keys:\n  quit: [ctrl+c, q]\n")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ctrl+c", "q"}, cfg.Keys[actionQuit])
+
+	writeConfig(t, "keys:\n  jump: [g]\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, `unknown key binding action "jump"`)
+
+	writeConfig(t, "keys:\n  back: []\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, `no keys bound to "back"`)
+}
+
+// Test that ctrl+c quits from every screen, including while loading and with
+// help open
+func TestCtrlCQuits(t *testing.T) {
+	models := map[string]model{
+		"profiles with a filter": {step: stateProfile, filter: "prod"},
+		"loading":                {step: stateRegion, loading: true},
+		"confirm":                {step: stateConfirm, selectedInstance: "i-123"},
+		"port forward":           {step: statePortForward},
+		"method":                 {step: stateMethod},
+		"help":                   {step: stateInstance, showHelp: true},
+	}
+	for name, m := range models {
+		t.Run(name, func(t *testing.T) {
+			_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+			require.NotNil(t, cmd)
+			_, isQuit := cmd().(tea.QuitMsg)
+			assert.True(t, isQuit)
+		})
+	}
+}
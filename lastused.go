@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Set by the -no-remember flag; the last selection is neither read nor written
+var noRemember bool
+
+type lastSelection struct {
+	Profile string `json:"profile"`
+	Region  string `json:"region"`
+}
+
+func configDir() string {
+	return os.ExpandEnv("$HOME/.config/ssmssh")
+}
+
+func lastSelectionPath() string {
+	return filepath.Join(configDir(), "last.json")
+}
+
+// loadLastSelection returns the previously used profile and region. A missing
+// or corrupt state file yields an empty selection.
+func loadLastSelection() lastSelection {
+	if noRemember {
+		return lastSelection{}
+	}
+	data, err := os.ReadFile(lastSelectionPath())
+	if err != nil {
+		return lastSelection{}
+	}
+	var last lastSelection
+	if err := json.Unmarshal(data, &last); err != nil {
+		return lastSelection{}
+	}
+	return last
+}
+
+func saveLastSelection(last lastSelection) error {
+	if noRemember {
+		return nil
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(last)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(lastSelectionPath(), data, 0644)
+}
+
+// indexOf returns the position of item in list, or 0 when absent.
+func indexOf(list []string, item string) int {
+	for i, v := range list {
+		if v == item {
+			return i
+		}
+	}
+	return 0
+}
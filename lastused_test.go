@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test last selection persistence
+func TestLastSelection(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		setTempHome(t)
+		require.NoError(t, saveLastSelection(lastSelection{Profile: "production", Region: "eu-west-1"}))
+		assert.Equal(t, lastSelection{Profile: "production", Region: "eu-west-1"}, loadLastSelection())
+	})
+
+	t.Run("missing file is ignored", func(t *testing.T) {
+		setTempHome(t)
+		assert.Equal(t, lastSelection{}, loadLastSelection())
+	})
+
+	t.Run("corrupt file is ignored", func(t *testing.T) {
+		setTempHome(t)
+		require.NoError(t, os.MkdirAll(configDir(), 0755))
+		require.NoError(t, os.WriteFile(lastSelectionPath(), []byte("{not json"), 0644))
+		assert.Equal(t, lastSelection{}, loadLastSelection())
+	})
+
+	t.Run("no-remember disables persistence", func(t *testing.T) {
+		home := setTempHome(t)
+		noRemember = true
+		defer func() { noRemember = false }()
+
+		require.NoError(t, saveLastSelection(lastSelection{Profile: "production", Region: "eu-west-1"}))
+		_, err := os.Stat(filepath.Join(home, ".config", "ssmssh", "last.json"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+// Test that the last selection pre-positions the cursor
+func TestLastSelectionCursor(t *testing.T) {
+	home := setTempHome(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".aws"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte("[default]\n[staging]\n[production]\n"), 0644))
+	require.NoError(t, saveLastSelection(lastSelection{Profile: "production", Region: "us-west-2"}))
+
+	m := initialModel()
+	assert.Equal(t, 2, m.cursor)
+
+	updatedModel, _ := m.Update(struct {
+		regions []string
+		err     error
+	}{regions: []string{"us-east-1", "us-west-2"}})
+	result := updatedModel.(model)
+	assert.Equal(t, 1, result.cursor)
+
+	t.Run("unknown region falls back to the top", func(t *testing.T) {
+		m := model{lastRegion: "ap-south-1"}
+		updatedModel, _ := m.Update(struct {
+			regions []string
+			err     error
+		}{regions: []string{"us-east-1", "us-west-2"}})
+		assert.Equal(t, 0, updatedModel.(model).cursor)
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Space borderStyle adds around its content: a border and padding of 2
+// columns on each side, and a border and padding of 1 row above and below
+const (
+	borderFrameWidth  = 6
+	borderFrameHeight = 4
+)
+
+// Widest the preview pane grows on large terminals
+const previewMaxWidth = 50
+
+// windowSize returns how many list rows fit in the terminal for the current
+// step, or listWindowSize before the terminal size is known.
+func (m model) windowSize() int {
+	if m.height == 0 {
+		return listWindowSize
+	}
+	// Header, search, and footer lines
+	chrome := borderFrameHeight + 3
+	switch m.step {
+	case stateProfile:
+		if m.groupProfiles {
+			chrome += len(groupProfilesByAlias(m.filteredProfiles, m.accountAliases))
+		}
+	case stateAccount:
+		// The profile line
+		chrome++
+	case stateRegion:
+		chrome++
+		if m.staticRegions {
+			chrome++
+		}
+		if m.hasPinnedRegions() {
+			// The titles of the pinned and other sections
+			chrome += 2
+		}
+	case stateInstance:
+		chrome++
+		if len(m.regionErrs) > 0 {
+			chrome++
+		}
+		if m.inlineErr != "" {
+			chrome++
+		}
+		if m.toast != "" {
+			chrome++
+		}
+	}
+	if size := m.height - chrome; size > 1 {
+		return size
+	}
+	return 1
+}
+
+// contentWidth returns the text width available inside a single bordered box,
+// or 0 (unbounded) before the terminal size is known.
+func (m model) contentWidth() int {
+	if m.width == 0 {
+		return 0
+	}
+	return max(m.width-borderFrameWidth, 1)
+}
+
+// paneWidths splits the terminal between the instance list and the preview
+// pane, returning the text width available inside each box. Both are 0
+// (unbounded) before the terminal size is known; the list takes the whole
+// width when the pane is hidden.
+func (m model) paneWidths() (int, int) {
+	if m.width == 0 {
+		return 0, 0
+	}
+	if !m.showRightPane() {
+		return m.contentWidth(), 0
+	}
+	available := m.width - 2*borderFrameWidth
+	right := min(available/3, previewMaxWidth)
+	return max(available-right, 1), max(right, 1)
+}
+
+// truncate shortens s to at most width terminal cells, ending it with an
+// ellipsis when anything was cut. A width of 0 or less leaves s unchanged.
+func truncate(s string, width int) string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return s
+	}
+	out := []rune{}
+	used := 0
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if used+w > width-1 {
+			break
+		}
+		out = append(out, r)
+		used += w
+	}
+	return string(out) + "…"
+}
+
+// fit truncates s to a width budget after reserving pad cells for styling
+// such as padding and the cursor marker.
+func fit(s string, width, pad int) string {
+	if width <= 0 {
+		return s
+	}
+	return truncate(s, max(width-pad, 1))
+}
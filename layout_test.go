@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test ellipsis truncation
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "web-server", truncate("web-server", 10))
+	assert.Equal(t, "web-serv…", truncate("web-server", 9))
+	assert.Equal(t, "…", truncate("web-server", 1))
+	assert.Equal(t, "web-server", truncate("web-server", 0))
+	assert.Equal(t, "日本…", truncate("日本語サーバー", 5))
+}
+
+// Test that the list window follows the terminal height
+func TestWindowSize(t *testing.T) {
+	m := model{step: stateRegion}
+	assert.Equal(t, listWindowSize, m.windowSize())
+
+	updatedModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+	m = updatedModel.(model)
+	assert.Equal(t, 120, m.width)
+	// Border/padding (4), header, profile, search, and footer lines
+	assert.Equal(t, 42, m.windowSize())
+
+	m.height = 5
+	assert.Equal(t, 1, m.windowSize())
+}
+
+// Test that the instance view fits the terminal on small and large screens
+func TestViewFitsTerminal(t *testing.T) {
+	instances := []Instance{}
+	for i := 0; i < 100; i++ {
+		instances = append(instances, Instance{
+			ID:    fmt.Sprintf("i-%017d", i),
+			Name:  "a-very-long-instance-name-that-would-not-fit-on-a-laptop-screen",
+			State: "running",
+		})
+	}
+	tags := []Tag{}
+	for i := 0; i < 60; i++ {
+		tags = append(tags, Tag{Key: fmt.Sprintf("Key%d", i), Value: strings.Repeat("v", 80)})
+	}
+	for _, size := range []tea.WindowSizeMsg{{Width: 80, Height: 24}, {Width: 200, Height: 60}} {
+		t.Run(fmt.Sprintf("%dx%d", size.Width, size.Height), func(t *testing.T) {
+			m := model{
+				step:              stateInstance,
+				instances:         instances,
+				filteredInstances: instances,
+				selectedProfile:   "default",
+				selectedRegion:    "us-east-1",
+				previewDetails:    &InstanceDetails{InstanceType: "t3.micro", Tags: tags},
+			}
+			updatedModel, _ := m.Update(size)
+			view := updatedModel.(model).View()
+
+			lines := strings.Split(view, "\n")
+			assert.LessOrEqual(t, len(lines), size.Height)
+			assert.LessOrEqual(t, lipgloss.Width(view), size.Width)
+			assert.Contains(t, view, "…")
+			// Bigger terminals show more rows
+			assert.Equal(t, size.Height-8, strings.Count(view, "i-000"))
+		})
+	}
+}
+
+// Test that a resize reflows the side-by-side layout without a keypress,
+// e.g. after splitting the terminal pane
+func TestResizeReflows(t *testing.T) {
+	instances := []Instance{{ID: "i-123", Name: strings.Repeat("web", 30), State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		previewDetails:    &InstanceDetails{Tags: []Tag{{Key: "Team", Value: strings.Repeat("x", 60)}}},
+	}
+	for _, size := range []tea.WindowSizeMsg{{Width: 200, Height: 40}, {Width: 70, Height: 20}, {Width: 120, Height: 30}} {
+		updatedModel, cmd := m.Update(size)
+		m = updatedModel.(model)
+		assert.Nil(t, cmd)
+		assert.LessOrEqual(t, lipgloss.Width(m.View()), size.Width, "%dx%d", size.Width, size.Height)
+		lw, rw := m.paneWidths()
+		assert.Equal(t, size.Width, lw+rw+2*borderFrameWidth, "panes fill the new width")
+	}
+}
+
+// Test that the secondary screens stay within a narrow terminal
+func TestSecondaryViewsFitTerminal(t *testing.T) {
+	longName := strings.Repeat("payments-api-", 10)
+	inst := Instance{ID: "i-0123456789abcdef0", Name: longName, Tags: []Tag{{Key: "Environment", Value: "production"}}}
+	base := model{
+		instances:        []Instance{inst},
+		selectedProfile:  "a-rather-long-profile-name-for-the-production-account",
+		selectedRegion:   "ap-southeast-2",
+		selectedInstance: inst.ID,
+		selectedName:     inst.Name,
+		reauthErr:        fmt.Errorf("%s", strings.Repeat("The SSO session associated with this profile has expired. ", 4)),
+		width:            60,
+		height:           24,
+	}
+	for _, step := range []state{stateConfirm, statePortForward, stateReauth, stateDone} {
+		m := base
+		m.step = step
+		view := m.View()
+		assert.LessOrEqual(t, lipgloss.Width(view), 60, "step %d:\n%s", step, view)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Headless listing flags: -list prints the instances of -profile/-region in
+// the -output format and exits without starting the TUI.
+var (
+	listMode    bool
+	listProfile string
+	listRegion  string
+	listOutput  = "json"
+)
+
+// listInstances writes the instances of profile/region to w as JSON or as a
+// human readable table. With -all-regions, region may be empty and every
+// region is listed; regions that fail are reported as an error after the
+// instances that could be listed are written.
+func listInstances(w io.Writer, profile, region, format string) error {
+	if format != "json" && format != "text" {
+		return fmt.Errorf("unknown output format %q (want json or text)", format)
+	}
+	if profile == "" {
+		return fmt.Errorf("-list requires -profile")
+	}
+	var instances []Instance
+	var listErr error
+	if allRegions && region == "" {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		regions := config.Regions
+		if len(regions) == 0 {
+			if regions, err = loadRegionList(discovery, profile, false); err != nil {
+				return err
+			}
+		}
+		var errs regionErrors
+		instances, errs = getInstancesAllRegions(discovery, profile, regions, false, nil)
+		if len(errs) > 0 {
+			listErr = errs
+		}
+	} else {
+		if region == "" {
+			return fmt.Errorf("-list requires -region (or -all-regions)")
+		}
+		var err error
+		if instances, err = getInstances(discovery, profile, region, false); err != nil {
+			return err
+		}
+	}
+	var err error
+	if format == "json" {
+		err = writeInstancesJSON(w, instances)
+	} else {
+		err = writeInstancesText(w, instances)
+	}
+	if err != nil {
+		return err
+	}
+	return listErr
+}
+
+func writeInstancesJSON(w io.Writer, instances []Instance) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(instances)
+}
+
+// writeInstancesText prints one aligned row per instance with its tags as
+// comma-separated key=value pairs.
+func writeInstancesText(w io.Writer, instances []Instance) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	withRegion := len(instances) > 0 && instances[0].Region != ""
+	if withRegion {
+		fmt.Fprintln(tw, "ID\tNAME\tSTATE\tREGION\tTAGS")
+	} else {
+		fmt.Fprintln(tw, "ID\tNAME\tSTATE\tTAGS")
+	}
+	for _, inst := range instances {
+		tags := make([]string, len(inst.Tags))
+		for i, tag := range inst.Tags {
+			tags[i] = tag.Key + "=" + tag.Value
+		}
+		row := []string{inst.ID, orDash(inst.Name), orDash(inst.State)}
+		if withRegion {
+			row = append(row, inst.Region)
+		}
+		row = append(row, orDash(strings.Join(tags, ",")))
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
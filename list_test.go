@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+// Test headless instance listing in both output formats
+func TestListInstances(t *testing.T) {
+	setTempHome(t)
+	withDemoMode(t)
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, listInstances(&out, "demo-dev", "us-east-1", "json"))
+		var instances []Instance
+		require.NoError(t, json.Unmarshal(out.Bytes(), &instances))
+		require.Len(t, instances, 4)
+		assert.Equal(t, "i-0a1b2c3d4e5f60001", instances[0].ID)
+		assert.Equal(t, "web-server-1", instances[0].Name)
+		assert.Equal(t, "running", instances[0].State)
+		assert.Equal(t, "production", awsssm.TagValue(instances[0].Tags, "Environment"))
+		assert.Equal(t, "10.0.1.11", instances[0].PrivateIP)
+		assert.Equal(t, "ip-10-0-1-11.ec2.internal", instances[0].PrivateDNS)
+		assert.NotContains(t, out.String(), `"region"`)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, listInstances(&out, "demo-dev", "us-east-1", "text"))
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		require.Len(t, lines, 5)
+		assert.Regexp(t, `^ID\s+NAME\s+STATE\s+TAGS$`, string(lines[0]))
+		assert.Regexp(t, `^i-0a1b2c3d4e5f60001\s+web-server-1\s+running\s+.*Environment=production`, string(lines[1]))
+	})
+
+	t.Run("empty region", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, listInstances(&out, "demo-dev", "eu-west-1", "json"))
+		assert.Equal(t, "[]\n", out.String())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.ErrorContains(t, listInstances(&out, "demo-dev", "us-east-1", "yaml"), "unknown output format")
+		assert.ErrorContains(t, listInstances(&out, "", "us-east-1", "json"), "-profile")
+		assert.ErrorContains(t, listInstances(&out, "demo-dev", "", "json"), "-region")
+		assert.Empty(t, out.String())
+	})
+}
+
+// Test listing every region with -all-regions
+func TestListInstancesAllRegions(t *testing.T) {
+	setTempHome(t)
+	withDemoMode(t)
+	allRegions = true
+	t.Cleanup(func() { allRegions = false })
+
+	var out bytes.Buffer
+	require.NoError(t, listInstances(&out, "demo-dev", "", "text"))
+	assert.Regexp(t, `^ID\s+NAME\s+STATE\s+REGION\s+TAGS\n`, out.String())
+	assert.Contains(t, out.String(), "us-west-2")
+}
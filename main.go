@@ -1,20 +1,33 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"gopkg.in/ini.v1"
+	"goversion/pkg/awsssm"
 )
 
-// Loading spinner style
-var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+// Number of list rows rendered at once
+const listWindowSize = 20
+
+// Column at which error messages wrap
+const errorWrapWidth = 100
+
+// Loading spinner style; the frames are chosen by -spinner or the config
+var spinnerFrames = spinnerStyles["braille"]
 var spinnerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700")).Bold(true)
 
 // Lipgloss styles
@@ -25,13 +38,19 @@ var (
 	itemStyle     = lipgloss.NewStyle().Padding(0, 1)
 	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#1a1a1a")).Background(lipgloss.Color("#00BFFF")).Padding(0, 1)
 	quitStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#888")).Italic(true)
+	mutedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#555")).Padding(0, 1)
 	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF3333")).Bold(true)
+	errorBoxStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#FF3333")).Padding(0, 1)
+	groupStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#888")).Underline(true).Padding(0, 1)
 )
 
-type Tag struct {
-	Key   string `json:"Key"`
-	Value string `json:"Value"`
-}
+// The discovered AWS resources, shared with the embeddable awsssm package
+type (
+	Tag             = awsssm.Tag
+	Instance        = awsssm.Instance
+	InstanceDetails = awsssm.InstanceDetails
+	Account         = awsssm.Account
+)
 
 type state int
 
@@ -40,273 +59,600 @@ const (
 	stateRegion
 	stateInstance
 	stateDone
+	statePortForward
+	stateReauth
+	stateConfirm
+	stateFavorites
+	stateMethod
+	stateRecent
+	stateAccount
+)
+
+// Kind of session started once a target is chosen
+type sessionMode int
+
+const (
+	sessionShell sessionMode = iota
+	sessionPortForward
+	sessionSSHProxy
+	// One session per marked instance; the commands are printed
+	sessionMulti
+	// ssh tunnelled through an EC2 Instance Connect Endpoint
+	sessionEICE
 )
 
 type model struct {
-	profiles          []string
-	regions           []string
-	instances         []string
-	selectedProfile   string
-	selectedRegion    string
-	selectedInstance  string
+	profiles        []string
+	regions         []string
+	instances       []Instance
+	selectedProfile string
+	selectedRegion  string
+	// Organization accounts listed with -org-role, and the one picked
+	accounts         []Account
+	filteredAccounts []Account
+	selectedAccount  Account
+	selectedInstance string
+	selectedName     string
+	lastRegion       string
+	reauthFrom       state
+	reauthErr        error
+	retry            tea.Cmd
+	config           Config
+	// Name of the connection launched from the command line, if any
+	connection        string
+	instanceSort      instanceSort
+	useProfileRegion  bool
 	cursor            int
 	err               error
 	step              state
 	filter            string
 	filteredProfiles  []string
 	filteredRegions   []string
-	filteredInstances []string
+	filteredInstances []Instance
 	loading           bool
+	loadingMsg        string
+	// Regions finished out of all of them while scanning with -all-regions
+	regionsDone  int
+	regionsTotal int
+	// Instances fetched so far by a listing that spans several pages
+	instancesLoaded   int
 	spinnerFrame      int
-	previewTags       []Tag
+	previewDetails    *InstanceDetails
 	previewLoading    bool
+	previewErr        error
 	previewInstanceId string
+	groupProfiles     bool
+	accountAliases    map[string]string
+	showTagMatrix     bool
+	tagMatrixLoading  bool
+	tagMatrix         map[string][]Tag
+	inlineErr         string
+	sessionMode       sessionMode
+	portField         int
+	remotePort        string
+	localPort         string
+	showHelp          bool
+	// The full tag values of the previewed instance are open, scrolled down
+	// by tagScroll lines
+	tagValuesOpen     bool
+	tagScroll         int
+	selectedInstances map[string]bool
+	targets           []Instance
+	width             int
+	height            int
+	allRegions        bool
+	confirm           bool
+	precheck          bool
+	regionCounts      bool
+	instanceCounts    map[string]int
+	filterHistory     []string
+	historyPos        int
+	toast             string
+	toastId           int
+	pendingOutput     []string
+	regionErrs        regionErrors
+	// The region list is built in because describe-regions was denied
+	staticRegions bool
+	lookups       context.Context
+	cancelLookups context.CancelFunc
+	// Recently used instances, checked, and the screen they were opened from
+	recent           []recentMatch
+	recentFrom       state
+	recentFromCursor int
+	cancelPreview    context.CancelFunc
+	// The current load, the model from before it and the input that started
+	// it, and whether it has run long enough to offer cancel and retry
+	loadingId      int
+	loadingFrom    *model
+	loadingTrigger tea.Msg
+	slowLoading    bool
 }
 
-func getProfiles() ([]string, error) {
-	cfg, err := ini.Load(os.ExpandEnv("$HOME/.aws/credentials"))
-	if err != nil {
-		return nil, err
+// Pause before reading the credentials file again when it doesn't parse. aws
+// sso login and credential_process refreshes rewrite it in place, so a read
+// can catch it half written. A variable so tests don't have to wait.
+var profilesRetryDelay = 200 * time.Millisecond
+
+// getProfiles lists the profiles of the files profileFiles returns, given the
+// config's profile_files, in order and without duplicates. Sections of AWS
+// CLI config files are named "profile name"; they are listed by name. A file
+// that fails to load is skipped with a warning on stderr, as long as another
+// one loads.
+func getProfiles(configured ...string) ([]string, error) {
+	if demoMode {
+		return demoProfiles()
+	}
+	files := profileFiles(configured)
+	if len(files) == 1 {
+		return readProfiles(files[0])
 	}
 	profiles := []string{}
-	for _, section := range cfg.Sections() {
-		if section.Name() != "DEFAULT" {
-			profiles = append(profiles, section.Name())
+	seen := map[string]bool{}
+	failed := []error{}
+	for _, path := range files {
+		names, err := readProfiles(path)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", path, err))
+			continue
 		}
+		for _, name := range names {
+			name = strings.TrimPrefix(name, "profile ")
+			if !seen[name] {
+				seen[name] = true
+				profiles = append(profiles, name)
+			}
+		}
+	}
+	if len(failed) == len(files) {
+		return nil, errors.Join(failed...)
+	}
+	for _, err := range failed {
+		fmt.Fprintln(os.Stderr, "Warning: skipping profiles file", err)
 	}
 	return profiles, nil
 }
 
-func getRegions(profile string) ([]string, error) {
-	cmd := exec.Command("aws", "ec2", "describe-regions", "--profile", profile, "--region", "us-west-2", "--output", "json")
-	out, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-	var result struct {
-		Regions []struct {
-			RegionName string `json:"RegionName"`
-		}
+// readProfiles lists the sections of one profiles file, reading it again
+// after a pause if it doesn't parse.
+func readProfiles(path string) ([]string, error) {
+	profiles, err := awsssm.GetProfiles(path)
+	if isParseError(err) {
+		time.Sleep(profilesRetryDelay)
+		profiles, err = awsssm.GetProfiles(path)
 	}
-	if err := json.Unmarshal(out, &result); err != nil {
+	return profiles, err
+}
+
+// isParseError reports whether err is about a file's content rather than
+// failing to read it, such as a missing file, which retrying won't fix.
+func isParseError(err error) bool {
+	var pathErr *fs.PathError
+	return err != nil && !errors.As(err, &pathErr)
+}
+
+// execCommand builds every subprocess the tool runs; cancelling ctx kills it.
+// It is a variable so tests can verify no process is spawned.
+var execCommand = exec.CommandContext
+
+// runAWS executes the AWS CLI with the given arguments and returns its stdout.
+// It is a variable so tests can substitute canned responses.
+var runAWS = execAWS
+
+// describeRunner hands the awsssm lookups to describeAWS, so they go through
+// runAWS and are retried when throttled.
+var describeRunner = awsssm.RunnerFunc(describeAWS)
+
+// execAWS runs the AWS CLI. On failure the CLI's stderr is carried in the
+// returned awsssm.CLIError.
+func execAWS(ctx context.Context, args ...string) ([]byte, error) {
+	cmd, err := awsCommand(ctx, args)
+	if err != nil {
 		return nil, err
 	}
-	regions := []string{}
-	for _, r := range result.Regions {
-		regions = append(regions, r.RegionName)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	out, err := cmd.Output()
+	logCommand("aws", args, start, err, strings.TrimSpace(stderr.String()))
+	if err != nil {
+		return nil, &awsssm.CLIError{Args: args, Err: err, Stderr: strings.TrimSpace(stderr.String())}
 	}
-	return regions, nil
+	return out, nil
 }
 
-func getInstances(profile, region string) ([]string, error) {
-	cmd := exec.Command("aws", "ec2", "describe-instances", "--profile", profile, "--region", region, "--output", "json")
-	out, err := cmd.Output()
+func getRegions(ctx context.Context, profile string) ([]string, error) {
+	// Each partition only lists its own regions, so ask one of them: the
+	// profile's own region first, which is the one it is known to reach
+	candidates := awsssm.DiscoveryRegions(profilePartition(profile), profileRegion(profile), defaultRegion())
+	regions, err := awsssm.GetRegionsFromAny(ctx, describeRunner, profile, candidates)
 	if err != nil {
 		return nil, err
 	}
-	var result struct {
-		Reservations []struct {
-			Instances []struct {
-				InstanceId string `json:"InstanceId"`
-				Tags       []struct {
-					Key   string `json:"Key"`
-					Value string `json:"Value"`
-				} `json:"Tags"`
-			}
+	return sortRegions(regions), nil
+}
+
+// getInstances returns the instances for profile/region, served from the disk
+// cache when it is fresh. refresh bypasses the cache and rewrites it.
+func getInstances(ctx context.Context, profile, region string, refresh bool) ([]Instance, error) {
+	return loadInstances(ctx, profile, region, refresh, nil)
+}
+
+// loadInstances is getInstances calling progress, when it isn't nil, with
+// the number of instances fetched so far after every describe-instances page
+// but the last. A cache hit reports no progress. The cache keeps terminated
+// instances; they are hidden on the way out.
+func loadInstances(ctx context.Context, profile, region string, refresh bool, progress func(loaded int)) ([]Instance, error) {
+	if demoMode {
+		instances, err := fetchInstancePages(ctx, profile, region, progress)
+		return hideTerminated(instances), err
+	}
+	if !refresh {
+		if instances, ok := readInstanceCache(profile, region, cacheTTL()); ok {
+			return hideTerminated(instances), nil
 		}
 	}
-	if err := json.Unmarshal(out, &result); err != nil {
+	instances, err := fetchInstancePages(ctx, profile, region, progress)
+	if err != nil {
 		return nil, err
 	}
-	instances := []string{}
-	for _, res := range result.Reservations {
-		for _, inst := range res.Instances {
-			name := ""
-			for _, tag := range inst.Tags {
-				if tag.Key == "Name" {
-					name = tag.Value
-				}
-			}
-			display := inst.InstanceId
-			if name != "" {
-				display += " (" + name + ")"
-			}
-			instances = append(instances, display)
+	// Caching is best-effort; a read-only home directory shouldn't block the picker
+	_ = writeInstanceCache(profile, region, instances)
+	return hideTerminated(instances), nil
+}
+
+func fetchInstances(ctx context.Context, profile, region string) ([]Instance, error) {
+	return fetchInstancePages(ctx, profile, region, nil)
+}
+
+func fetchInstancePages(ctx context.Context, profile, region string, progress func(loaded int)) ([]Instance, error) {
+	instances := []Instance{}
+	err := awsssm.FindInstancePages(ctx, describeRunner, profile, region, instanceFilters, func(page []Instance, more bool) {
+		instances = append(instances, page...)
+		if more && progress != nil {
+			progress(len(instances))
 		}
+	})
+	if err != nil {
+		return nil, err
 	}
 	return instances, nil
 }
 
+// sessionArgs returns the AWS CLI arguments for an interactive SSM session.
+func sessionArgs(profile, region, instanceId string) []string {
+	return []string{"ssm", "start-session", "--profile", profile, "--region", region, "--target", instanceId}
+}
+
 func startSession(profile, region, instanceId string) error {
-	cmd := exec.Command("aws", "ssm", "start-session", "--profile", profile, "--region", region, "--target", instanceId)
+	return runSession(shellSessionArgs(profile, region, instanceId))
+}
+
+func startPortForwardSession(profile, region, instanceId string, localPort, remotePort int) error {
+	if keepaliveInterval > 0 && !demoMode {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go keepAlive(ctx, localPort, keepaliveInterval)
+	}
+	return runSession(portForwardArgs(profile, region, instanceId, localPort, remotePort))
+}
+
+// runSession runs an interactive aws CLI session attached to the terminal.
+func runSession(args []string) error {
+	return runSessionTo(args, os.Stderr)
+}
+
+// runSessionTo is runSession with the session's stderr going to stderr, so
+// its errors can be inspected.
+func runSessionTo(args []string, stderr io.Writer) error {
+	if demoMode {
+		fmt.Printf("Demo mode, would run: aws %s\n", shellJoin(args))
+		return nil
+	}
+	cmd, err := awsCommand(context.Background(), args)
+	if err != nil {
+		return err
+	}
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = stderr
 	cmd.Stdin = os.Stdin
-	fmt.Printf("Running: aws ssm start-session --profile %s --region %s --target %s\n", profile, region, instanceId)
-	return cmd.Run()
+	if !quiet {
+		fmt.Printf("Running: aws %s\n", shellJoin(args))
+	}
+	start := time.Now()
+	err = runAttached(cmd)
+	logCommand("aws", args, start, err, "")
+	// A tmux window outlives ssmssh, so there is nothing to summarize
+	if sessionSummary && !useTmux {
+		printSummary(os.Stderr, args, time.Since(start), err)
+	}
+	return err
 }
 
-func getInstanceTags(profile, region, instanceId string) ([]Tag, error) {
-	cmd := exec.Command("aws", "ec2", "describe-instances", "--profile", profile, "--region", region, "--instance-ids", instanceId, "--output", "json")
-	out, err := cmd.Output()
-	if err != nil {
-		return nil, err
+// shellJoin renders args as a copy-pasteable shell command line, single-quoting
+// any argument containing characters the shell would interpret.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.Trim(a, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
 	}
-	var result struct {
-		Reservations []struct {
-			Instances []struct {
-				Tags []Tag `json:"Tags"`
-			}
+	return strings.Join(quoted, " ")
+}
+
+func getInstanceDetails(ctx context.Context, profile, region, instanceId string) (InstanceDetails, error) {
+	return awsssm.GetInstanceDetails(ctx, describeRunner, profile, region, instanceId)
+}
+
+func regionsCmd(ctx context.Context, profile string, refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		regions, err := loadRegionList(ctx, profile, refresh)
+		if isAccessDenied(err) {
+			// Locked-down roles often lack ec2:DescribeRegions; don't let
+			// that block the whole tool
+			return struct {
+				staticRegions []string
+			}{sortRegions(partitionRegions(profilePartition(profile)))}
+		}
+		return struct {
+			regions []string
+			err     error
+		}{regions, lookupErr(ctx, err, "regions", loadTimeout)}
+	}
+}
+
+// loadRegionList is getRegions served from the disk cache for up to a day.
+// refresh bypasses the cache and rewrites it. Like the instance cache, it is
+// off when SSMSSH_CACHE_TTL is 0.
+func loadRegionList(ctx context.Context, profile string, refresh bool) ([]string, error) {
+	useCache := !demoMode && cacheTTL() > 0
+	if useCache && !refresh {
+		if regions, ok := readRegionCache(profile, regionCacheTTL); ok {
+			return regions, nil
 		}
 	}
-	if err := json.Unmarshal(out, &result); err != nil {
+	regions, err := getRegions(ctx, profile)
+	if err != nil {
 		return nil, err
 	}
-	tags := []Tag{}
-	for _, res := range result.Reservations {
-		for _, inst := range res.Instances {
-			tags = append(tags, inst.Tags...)
-		}
+	if useCache {
+		_ = writeRegionCache(profile, regions)
 	}
-	return tags, nil
+	return regions, nil
 }
 
-func regionsCmd(profile string) tea.Cmd {
+func configuredRegionsCmd(regions []string) tea.Cmd {
 	return func() tea.Msg {
-		ch := make(chan struct {
+		return struct {
 			regions []string
 			err     error
-		}, 1)
-		go func() {
-			regions, err := getRegions(profile)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "getRegions error: %v\n", err)
-			}
-			ch <- struct {
-				regions []string
-				err     error
-			}{regions, err}
-		}()
+		}{regions, nil}
+	}
+}
+
+// progressStream connects a background lookup to the program: send delivers a
+// message, and next waits for the following one. Progress messages carry next
+// so the model keeps reading until the final message.
+func progressStream(ctx context.Context) (tea.Cmd, func(tea.Msg)) {
+	updates := make(chan tea.Msg)
+	next := func() tea.Msg {
 		select {
-		case msg := <-ch:
+		case msg := <-updates:
 			return msg
-		case <-time.After(15 * time.Second):
-			fmt.Fprintf(os.Stderr, "timeout loading regions\n")
-			return struct {
-				regions []string
-				err     error
-			}{nil, fmt.Errorf("timeout loading regions")}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	send := func(msg tea.Msg) {
+		select {
+		case updates <- msg:
+		case <-ctx.Done():
+			// The program has stopped reading, e.g. after quitting
 		}
 	}
+	return next, send
 }
 
-func instancesCmd(profile, region string) tea.Cmd {
+// instancesCmd lists the instances of profile/region. The listing runs in the
+// background and streams its progress: each describe-instances page sends a
+// message with the running total, carrying the command that waits for the
+// next message, until the final message with the instances arrives.
+func instancesCmd(ctx context.Context, profile, region string, refresh bool) tea.Cmd {
+	next, send := progressStream(ctx)
 	return func() tea.Msg {
-		ch := make(chan struct {
-			instances []string
-			err       error
-		}, 1)
 		go func() {
-			instances, err := getInstances(profile, region)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "getInstances error: %v\n", err)
-			}
-			ch <- struct {
-				instances []string
+			ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+			defer cancel()
+			instances, err := loadInstances(ctx, profile, region, refresh, func(loaded int) {
+				send(struct {
+					instancesLoaded int
+					nextProgress    tea.Cmd
+				}{loaded, next})
+			})
+			send(struct {
+				instances []Instance
 				err       error
-			}{instances, err}
+			}{instances, lookupErr(ctx, err, "instances", loadTimeout)})
 		}()
-		select {
-		case msg := <-ch:
-			return msg
-		case <-time.After(15 * time.Second):
-			fmt.Fprintf(os.Stderr, "timeout loading instances\n")
-			return struct {
-				instances []string
-				err       error
-			}{nil, fmt.Errorf("timeout loading instances")}
-		}
+		return next()
 	}
 }
 
-func previewTagsCmd(profile, region, instanceId string) tea.Cmd {
+// Pause before loading a preview, so scrolling through the list only loads
+// the row the cursor stops on. A variable so tests don't have to wait.
+var previewDebounce = 150 * time.Millisecond
+
+// previewDetailsCmd loads the preview pane once the cursor has rested for
+// previewDebounce. A lookup cancelled because the cursor moved on or the user
+// left the instance list produces no message.
+func previewDetailsCmd(ctx context.Context, profile, region, instanceId string) tea.Cmd {
 	return func() tea.Msg {
-		ch := make(chan struct {
-			tags       []Tag
-			instanceId string
-			err        error
-		}, 1)
-		go func() {
-			tags, err := getInstanceTags(profile, region, instanceId)
-			ch <- struct {
-				tags       []Tag
-				instanceId string
-				err        error
-			}{tags, instanceId, err}
-		}()
 		select {
-		case msg := <-ch:
-			return msg
-		case <-time.After(5 * time.Second):
+		case <-time.After(previewDebounce):
+		case <-ctx.Done():
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(ctx, previewTimeout)
+		defer cancel()
+		details, err := getInstanceDetails(ctx, profile, region, instanceId)
+		if lookupCancelled(ctx) {
+			return nil
+		}
+		if err = lookupErr(ctx, err, "instance details", previewTimeout); isTimeout(err) {
 			return struct {
-				tags       []Tag
+				details    *InstanceDetails
 				instanceId string
 				err        error
-			}{nil, instanceId, fmt.Errorf("timeout loading tags")}
+			}{nil, instanceId, err}
 		}
+		return struct {
+			details    *InstanceDetails
+			instanceId string
+			err        error
+		}{&details, instanceId, err}
 	}
 }
 
 func initialModel() model {
-	profiles, err := getProfiles()
+	config, configErr := loadConfig()
+	profiles, err := getProfiles(config.ProfileFiles...)
+	if err == nil {
+		err = configErr
+	}
+	last := loadLastSelection()
+	step := stateProfile
+	if len(config.Favorites) > 0 {
+		// Saved targets are one keystroke away; ← browses all profiles
+		step = stateFavorites
+	}
+	lookups, cancelLookups := context.WithCancel(discovery)
+	// An unknown order is reported by loadConfig; fall back to name order
+	order, _ := parseInstanceSort(config.InstanceSort)
 	return model{
 		profiles:         profiles,
 		filteredProfiles: profiles,
-		cursor:           0,
+		cursor:           indexOf(profiles, last.Profile),
 		err:              err,
-		step:             stateProfile,
+		step:             step,
 		filter:           "",
+		lastRegion:       last.Region,
+		config:           config,
+		instanceSort:     order,
+		useProfileRegion: useProfileRegion || config.UseProfileRegion,
+		allRegions:       allRegions,
+		confirm:          confirmSession,
+		precheck:         precheckAgent && !demoMode,
+		regionCounts:     regionCounts,
+		filterHistory:    loadFilterHistory(),
+		lookups:          lookups,
+		cancelLookups:    cancelLookups,
 	}
 }
 
 func (m model) Init() tea.Cmd {
+	if m.connection != "" {
+		return tea.Batch(resolveConnectionCmd(m.lookupContext(), m.config.Connections[m.connection]), spinnerTick())
+	}
+	if m.step == stateRecent && m.loading {
+		return tea.Batch(resolveRecentCmd(m.lookupContext(), loadRecent()), spinnerTick())
+	}
 	return nil
 }
 
+// Update handles msg and keeps track of lookups started by it, so a slow one
+// can be cancelled or retried from the loading screen.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	return m.trackLoading(updated.(model), msg, cmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		s := msg.String()
-		// Only allow quit on command-q and command-c and esc
-		switch s {
-		case "cmd+q", "cmd+c", "esc":
+		if m.showHelp {
+			return m.updateHelp(s)
+		}
+		if m.tagValuesOpen {
+			return m.updateTagValues(s)
+		}
+		// Like the other letter keys, ? is typed into a filter in progress
+		if s == "?" && !m.loading && m.filter == "" {
+			m.showHelp = true
+			return m, nil
+		}
+		// On the confirmation screen esc backs out instead of quitting
+		if m.step == stateConfirm {
+			if m.keys().matches(actionQuit, s) {
+				return m, tea.Quit
+			}
+			return m.updateConfirm(s)
+		}
+		// Quit on the quit keys, and on filter-clear once the filter is
+		// empty; filter-clear with a filter clears it below
+		if m.isKey(actionQuit, s) || (m.filter == "" && m.isKey(actionFilterClear, s)) {
 			return m, tea.Quit
 		}
 		if m.loading {
-			// Ignore other input while loading
+			// A slow load can be given up on; other input is ignored
+			if m.canAbandonLoading() {
+				switch s {
+				case "c":
+					return m.cancelLoading()
+				case "r":
+					return m.retryLoading()
+				}
+			}
 			return m, nil
 		}
-		// Up/down arrows always work for navigation, k/j only if filter is empty
-		switch s {
-		case "up":
+		m.inlineErr = ""
+		if m.step == statePortForward {
+			return m.updatePortForward(s)
+		}
+		if m.step == stateReauth {
+			return m.updateReauth(s)
+		}
+		if m.step == stateRecent {
+			return m.updateRecent(s)
+		}
+		if s == recentKey {
+			switch m.step {
+			case stateProfile, stateRegion, stateInstance, stateFavorites:
+				return m.showRecent()
+			}
+		}
+		if m.step == stateFavorites {
+			return m.updateFavorites(s)
+		}
+		if m.step == stateMethod {
+			return m.updateMethod(s)
+		}
+		if m.isKey(actionBack, s) {
+			return m.back(), nil
+		}
+		switch {
+		case m.isKey(actionUp, s):
 			switch m.step {
 			case stateProfile:
 				if m.cursor > 0 {
 					m.cursor--
 				}
-			case stateRegion:
+			case stateRegion, stateAccount:
 				if m.cursor > 0 {
 					m.cursor--
 				}
 			case stateInstance:
 				if m.cursor > 0 {
 					m.cursor--
-					m.previewLoading = true
-					m.previewInstanceId = strings.Split(m.filteredInstances[m.cursor], " ")[0]
-					return m, previewTagsCmd(m.selectedProfile, m.selectedRegion, m.previewInstanceId)
+					return m.preview()
 				}
 			}
-		case "down":
+			return m, nil
+		case m.isKey(actionDown, s):
 			switch m.step {
 			case stateProfile:
 				if m.cursor < len(m.filteredProfiles)-1 {
@@ -316,97 +662,213 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.cursor < len(m.filteredRegions)-1 {
 					m.cursor++
 				}
+			case stateAccount:
+				if m.cursor < len(m.filteredAccounts)-1 {
+					m.cursor++
+				}
 			case stateInstance:
 				if m.cursor < len(m.filteredInstances)-1 {
 					m.cursor++
-					m.previewLoading = true
-					m.previewInstanceId = strings.Split(m.filteredInstances[m.cursor], " ")[0]
-					return m, previewTagsCmd(m.selectedProfile, m.selectedRegion, m.previewInstanceId)
+					return m.preview()
 				}
 			}
+			return m, nil
 		}
-		if m.filter == "" {
-			switch s {
-			case "k":
-				switch m.step {
-				case stateProfile:
-					if m.cursor > 0 {
-						m.cursor--
-					}
-				case stateRegion:
-					if m.cursor > 0 {
-						m.cursor--
-					}
-				case stateInstance:
-					if m.cursor > 0 {
-						m.cursor--
-						m.previewLoading = true
-						m.previewInstanceId = strings.Split(m.filteredInstances[m.cursor], " ")[0]
-						return m, previewTagsCmd(m.selectedProfile, m.selectedRegion, m.previewInstanceId)
-					}
+		// Jump a page at a time, or to either end of the list
+		switch s {
+		case "pgup":
+			return m.jumpTo(m.cursor - m.windowSize())
+		case "pgdown":
+			return m.jumpTo(m.cursor + m.windowSize())
+		case "home":
+			return m.jumpTo(0)
+		case "end":
+			return m.jumpTo(m.listLen() - 1)
+		}
+		if row, ok := quickSelectRow(s); ok {
+			return m.quickSelect(row)
+		}
+		// Actions are bound to alt so every printable key goes to the filter
+		switch s {
+		case "alt+r":
+			// Force-refresh the region list, bypassing the disk cache.
+			// Configured regions and a profile's region offer aren't
+			// looked up, so there is nothing to refresh.
+			if m.step == stateRegion && len(m.config.Regions) == 0 && !m.staticRegions && !isRegionOffer(m.regions) {
+				m.loading = true
+				m.loadingMsg = "Refreshing regions for " + m.selectedProfile + "..."
+				return m, regionsCmd(m.lookupContext(), m.selectedProfile, true)
+			}
+			// Force-refresh the instance list, bypassing the disk cache
+			if m.step == stateInstance {
+				m.loading = true
+				if m.allRegions {
+					m.loadingMsg = "Refreshing instances in all regions..."
+					return m, allRegionsCmd(m.lookupContext(), m.selectedProfile, m.config.Regions, true)
 				}
-			case "j":
-				switch m.step {
-				case stateProfile:
-					if m.cursor < len(m.filteredProfiles)-1 {
-						m.cursor++
-					}
-				case stateRegion:
-					if m.cursor < len(m.filteredRegions)-1 {
-						m.cursor++
-					}
-				case stateInstance:
-					if m.cursor < len(m.filteredInstances)-1 {
-						m.cursor++
-						m.previewLoading = true
-						m.previewInstanceId = strings.Split(m.filteredInstances[m.cursor], " ")[0]
-						return m, previewTagsCmd(m.selectedProfile, m.selectedRegion, m.previewInstanceId)
-					}
+				m.loadingMsg = "Refreshing instances in " + m.selectedRegion + "..."
+				return m, instancesCmd(m.lookupContext(), m.selectedProfile, m.selectedRegion, true)
+			}
+		case "alt+p":
+			// Prompt for ports and start a port forwarding session
+			if m.step == stateInstance && len(m.filteredInstances) > 0 {
+				inst := m.filteredInstances[m.cursor]
+				if !inst.Connectable() {
+					m.inlineErr = fmt.Sprintf("Instance is %s; only running instances can be connected to", inst.State)
+					return m, nil
 				}
+				m = m.selectInstance(inst)
+				m.step = statePortForward
+				m.portField = portFieldRemote
+				m.remotePort, m.localPort = "", ""
+				return m, nil
+			}
+		case "alt+s":
+			// Print an SSH ProxyCommand for the highlighted instance and exit
+			if m.step == stateInstance && len(m.filteredInstances) > 0 {
+				if currentRoleArn() != "" {
+					m.inlineErr = roleCommandMsg
+					return m, nil
+				}
+				m = m.selectInstance(m.filteredInstances[m.cursor])
+				m.sessionMode = sessionSSHProxy
+				m.step = stateDone
+				return m, tea.Quit
+			}
+		case "alt+f":
+			// Save the highlighted instance as a favorite, or forget it
+			if m.step == stateInstance {
+				return m.toggleFavorite()
+			}
+		case "alt+y", "alt+Y":
+			// Copy the highlighted instance ID, or its session command with alt+Y
+			if m.step == stateInstance {
+				return m.copyHighlighted(s == "alt+Y")
+			}
+		case "alt+t":
+			// Show or hide the details preview
+			if m.step == stateInstance {
+				return m.togglePreview()
+			}
+		case "alt+v":
+			// Show the full tag values the preview pane cuts short
+			if m.step == stateInstance {
+				return m.showTagValues()
+			}
+		case "alt+o":
+			// Cycle the order of the instance list
+			if m.step == stateInstance {
+				return m.cycleSort()
+			}
+		case "alt+m":
+			// Toggle the tag matrix for the visible instances
+			if m.step == stateInstance {
+				m.showTagMatrix = !m.showTagMatrix
+				return m.fetchTagMatrix()
 			}
 		}
-		switch s {
-		case "enter":
+		switch {
+		case s == "tab":
+			// Tab marks and pins; space is typed into the filter like any other key
+			if m.step == stateInstance {
+				return m.toggleMark()
+			}
+			if m.step == stateRegion {
+				return m.togglePin()
+			}
+			// Toggle grouping profiles under their account alias
+			if m.step == stateProfile {
+				m.groupProfiles = !m.groupProfiles
+				if m.groupProfiles && m.accountAliases == nil {
+					m.loading = true
+					m.loadingMsg = "Looking up account aliases..."
+					return m, aliasesCmd(m.profiles)
+				}
+				m.filteredProfiles = m.profileList()
+				m.cursor = 0
+				return m, nil
+			}
+		case m.isKey(actionSelect, s):
+			m = m.rememberFilter()
 			switch m.step {
 			case stateProfile:
 				if len(m.filteredProfiles) == 0 {
-					m.err = fmt.Errorf("no AWS profiles found")
-					return m, tea.Quit
+					// The view explains the empty list; stay open until the user quits
+					return m, nil
 				}
 				m.selectedProfile = m.filteredProfiles[m.cursor]
-				m.loading = true
-				return m, regionsCmd(m.selectedProfile)
+				var next tea.Cmd
+				if orgRole != "" {
+					m, next = m.loadAccounts()
+				} else {
+					var updated tea.Model
+					updated, next = m.afterProfile()
+					m = updated.(model)
+				}
+				// Log in first rather than failing on an SSO profile without a valid token
+				if needsSSOLogin(m.selectedProfile) {
+					m.reauthFrom = stateProfile
+					m.retry = next
+					return m, ssoLoginCmd(m.selectedProfile)
+				}
+				return m, next
+			case stateAccount:
+				return m.selectAccount()
 			case stateRegion:
 				if len(m.filteredRegions) == 0 {
-					m.err = fmt.Errorf("no regions found")
-					return m, tea.Quit
+					return m, nil
+				}
+				if m.filteredRegions[m.cursor] == otherRegionsItem {
+					return m.loadRegions()
 				}
 				m.selectedRegion = m.filteredRegions[m.cursor]
 				m.loading = true
-				return m, instancesCmd(m.selectedProfile, m.selectedRegion)
+				m.loadingMsg = "Fetching instances in " + m.selectedRegion + "..."
+				return m, instancesCmd(m.lookupContext(), m.selectedProfile, m.selectedRegion, false)
 			case stateInstance:
+				if len(m.selectedInstances) > 0 {
+					return m.selectMarked()
+				}
 				if len(m.filteredInstances) == 0 {
-					m.err = fmt.Errorf("no instances found")
+					return m, nil
+				}
+				// SSM can only reach running instances
+				if inst := m.filteredInstances[m.cursor]; !inst.Connectable() {
+					m.inlineErr = fmt.Sprintf("Instance is %s; only running instances can be connected to", inst.State)
+					return m, nil
+				}
+				m = m.selectInstance(m.filteredInstances[m.cursor])
+				if sshConfigOutput {
+					m.sessionMode = sessionSSHProxy
+					m.step = stateDone
 					return m, tea.Quit
 				}
-				m.selectedInstance = strings.Split(m.filteredInstances[m.cursor], " ")[0]
-				m.step = stateDone
-				return m, tea.Quit
+				return m.startSelected()
 			}
-		case "backspace":
+		case m.isKey(actionFilterClear, s):
+			m.filter = ""
+			m.historyPos = 0
+		case s == "ctrl+p":
+			// Recall an older filter from the history
+			m = m.recallFilter(1)
+		case s == "ctrl+n":
+			m = m.recallFilter(-1)
+		case s == "backspace":
 			if len(m.filter) > 0 {
 				m.filter = m.filter[:len(m.filter)-1]
 			}
+			m.historyPos = 0
 		default:
 			// Only filter on printable runes
-			if len(s) == 1 && s[0] >= 32 && s[0] <= 126 {
+			if isFilterKey(s) {
 				m.filter += s
+				m.historyPos = 0
 			}
 		}
 		// Update filtered lists
 		switch m.step {
 		case stateProfile:
-			m.filteredProfiles = filterList(m.profiles, m.filter)
+			m.filteredProfiles = m.profileList()
 			if len(m.filteredProfiles) == 0 {
 				m.cursor = 0
 			} else {
@@ -418,7 +880,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case stateRegion:
-			m.filteredRegions = filterList(m.regions, m.filter)
+			m.filteredRegions = m.regionList()
 			if len(m.filteredRegions) == 0 {
 				m.cursor = 0
 			} else {
@@ -429,8 +891,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.cursor = 0
 				}
 			}
+		case stateAccount:
+			m.filteredAccounts = m.accountList()
+			if len(m.filteredAccounts) == 0 {
+				m.cursor = 0
+			} else {
+				if m.cursor >= len(m.filteredAccounts) {
+					m.cursor = len(m.filteredAccounts) - 1
+				}
+				if m.cursor < 0 {
+					m.cursor = 0
+				}
+			}
 		case stateInstance:
-			m.filteredInstances = filterList(m.instances, m.filter)
+			m.filteredInstances = filterInstances(m.instances, m.filter)
 			if len(m.filteredInstances) == 0 {
 				m.cursor = 0
 			} else {
@@ -440,10 +914,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.cursor < 0 {
 					m.cursor = 0
 				}
-				// Preview loading
-				m.previewLoading = true
-				m.previewInstanceId = strings.Split(m.filteredInstances[m.cursor], " ")[0]
-				return m, previewTagsCmd(m.selectedProfile, m.selectedRegion, m.previewInstanceId)
+				return m.preview()
 			}
 		}
 	case struct {
@@ -451,38 +922,202 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		err     error
 	}:
 		m.loading = false
+		if isExpiredCredentials(msg.err) {
+			return m.promptReauth(msg.err, regionsCmd(m.lookupContext(), m.selectedProfile, true)), nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
 		m.regions = msg.regions
-		m.filteredRegions = msg.regions
-		m.cursor = 0
 		m.filter = ""
+		m.filteredRegions = m.regionList()
+		m.staticRegions = false
+		m.cursor = regionCursor(m.filteredRegions, m.lastRegion)
 		m.step = stateRegion
+		// The offer of a profile's region is there to avoid lookups
+		if m.regionCounts && !isRegionOffer(m.regions) {
+			m.instanceCounts = map[string]int{}
+			return m, regionCountsCmd(m.lookupContext(), m.selectedProfile, m.regions)
+		}
+	case struct {
+		accounts    []Account
+		accountsErr error
+	}:
+		return m.updateAccounts(msg.accounts, msg.accountsErr)
+	case struct {
+		staticRegions []string
+	}:
+		updated, cmd := m.Update(struct {
+			regions []string
+			err     error
+		}{msg.staticRegions, nil})
+		result := updated.(model)
+		result.staticRegions = true
+		return result, cmd
+	case struct {
+		instancesLoaded int
+		nextProgress    tea.Cmd
+	}:
+		m.instancesLoaded = msg.instancesLoaded
+		// Keep reading until the final message so the listing never blocks
+		return m.continueLoading(msg.nextProgress)
 	case struct {
-		instances []string
+		instances []Instance
 		err       error
 	}:
 		m.loading = false
+		m.instancesLoaded = 0
+		if isExpiredCredentials(msg.err) {
+			return m.promptReauth(msg.err, instancesCmd(m.lookupContext(), m.selectedProfile, m.selectedRegion, true)), nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.instances = sortInstances(msg.instances, m.instanceSort)
+		m.filteredInstances = m.instances
+		m.selectedInstances = nil
+		m.cursor = 0
+		m.filter = ""
+		m.step = stateInstance
+		if len(m.filteredInstances) > 0 {
+			// Load the details of the first instance straight away
+			return m.preview()
+		}
+	case struct {
+		regionsDone, regionsTotal int
+		nextRegion                tea.Cmd
+	}:
+		m.regionsDone, m.regionsTotal = msg.regionsDone, msg.regionsTotal
+		return m.continueLoading(msg.nextRegion)
+	case struct {
+		regionInstances []Instance
+		regionErrs      regionErrors
+		err             error
+	}:
+		m.loading = false
+		m.regionsDone, m.regionsTotal = 0, 0
+		if isExpiredCredentials(msg.err) {
+			return m.promptReauth(msg.err, allRegionsCmd(m.lookupContext(), m.selectedProfile, m.config.Regions, true)), nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
-		m.instances = msg.instances
-		m.filteredInstances = msg.instances
+		// Regions that failed are reported above the list rather than failing it
+		m.regionErrs = msg.regionErrs
+		m.instances = sortInstances(msg.regionInstances, m.instanceSort)
+		m.filteredInstances = m.instances
+		m.selectedInstances = nil
 		m.cursor = 0
 		m.filter = ""
 		m.step = stateInstance
+		if len(m.filteredInstances) > 0 {
+			// Load the details of the first instance straight away
+			return m.preview()
+		}
+	case struct {
+		offline     []agentStatus
+		precheckErr error
+	}:
+		return m.updatePrecheck(msg.offline, msg.precheckErr)
+	case struct {
+		matrixTags map[string][]Tag
+		err        error
+	}:
+		return m.updateTagMatrix(msg.matrixTags, msg.err)
+	case struct {
+		loginErr error
+	}:
+		if msg.loginErr != nil {
+			m.err = msg.loginErr
+			return m, nil
+		}
+		// Logged in: go back to where the failure happened and retry
+		m.step = m.reauthFrom
+		m.reauthErr = nil
+		m.loading = true
+		retry := m.retry
+		m.retry = nil
+		return m, tea.Batch(retry, spinnerTick())
+	case struct {
+		aliases map[string]string
+	}:
+		m.loading = false
+		m.accountAliases = msg.aliases
+		m.filteredProfiles = m.profileList()
+		m.cursor = 0
 	case struct {
-		tags       []Tag
+		details    *InstanceDetails
 		instanceId string
 		err        error
 	}:
+		// Ignore stale responses for an instance no longer highlighted
 		if msg.instanceId == m.previewInstanceId {
-			m.previewTags = msg.tags
+			m.previewDetails = msg.details
+			m.previewErr = msg.err
+			if msg.err != nil {
+				m.previewDetails = nil
+			}
 			m.previewLoading = false
 		}
+	case struct {
+		connectionInstances []Instance
+		err                 error
+	}:
+		return m.updateConnectionInstances(msg.connectionInstances, msg.err)
+	case struct {
+		recentMatches []recentMatch
+	}:
+		return m.updateRecentMatches(msg.recentMatches)
+	case struct {
+		favoriteInstances []Instance
+		err               error
+	}:
+		return m.updateFavoriteInstances(msg.favoriteInstances, msg.err)
+	case struct {
+		endpoints    []awsssm.InstanceConnectEndpoint
+		endpointsErr error
+	}:
+		return m.updateEndpoints(msg.endpoints, msg.endpointsErr)
+	case struct {
+		countProfile string
+		countRegion  string
+		count        int
+		countErr     error
+	}:
+		return m.updateRegionCount(msg.countProfile, msg.countRegion, msg.count, msg.countErr), nil
+	case struct {
+		deadlineErr error
+	}:
+		// A session already chosen is left to start
+		if m.step == stateDone {
+			return m, nil
+		}
+		m.err = msg.deadlineErr
+		return m, tea.Quit
+	case struct {
+		slowLoadingId int
+		rest          tea.Cmd
+	}:
+		return m.updateSlowLoading(msg.slowLoadingId, msg.rest)
+	case struct {
+		slowLoadedId int
+		loaded       tea.Msg
+	}:
+		return m.updateSlowLoaded(msg.slowLoadedId, msg.loaded)
+	case struct{ toastId int }:
+		if msg.toastId == m.toastId {
+			m.toast = ""
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		// A taller window shows tag matrix rows without tags yet
+		return m.fetchTagMatrix()
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
 	case tea.Msg:
 		// Spinner tick: use a custom message type
 		if m.loading {
@@ -504,155 +1139,1016 @@ func spinnerTick() tea.Cmd {
 	}
 }
 
-func filterList(list []string, filter string) []string {
+// profileList returns the filtered profiles, ordered by account alias group
+// when the grouped view is enabled.
+func (m model) profileList() []string {
+	filtered := fuzzyFilter(m.profiles, m.filter)
+	if m.groupProfiles {
+		return flattenProfileGroups(groupProfilesByAlias(filtered, m.accountAliases))
+	}
+	return filtered
+}
+
+// afterProfile goes on from the selected profile, or the account picked for
+// it: to the instances of all regions with -all-regions, otherwise to the
+// regions.
+func (m model) afterProfile() (tea.Model, tea.Cmd) {
+	if m.allRegions {
+		m.loading = true
+		m.loadingMsg = "Fetching instances in all regions..."
+		return m, allRegionsCmd(m.lookupContext(), m.selectedProfile, m.config.Regions, false)
+	}
+	if region := profileRegion(m.selectedProfile); region != "" {
+		return m.offerProfileRegion(region)
+	}
+	return m.loadRegions()
+}
+
+// back moves to the previous step of the state machine, clearing the filter
+// and placing the cursor on the item chosen before.
+func (m model) back() model {
+	m = m.leaveScreen()
+	m.filter = ""
+	switch m.step {
+	case stateProfile:
+		if len(m.config.Favorites) > 0 {
+			m.step = stateFavorites
+			m.cursor = 0
+		}
+	case stateRegion:
+		if orgRole != "" {
+			return m.backToAccounts()
+		}
+		m.step = stateProfile
+		m.filteredProfiles = m.profileList()
+		m.cursor = indexOf(m.filteredProfiles, m.selectedProfile)
+	case stateAccount:
+		m.step = stateProfile
+		m.filteredProfiles = m.profileList()
+		m.cursor = indexOf(m.filteredProfiles, m.selectedProfile)
+	case stateInstance:
+		if m.allRegions && orgRole != "" {
+			m = m.backToAccounts()
+			m.regionErrs = nil
+		} else if m.allRegions {
+			// There is no region step in the all-regions view
+			m.step = stateProfile
+			m.filteredProfiles = m.profileList()
+			m.cursor = indexOf(m.filteredProfiles, m.selectedProfile)
+			m.regionErrs = nil
+		} else {
+			m.step = stateRegion
+			m.filteredRegions = m.regionList()
+			m.cursor = indexOf(m.filteredRegions, m.selectedRegion)
+		}
+		m.previewDetails = nil
+		m.previewErr = nil
+		m.previewLoading = false
+		m.previewInstanceId = ""
+		m.selectedInstances = nil
+		m.showTagMatrix = false
+		m.tagMatrixLoading = false
+		m.tagMatrix = nil
+	case stateConfirm:
+		if m.sessionMode == sessionPortForward {
+			// Keep the entered ports so they can be corrected
+			m.step = statePortForward
+			m.sessionMode = sessionShell
+			return m
+		}
+		// Marks are kept so the selection can be adjusted
+		m.sessionMode = sessionShell
+		m.targets = nil
+		m = m.backToInstances()
+	case statePortForward, stateMethod:
+		m = m.backToInstances()
+	}
+	return m
+}
+
+// lookupContext returns the context for lookups started from the current
+// screen. It is cancelled when the user leaves the screen, killing any AWS CLI
+// processes still running for it.
+func (m model) lookupContext() context.Context {
+	if m.lookups == nil {
+		return discovery
+	}
+	return m.lookups
+}
+
+// leaveScreen cancels the current screen's lookups and starts a fresh context
+// for the next one.
+func (m model) leaveScreen() model {
+	if m.cancelLookups != nil {
+		m.cancelLookups()
+	}
+	m.lookups, m.cancelLookups = context.WithCancel(discovery)
+	return m
+}
+
+// listLen returns the length of the list shown in the current step.
+func (m model) listLen() int {
+	switch m.step {
+	case stateProfile:
+		return len(m.filteredProfiles)
+	case stateRegion:
+		return len(m.filteredRegions)
+	case stateAccount:
+		return len(m.filteredAccounts)
+	case stateInstance:
+		return len(m.filteredInstances)
+	}
+	return 0
+}
+
+// emptyListMessage explains why the current list has nothing to show.
+func (m model) emptyListMessage() string {
+	switch m.step {
+	case stateProfile:
+		if len(m.profiles) == 0 {
+			return "No AWS profiles found — add one to ~/.aws/credentials or ~/.aws/config"
+		}
+		return "No profiles match \"" + m.filter + "\""
+	case stateRegion:
+		if len(m.regions) == 0 {
+			return "No regions found for profile " + m.selectedProfile
+		}
+		return "No regions match \"" + m.filter + "\""
+	case stateAccount:
+		if len(m.accounts) == 0 {
+			return "No active accounts found in the organization of " + m.selectedProfile
+		}
+		return "No accounts match \"" + m.filter + "\""
+	case stateInstance:
+		if len(m.instances) == 0 {
+			found := "No instances found"
+			if len(instanceFilters) > 0 {
+				found = "No instances match -filter"
+			}
+			if m.allRegions {
+				return found + " in any region — press ←/h to pick another profile"
+			}
+			return found + " in " + m.selectedRegion + " — press ←/h to pick another region"
+		}
+		return "No instances match \"" + m.filter + "\""
+	}
+	return ""
+}
+
+// jumpTo moves the cursor to index, clamped to the current list, and loads
+// the preview when the highlighted instance changes.
+func (m model) jumpTo(index int) (tea.Model, tea.Cmd) {
+	n := m.listLen()
+	if n == 0 {
+		return m, nil
+	}
+	if index >= n {
+		index = n - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index == m.cursor {
+		return m, nil
+	}
+	m.cursor = index
+	if m.step == stateInstance {
+		return m.preview()
+	}
+	return m, nil
+}
+
+// backToInstances returns to the instance list with the cursor on the
+// previously selected instance.
+func (m model) backToInstances() model {
+	m.step = stateInstance
+	m.filteredInstances = m.instances
+	m.cursor = 0
+	for i, inst := range m.filteredInstances {
+		if inst.ID == m.selectedInstance {
+			m.cursor = i
+		}
+	}
+	m.selectedInstance, m.selectedName = "", ""
+	return m
+}
+
+// visibleInstanceIds returns the IDs of the instances in the rendered window.
+func (m model) visibleInstanceIds() []string {
+	start, end := windowBounds(m.cursor, len(m.filteredInstances), m.windowSize())
+	ids := []string{}
+	for _, inst := range m.filteredInstances[start:end] {
+		ids = append(ids, inst.ID)
+	}
+	return ids
+}
+
+// visibleInstanceIdsByRegion groups the rendered window's instance IDs by the
+// region each instance lives in.
+func (m model) visibleInstanceIdsByRegion() map[string][]string {
+	start, end := windowBounds(m.cursor, len(m.filteredInstances), m.windowSize())
+	ids := map[string][]string{}
+	for _, inst := range m.filteredInstances[start:end] {
+		region := m.instanceRegion(inst)
+		ids[region] = append(ids[region], inst.ID)
+	}
+	return ids
+}
+
+// instanceRegion returns the region inst lives in: its own in the
+// all-regions view, otherwise the selected one.
+func (m model) instanceRegion(inst Instance) string {
+	if inst.Region != "" {
+		return inst.Region
+	}
+	return m.selectedRegion
+}
+
+// selectInstance records inst as the session target, including its region
+// when it came from the all-regions view.
+func (m model) selectInstance(inst Instance) model {
+	m.selectedInstance = inst.ID
+	m.selectedName = inst.Name
+	m.selectedRegion = m.instanceRegion(inst)
+	return m
+}
+
+// preview starts loading the tags of the highlighted instance, cancelling
+// the load for the previously highlighted one.
+func (m model) preview() (model, tea.Cmd) {
+	m = m.cancelPreviewLoad()
+	// Rows scrolled into the tag matrix need their tags
+	m, matrix := m.fetchTagMatrix()
+	// A hidden pane needs no details, so don't spend API calls on them
+	if m.config.HidePreview {
+		return m, matrix
+	}
+	inst := m.filteredInstances[m.cursor]
+	m.previewLoading = true
+	m.previewErr = nil
+	m.previewInstanceId = inst.ID
+	var ctx context.Context
+	ctx, m.cancelPreview = context.WithCancel(m.lookupContext())
+	details := previewDetailsCmd(ctx, m.selectedProfile, m.instanceRegion(inst), inst.ID)
+	if matrix != nil {
+		return m, tea.Batch(matrix, details)
+	}
+	return m, details
+}
+
+// cancelPreviewLoad stops the in-flight preview lookup, if any.
+func (m model) cancelPreviewLoad() model {
+	if m.cancelPreview != nil {
+		m.cancelPreview()
+		m.cancelPreview = nil
+	}
+	return m
+}
+
+// startsProfileGroup reports whether a group title is rendered above profile i
+// in a window starting at start.
+func (m model) startsProfileGroup(i, start int) bool {
+	return m.groupProfiles && (i == start || m.profileAlias(m.filteredProfiles[i-1]) != m.profileAlias(m.filteredProfiles[i]))
+}
+
+func (m model) profileAlias(profile string) string {
+	if alias, ok := m.accountAliases[profile]; ok {
+		return alias
+	}
+	return unknownAccountAlias
+}
+
+// listCount renders how many items the filter matched out of the full list,
+// e.g. " (12/340)", for the list headers.
+func listCount(filtered, total int) string {
+	return fmt.Sprintf(" (%d/%d)", filtered, total)
+}
+
+// windowBounds returns the [start, end) slice of a list of total items that
+// keeps cursor roughly centred in a window of size rows.
+func windowBounds(cursor, total, size int) (int, int) {
+	start := cursor - size/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + size
+	if end > total {
+		end = total
+		start = end - size
+		if start < 0 {
+			start = 0
+		}
+	}
+	return start, end
+}
+
+// Fuzzy scoring bonuses: adjacent matched characters and matches at the start
+// of a word (after "-", "_", ".", "/", "(" or a space) rank higher.
+const (
+	fuzzyConsecutiveBonus = 5
+	fuzzyWordStartBonus   = 3
+	// Added for a whole private IP or hostname, so the instance it names
+	// comes first
+	privateAddressBonus = 1000
+)
+
+// fuzzyScore reports whether the characters of pattern appear in candidate in
+// order (not necessarily contiguously), case-insensitively, and how well.
+// Higher scores are better matches.
+func fuzzyScore(candidate, pattern string) (int, bool) {
+	score, _, ok := fuzzyMatch(candidate, pattern)
+	return score, ok
+}
+
+// fuzzyMatch is fuzzyScore that also returns the rune positions in candidate
+// that matched, for highlighting.
+func fuzzyMatch(candidate, pattern string) (int, []int, bool) {
+	c := []rune(strings.ToLower(candidate))
+	p := []rune(strings.ToLower(pattern))
+	score := 0
+	prev := -2
+	ci := 0
+	positions := make([]int, 0, len(p))
+	for _, pr := range p {
+		found := false
+		for ; ci < len(c); ci++ {
+			if c[ci] != pr {
+				continue
+			}
+			score++
+			if ci == prev+1 {
+				score += fuzzyConsecutiveBonus
+			}
+			if ci == 0 || strings.ContainsRune("-_./( ", c[ci-1]) {
+				score += fuzzyWordStartBonus
+			}
+			positions = append(positions, ci)
+			prev = ci
+			ci++
+			found = true
+			break
+		}
+		if !found {
+			return 0, nil, false
+		}
+	}
+	return score, positions, true
+}
+
+// fuzzyFilter returns the items matching filter as a subsequence, best match
+// first. Equal scores keep their original order. An empty filter returns the
+// list unchanged.
+func fuzzyFilter(list []string, filter string) []string {
 	if filter == "" {
 		return list
 	}
-	f := strings.ToLower(filter)
-	out := []string{}
+	type scored struct {
+		item  string
+		score int
+	}
+	matches := []scored{}
 	for _, item := range list {
-		if strings.Contains(strings.ToLower(item), f) {
-			out = append(out, item)
+		if score, ok := fuzzyScore(item, filter); ok {
+			matches = append(matches, scored{item, score})
 		}
 	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.item
+	}
 	return out
 }
 
+// filterInstances matches instances against a tag-aware query.
+//
+// The query is split on whitespace and every term must match (AND):
+//   - "key:value" matches when the instance has a tag whose key starts with
+//     key and whose value contains value, e.g. "env:prod" matches
+//     Environment=production. "key:" alone matches any instance carrying such
+//     a tag.
+//   - A bare term fuzzy-matches the display string (ID, Name, state) or is a
+//     substring of any tag value.
+//
+// All comparisons are case-insensitive. Results are ranked by the summed fuzzy
+// score of the terms. An empty query returns the list as-is.
+func filterInstances(instances []Instance, query string) []Instance {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return instances
+	}
+	type scored struct {
+		item  Instance
+		score int
+	}
+	matches := []scored{}
+	for _, inst := range instances {
+		total := 0
+		matched := true
+		for _, term := range terms {
+			score, ok := matchInstanceTerm(inst, term)
+			if !ok {
+				matched = false
+				break
+			}
+			total += score
+		}
+		if matched {
+			matches = append(matches, scored{inst, total})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	out := make([]Instance, len(matches))
+	for i, m := range matches {
+		out[i] = m.item
+	}
+	return out
+}
+
+// matchInstanceTerm reports whether a single lower-cased query term matches,
+// and its score. Tag matches score as a full-length contiguous match. A term
+// that is the instance's private IP or DNS name, or a prefix of them (e.g. an
+// IP pasted from an alarm), outranks every other match.
+func matchInstanceTerm(inst Instance, term string) (int, bool) {
+	exact := len(term) * (1 + fuzzyConsecutiveBonus)
+	for _, addr := range []string{inst.PrivateIP, inst.PrivateDNS} {
+		addr = strings.ToLower(addr)
+		if addr == "" {
+			continue
+		}
+		if addr == term || strings.HasPrefix(addr, term+".") {
+			return exact + privateAddressBonus, true
+		}
+		if strings.HasPrefix(addr, term) {
+			return exact, true
+		}
+	}
+	display, tags := inst.Display(), inst.Tags
+	if key, value, ok := strings.Cut(term, ":"); ok && key != "" {
+		for _, tag := range tags {
+			if strings.HasPrefix(strings.ToLower(tag.Key), key) && strings.Contains(strings.ToLower(tag.Value), value) {
+				return exact, true
+			}
+		}
+		return 0, false
+	}
+	best, matched := fuzzyScore(display, term)
+	for _, tag := range tags {
+		if strings.Contains(strings.ToLower(tag.Value), term) && (!matched || exact > best) {
+			best, matched = exact, true
+		}
+	}
+	return best, matched
+}
+
 func (m model) View() string {
 	if m.err != nil {
-		return errorStyle.Render("Error: "+m.err.Error()) + "\n"
+		// Wrap long AWS CLI messages instead of letting them run off screen
+		msg := "Error: " + m.err.Error()
+		width := min(lipgloss.Width(msg), errorWrapWidth)
+		if m.width > 0 {
+			// Keep the box inside the terminal: border and padding take 4 columns
+			width = max(min(width, m.width-4), 1)
+		}
+		content := errorStyle.Copy().Width(width).Render(msg)
+		if isTimeout(m.err) {
+			content += "\n" + infoStyle.Render(timeoutHint)
+		}
+		return errorBoxStyle.Render(content) + "\n"
 	}
 	var content string
+	if m.showHelp {
+		return m.helpView()
+	}
+	if m.tagValuesOpen {
+		return m.tagValuesView()
+	}
 	if m.loading {
 		spinner := spinnerStyle.Render(spinnerFrames[m.spinnerFrame])
-		msg := infoStyle.Render("Loading...")
+		label := m.loadingMsg
+		if label == "" {
+			label = "Loading..."
+		}
+		if m.instancesLoaded > 0 {
+			label = fmt.Sprintf("Loaded %d instances from %s...", m.instancesLoaded, m.selectedRegion)
+		}
+		if m.regionsTotal > 0 {
+			label = fmt.Sprintf("Scanned %d of %d regions...", m.regionsDone, m.regionsTotal)
+		}
+		if retriesInFlight.Load() > 0 {
+			label += " (throttled by AWS, retrying...)"
+		}
+		msg := infoStyle.Render(fit(label, m.contentWidth(), 4))
+		if m.regionsTotal > 0 {
+			width := progressBarWidth
+			if w := m.contentWidth(); w > 0 {
+				width = min(width, w)
+			}
+			msg += "\n" + progressBar(m.regionsDone, m.regionsTotal, width)
+		}
+		if m.canAbandonLoading() {
+			msg += "\n" + quitStyle.Render(fit("Still loading... press c to cancel, r to retry", m.contentWidth(), 0))
+		}
 		return borderStyle.Render(fmt.Sprintf("%s %s", spinner, msg))
 	}
 	switch m.step {
 	case stateProfile:
-		content += headerStyle.Render("Select AWS profile") + "\n"
-		content += infoStyle.Render("Search:"+m.filter) + "\n"
-		windowSize := 20
-		start := m.cursor - windowSize/2
-		if start < 0 {
-			start = 0
-		}
-		end := start + windowSize
-		if end > len(m.filteredProfiles) {
-			end = len(m.filteredProfiles)
-			start = end - windowSize
-			if start < 0 {
-				start = 0
-			}
-		}
+		w := m.contentWidth()
+		content += headerStyle.Render(fit("Select AWS profile"+listCount(len(m.filteredProfiles), len(m.profiles)), w, 2)) + "\n"
+		content += infoStyle.Render(fit("Search:"+m.filter, w, 2)) + "\n"
+		start, end := windowBounds(m.cursor, len(m.filteredProfiles), m.windowSize())
 		for i := start; i < end; i++ {
-			p := m.filteredProfiles[i]
+			p := fit(m.filteredProfiles[i], w, 4)
+			if m.startsProfileGroup(i, start) {
+				content += groupStyle.Render(fit(m.profileAlias(m.filteredProfiles[i]), w, 2)) + "\n"
+			}
+			_, matched, _ := fuzzyMatch(p, m.filter)
 			var line string
 			if m.cursor == i {
-				line = selectedStyle.Render("> " + p)
+				line = renderHighlighted("> ", p, matched, selectedStyle)
 			} else {
-				line = itemStyle.Render("  " + p)
+				line = renderHighlighted("  ", p, matched, itemStyle)
 			}
 			content += line + "\n"
 		}
-		content += quitStyle.Render("esc: quit")
+		if len(m.filteredProfiles) == 0 {
+			content += mutedStyle.Render(fit(m.emptyListMessage(), w, 2)) + "\n"
+		}
+		footer := "tab: group by account • ?: help • esc: quit"
+		if len(m.config.Favorites) > 0 {
+			footer = "←: favorites • " + footer
+		}
+		content += quitStyle.Render(fit(footer, w, 0))
 		return borderStyle.Render(content)
 	case stateRegion:
-		content += headerStyle.Render("Select AWS region") + "\n"
-		content += infoStyle.Render("Profile:"+m.selectedProfile) + "\n"
-		content += infoStyle.Render("Search:"+m.filter) + "\n"
-		windowSize := 20
-		start := m.cursor - windowSize/2
-		if start < 0 {
-			start = 0
-		}
-		end := start + windowSize
-		if end > len(m.filteredRegions) {
-			end = len(m.filteredRegions)
-			start = end - windowSize
-			if start < 0 {
-				start = 0
-			}
+		w := m.contentWidth()
+		content += headerStyle.Render(fit("Select AWS region"+listCount(len(m.filteredRegions), len(m.regions)), w, 2)) + "\n"
+		content += infoStyle.Render(fit("Profile:"+m.selectedProfile, w, 2)) + "\n"
+		if m.staticRegions {
+			content += mutedStyle.Render(fit("Built-in region list: this profile may not call describe-regions", w, 2)) + "\n"
 		}
+		content += infoStyle.Render(fit("Search:"+m.filter, w, 2)) + "\n"
+		start, end := windowBounds(m.cursor, len(m.filteredRegions), m.windowSize())
 		for i := start; i < end; i++ {
-			r := m.filteredRegions[i]
+			count := m.regionCountLabel(m.filteredRegions[i])
+			if m.startsRegionGroup(i, start) {
+				content += groupStyle.Render(fit(m.regionGroupTitle(m.filteredRegions[i]), w, 2)) + "\n"
+			}
+			r := fit(m.filteredRegions[i], w, 4+len(count))
+			_, matched, _ := fuzzyMatch(r, m.filter)
+			r += count
 			var line string
 			if m.cursor == i {
-				line = selectedStyle.Render("> " + r)
+				line = renderHighlighted("> ", r, matched, selectedStyle)
 			} else {
-				line = itemStyle.Render("  " + r)
+				line = renderHighlighted("  ", r, matched, itemStyle)
 			}
 			content += line + "\n"
 		}
-		content += quitStyle.Render("esc: quit")
+		if len(m.filteredRegions) == 0 {
+			content += mutedStyle.Render(fit(m.emptyListMessage(), w, 2)) + "\n"
+		}
+		content += quitStyle.Render(fit("←/h: back • tab: pin • alt+r: refresh • ?: help • esc: quit", w, 0))
 		return borderStyle.Render(content)
 	case stateInstance:
+		lw, rw := m.paneWidths()
 		// Left: instance list
-		left := headerStyle.Render("Select EC2 instance") + "\n"
-		left += infoStyle.Render("Profile:"+m.selectedProfile+" | Region:"+m.selectedRegion) + "\n"
-		left += infoStyle.Render("Search:"+m.filter) + "\n"
-		windowSize := 20
-		start := m.cursor - windowSize/2
-		if start < 0 {
-			start = 0
+		left := headerStyle.Render(fit("Select EC2 instance"+listCount(len(m.filteredInstances), len(m.instances))+" • by "+m.instanceSort.String(), lw, 2)) + "\n"
+		region := m.selectedRegion
+		if m.allRegions {
+			region = "all"
 		}
-		end := start + windowSize
-		if end > len(m.filteredInstances) {
-			end = len(m.filteredInstances)
-			start = end - windowSize
-			if start < 0 {
-				start = 0
-			}
+		left += infoStyle.Render(fit("Profile:"+m.selectedProfile+" | Region:"+region, lw, 2)) + "\n"
+		if len(m.regionErrs) > 0 {
+			left += errorStyle.Render(fit(fmt.Sprintf("Skipped %d region(s) that failed: %s", len(m.regionErrs), strings.Join(m.regionErrs.regions(), ", ")), lw, 0)) + "\n"
 		}
+		left += infoStyle.Render(fit("Search:"+m.filter, lw, 2)) + "\n"
+		start, end := windowBounds(m.cursor, len(m.filteredInstances), m.windowSize())
+		// Columns line up across the visible rows, leaving room for the
+		// cursor, padding, and the mark and favorite columns
+		pad := 4 + len([]rune(m.markColumn(Instance{}))) + len([]rune(" ★"))
+		widths := columnWidths(m.filteredInstances[start:end], max(lw-pad, 0))
 		for i := start; i < end; i++ {
 			inst := m.filteredInstances[i]
+			mark := m.markColumn(inst)
+			star := ""
+			if m.isFavorite(inst) {
+				star = " ★"
+			}
+			row := instanceRow(inst, widths)
+			display := mark + row + star
+			matched := offsetPositions(instanceMatchPositions(row, m.filter), len([]rune(mark)))
 			var line string
 			if m.cursor == i {
-				line = selectedStyle.Render("> " + inst)
+				line = renderHighlighted("> ", display, matched, selectedStyle)
+			} else if !inst.Connectable() {
+				line = renderHighlighted("  ", display, matched, mutedStyle)
 			} else {
-				line = itemStyle.Render("  " + inst)
+				line = renderHighlighted("  ", display, matched, m.instanceRowStyle(inst))
 			}
 			left += line + "\n"
 		}
-		left += quitStyle.Render("esc: quit")
-		left = borderStyle.Render(left)
+		if len(m.filteredInstances) == 0 {
+			left += mutedStyle.Render(fit(m.emptyListMessage(), lw, 2)) + "\n"
+		}
+		if m.inlineErr != "" {
+			left += errorStyle.Render(fit(m.inlineErr, lw, 0)) + "\n"
+		}
+		if m.toast != "" {
+			left += infoStyle.Render(fit(m.toast, lw, 2)) + "\n"
+		}
+		left += quitStyle.Render(fit("←/h: back • tab: mark • alt+f: favorite • alt+y/Y: copy id/command • alt+r: refresh • alt+t: preview • alt+m: tag matrix • alt+o: sort • alt+p: port forward • alt+s: ssh config • ?: help • esc: quit", lw, 0))
+		if lw > 0 {
+			// A fixed width keeps the preview pane in place, so clicks can be
+			// told apart from those on the list
+			left = borderStyle.Copy().Width(lw + borderFrameWidth - 2).Render(left)
+		} else {
+			left = borderStyle.Render(left)
+		}
 		// Right: preview window
-		var right string
-		if m.previewLoading {
-			right = spinnerStyle.Render(spinnerFrames[m.spinnerFrame]) + " " + infoStyle.Render("Loading tags...")
-		} else if len(m.previewTags) > 0 {
-			right = headerStyle.Render("Instance Tags") + "\n"
-			for _, tag := range m.previewTags {
-				right += infoStyle.Render(fmt.Sprintf("%s: %s", tag.Key, tag.Value)) + "\n"
+		var right []string
+		if m.showTagMatrix {
+			right = append(right, headerStyle.Render(fit("Tag Matrix", rw, 2)))
+			if m.tagMatrixLoading {
+				right = append(right, spinnerStyle.Render(spinnerFrames[m.spinnerFrame])+" "+infoStyle.Render("Loading tags..."))
+			} else {
+				for _, line := range renderTagMatrix(m.visibleInstanceIds(), tagColumns(), m.tagMatrix) {
+					right = append(right, infoStyle.Render(fit(line, rw, 2)))
+				}
 			}
+		} else if m.previewLoading {
+			right = append(right, spinnerStyle.Render(spinnerFrames[m.spinnerFrame])+" "+infoStyle.Render("Loading details..."))
+		} else if m.previewErr != nil {
+			right = append(right, errorStyle.Render(fit("Could not load details.", rw, 2)))
+		} else if m.previewDetails == nil {
+			// Nothing requested yet, e.g. an empty list
+			right = append(right, mutedStyle.Render(fit("No instance details loaded.", rw, 2)))
+		} else if m.config.PreviewTemplate != "" {
+			right = append(right, m.templatePreviewLines(rw)...)
 		} else {
-			right = infoStyle.Render("No tags found.")
+			if len(m.previewDetails.Lines()) > 0 {
+				right = append(right, headerStyle.Render(fit("Instance Details", rw, 2)))
+				for _, line := range m.previewDetails.Lines() {
+					right = append(right, infoStyle.Render(fit(line, rw, 2)))
+				}
+			}
+			if len(m.previewDetails.Tags) > 0 {
+				right = append(right, headerStyle.Render(fit("Instance Tags", rw, 2)))
+				// Long values such as ARNs wrap instead of widening the pane
+				for _, tag := range m.previewDetails.Tags {
+					for _, line := range tagLines(tag, previewTextWidth(rw)) {
+						right = append(right, infoStyle.Render(line))
+					}
+				}
+			} else {
+				// Loaded, and the instance really has no tags
+				right = append(right, infoStyle.Render(fit("No tags found.", rw, 2)))
+			}
+		}
+		// Don't let a long tag list run past the bottom of the terminal
+		if rows := m.height - borderFrameHeight; m.height > 0 && len(right) > rows {
+			right = right[:max(rows, 1)]
+		}
+		if !m.showRightPane() {
+			return left
 		}
-		right = borderStyle.Render(right)
 		// Layout: side by side
-		return lipgloss.JoinHorizontal(lipgloss.Top, left, right)
+		return lipgloss.JoinHorizontal(lipgloss.Top, left, borderStyle.Render(strings.Join(right, "\n")))
+	case statePortForward:
+		return m.portForwardView()
+	case stateReauth:
+		return m.reauthView()
+	case stateConfirm:
+		return m.confirmView()
+	case stateFavorites:
+		return m.favoritesView()
+	case stateRecent:
+		return m.recentView()
+	case stateAccount:
+		return m.accountView()
+	case stateMethod:
+		return m.methodView()
 	case stateDone:
-		content += headerStyle.Render("Session Starting") + "\n"
-		content += infoStyle.Render(fmt.Sprintf("Selected: Profile=%s, Region=%s, Instance=%s", m.selectedProfile, m.selectedRegion, m.selectedInstance)) + "\n"
-		content += infoStyle.Render("Starting SSM session...") + "\n"
+		if quiet {
+			return ""
+		}
+		w := m.contentWidth()
+		content += headerStyle.Render(fit("Session Starting", w, 2)) + "\n"
+		if m.sessionMode == sessionMulti {
+			if remoteCommand != "" {
+				content += infoStyle.Render(fit(fmt.Sprintf("Selected %d instances; running %s...", len(m.targets), remoteCommand), w, 2)) + "\n"
+			} else {
+				content += infoStyle.Render(fit(fmt.Sprintf("Selected %d instances; printing their session commands...", len(m.targets)), w, 2)) + "\n"
+			}
+			return borderStyle.Render(content)
+		}
+		content += infoStyle.Render(fit(fmt.Sprintf("Selected: Profile=%s, Region=%s, Instance=%s", m.selectedProfile, m.selectedRegion, m.selectedInstance), w, 2)) + "\n"
+		if m.sessionMode == sessionPortForward {
+			content += infoStyle.Render(fmt.Sprintf("Forwarding localhost:%s to port %s...", m.localPort, m.remotePort)) + "\n"
+		} else if m.sessionMode == sessionSSHProxy {
+			content += infoStyle.Render("Generating SSH configuration...") + "\n"
+		} else if m.sessionMode == sessionEICE {
+			content += infoStyle.Render("Connecting through EC2 Instance Connect Endpoint...") + "\n"
+		} else if remoteCommand != "" {
+			content += infoStyle.Render(fit("Running "+remoteCommand+"...", w, 2)) + "\n"
+		} else {
+			content += infoStyle.Render("Starting SSM session...") + "\n"
+		}
 		return borderStyle.Render(content)
 	}
 	return ""
 }
 
 func main() {
-	p := tea.NewProgram(initialModel())
+	// Environment provides the timeout defaults; flags parsed below override them
+	applyTimeoutEnv()
+	demo := flag.Bool("demo", false, "run against built-in sample data without calling AWS")
+	flag.DurationVar(&loadTimeout, "timeout", loadTimeout, "time allowed for each region/instance lookup (overrides SSMSSH_TIMEOUT)")
+	flag.DurationVar(&discoveryDeadline, "deadline", 0, "give up with an error if no target is chosen within this time, e.g. 30s, stopping any AWS calls still running")
+	flag.DurationVar(&previewTimeout, "preview-timeout", previewTimeout, "time allowed for each tag preview lookup (overrides SSMSSH_PREVIEW_TIMEOUT)")
+	flag.BoolVar(&noColor, "no-color", false, "disable colors (also set by the NO_COLOR environment variable)")
+	flag.StringVar(&spinnerName, "spinner", "", "loading spinner style: braille, dots, or line (default braille on UTF-8 terminals, line otherwise)")
+	flag.BoolVar(&noRemember, "no-remember", false, "don't remember the last used profile and region")
+	flag.Var(filterFlag{}, "filter", "only list instances matching this describe-instances filter, e.g. tag:Environment=production (repeatable)")
+	flag.Var(idFilterFlag{"instance.group-id", "sg"}, "sg", "only list instances in this security group, e.g. sg-0123456789abcdef0 (comma-separated IDs match any)")
+	flag.Var(idFilterFlag{"subnet-id", "subnet"}, "subnet", "only list instances in this subnet, e.g. subnet-0123456789abcdef0 (comma-separated IDs match any)")
+	flag.BoolVar(&includeTerminated, "include-terminated", false, "also list terminated and shutting-down instances")
+	flag.BoolVar(&showVersion, "version", false, "print the version, commit and Go version and exit")
+	flag.BoolVar(&useProfileRegion, "profile-region", false, "skip the region step for profiles with a region in ~/.aws/config")
+	flag.BoolVar(&regionCounts, "region-counts", false, "count the instances in every region in the background and show them in the region list")
+	flag.StringVar(&partition, "partition", "", "AWS partition to list regions from: aws, aws-us-gov, or aws-cn (default: that of the profile's region)")
+	flag.BoolVar(&allRegions, "all-regions", false, "list instances from every region at once instead of picking a region")
+	flag.StringVar(&roleArn, "role-arn", "", "assume this IAM role from the selected profile for every AWS call")
+	flag.StringVar(&roleExternalId, "external-id", "", "external ID to pass when assuming -role-arn")
+	flag.StringVar(&roleSessionName, "role-session-name", roleSessionName, "session name to use when assuming -role-arn")
+	flag.StringVar(&orgRole, "org-role", "", "pick an account of the profile's AWS Organization and assume this role in it, e.g. OrganizationAccountAccessRole")
+	flag.StringVar(&sessionDocument, "document-name", "", "SSM session document to start instead of the default shell, e.g. a custom Session document")
+	flag.StringVar(&sessionParameters, "parameters", "", "parameters for -document-name, as JSON or AWS CLI shorthand")
+	flag.IntVar(&idleTimeout, "idle-timeout", 0, "minutes of inactivity before SSM ends the session (1-60), passed to -document-name as idleSessionTimeout")
+	flag.DurationVar(&keepaliveInterval, "keepalive", 0, "open a connection through port forwarding sessions this often, e.g. 5m, so they aren't ended as idle")
+	flag.BoolVar(&startOnRecent, "recent", false, "start on the recently used instances (ctrl+r opens them from any list)")
+	flag.StringVar(&methodsFlag, "methods", "", "connection methods a shell session tries in order while they can't reach the instance, e.g. ssm-session,ec2-instance-connect")
+	flag.BoolVar(&quiet, "quiet", false, "don't print the command being run or the session starting screen")
+	flag.BoolVar(&sessionSummary, "summary", false, "after a session ends, print the command that ran and how long it lasted")
+	flag.BoolVar(&reconnect, "reconnect", false, "start a shell or port forwarding session again when it ends abnormally, e.g. after a network drop")
+	flag.IntVar(&reconnectAttempts, "reconnect-attempts", reconnectAttempts, "how many times -reconnect starts a dropped session again")
+	flag.BoolVar(&useTmux, "tmux", false, "open the session in a new tmux window named profile/region/name instead of in this terminal")
+	flag.BoolVar(&precheckAgent, "precheck", false, "check that the SSM agent is online before starting a session")
+	flag.BoolVar(&confirmSession, "confirm", false, "show the selection and ask for confirmation before starting a session")
+	flag.StringVar(&eiceUser, "eice-user", eiceUser, "login for SSH through an EC2 Instance Connect Endpoint")
+	flag.StringVar(&remoteCommand, "cmd", "", "run this shell command on the selected instance(s) with SSM send-command and print its output instead of opening a session")
+	flag.BoolVar(&evalOutput, "eval", false, "print export statements for the selected profile, region and instance, for eval \"$(ssmssh -eval)\", instead of connecting")
+	flag.BoolVar(&describeOutput, "describe", false, "print the describe-instances JSON of the selected instance(s) instead of connecting")
+	flag.StringVar(&describeTarget, "target", "", "instance ID to print with -describe, together with -profile and -region, without the TUI")
+	flag.StringVar(&sshUser, "user", "", "OS user for SSH over SSM, e.g. ubuntu; overrides ssh_users in the config")
+	flag.BoolVar(&sshConfigOutput, "ssh-config", false, "print a ~/.ssh/config Host block for the selected instance instead of connecting")
+	flag.BoolVar(&listMode, "list", false, "print the instances of -profile and -region and exit without the TUI")
+	flag.StringVar(&listProfile, "profile", "", "AWS profile to list with -list or -describe -target")
+	flag.StringVar(&listRegion, "region", "", "AWS region to list with -list or -describe -target")
+	flag.StringVar(&listOutput, "output", listOutput, "-list output format: json or text")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [connection]\n       %s [flags] %s -profile X -region Y -name N\n", os.Args[0], os.Args[0], resolveCommand)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	subcommand := flag.Arg(0) == resolveCommand
+	if flag.NArg() > 1 && !subcommand {
+		flag.Usage()
+		os.Exit(2)
+	}
+	connectionName = flag.Arg(0)
+	if showVersion {
+		fmt.Println(versionInfo())
+		return
+	}
+	if err := validateSessionDocument(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	if err := validateTmux(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	if _, err := spinnerFramesFor(spinnerName); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	if err := validatePartition(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	if err := validateSSHUser(sshUser); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	if _, err := parseMethods(methodsFlag); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	if describeTarget != "" && !describeOutput {
+		fmt.Fprintln(os.Stderr, "Error: -target is only used with -describe")
+		os.Exit(2)
+	}
+	if orgRole != "" && roleArn != "" {
+		fmt.Fprintln(os.Stderr, "Error: -org-role and -role-arn can't be combined")
+		os.Exit(2)
+	}
+	if sshConfigOutput && (orgRole != "" || roleArn != "") {
+		fmt.Fprintln(os.Stderr, "Error: -ssh-config can't be combined with -role-arn or -org-role; the Host block would connect as the profile")
+		os.Exit(2)
+	}
+	applyColorPreference()
+	if *demo {
+		enableDemoMode()
+	}
+	stopDeadline := startDeadline(discoveryDeadline)
+	if subcommand {
+		if !demoMode {
+			if err := preflight(false); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(runResolve(flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+	if listMode {
+		if !demoMode {
+			if err := preflight(false); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+		if err := listInstances(os.Stdout, listProfile, listRegion, listOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", deadlineErr(err))
+			os.Exit(1)
+		}
+		return
+	}
+	if describeTarget != "" {
+		if !demoMode {
+			if err := preflight(false); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+		if err := describeTargetFlag(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", deadlineErr(err))
+			os.Exit(1)
+		}
+		return
+	}
+	// The alternate screen keeps the view at a fixed origin so mouse rows map to list items
+	initial := initialModel()
+	spinner := spinnerName
+	if spinner == "" {
+		spinner = initial.config.Spinner
+	}
+	// An unknown configured style is reported by loadConfig
+	if frames, err := spinnerFramesFor(spinner); err == nil {
+		spinnerFrames = frames
+	}
+	quiet = quiet || initial.config.Quiet
+	configTagColumns = initial.config.TagColumns
+	if connectionName != "" && initial.err == nil {
+		if _, ok := initial.config.Connections[connectionName]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown connection %q; define it under connections in %s\n", connectionName, configPath())
+			os.Exit(2)
+		}
+		initial = initial.launchConnection(connectionName)
+	}
+	if startOnRecent && initial.connection == "" && initial.err == nil {
+		initial, _ = initial.showRecent()
+	}
+	if !demoMode {
+		// Missing tools are reported in the TUI before any AWS call is made
+		if err := preflight(true); err != nil {
+			initial.err = err
+		}
+	}
+	options := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if evalOutput || describeOutput {
+		// Stdout is captured by the shell's $(...) or a pipe; draw on the
+		// terminal instead
+		options = append(options, tea.WithOutput(os.Stderr))
+	}
+	p := tea.NewProgram(initial, options...)
+	if discoveryDeadline > 0 {
+		go waitDeadline(p.Send)
+	}
 	m, err := p.Run()
 	if err != nil {
 		fmt.Println("Error running Bubble Tea program:", err)
 		os.Exit(1)
 	}
+	// The deadline only bounds discovery, not the session
+	stopDeadline()
 	final := m.(model)
-	if final.err != nil || final.step != stateDone {
+	// Stop lookups still running, such as the preview of the last highlighted row
+	if final.cancelLookups != nil {
+		final.cancelLookups()
+	}
+	_ = saveFilterHistory(final.filterHistory)
+	// Text that couldn't go to the clipboard
+	for _, text := range final.pendingOutput {
+		if evalOutput || describeOutput {
+			fmt.Fprintln(os.Stderr, text)
+		} else {
+			fmt.Println(text)
+		}
+	}
+	if final.err != nil {
+		// The alternate screen is gone once the program exits; show the error again
+		fmt.Fprintln(os.Stderr, final.View())
+		os.Exit(1)
+	}
+	if final.step != stateDone {
 		os.Exit(1)
 	}
+	// Remembering the selection is a convenience; never block the session on it
+	_ = saveLastSelection(lastSelection{Profile: final.selectedProfile, Region: final.selectedRegion})
+	if evalOutput {
+		var creds roleCredentials
+		if arn := currentRoleArn(); arn != "" {
+			if creds, err = assumeRole(context.Background(), arn, final.selectedProfile); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Print(evalExports(final.selectedProfile, final.selectedRegion, final.sessionTargets(), creds))
+		return
+	}
+	if describeOutput {
+		if err := describeInstances(context.Background(), os.Stdout, final.selectedProfile, final.sessionTargets()); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if !demoMode && !sshConfigOutput && final.sessionMode != sessionSSHProxy {
+		_ = saveRecent(rememberRecent(loadRecent(), final.selectedProfile, final.sessionTargets()))
+	}
+	if remoteCommand != "" && (final.sessionMode == sessionShell || final.sessionMode == sessionMulti) {
+		auditSessions(os.Stderr, final.config, final.selectedProfile, "command", final.sessionTargets())
+		os.Exit(runRemoteCommands(os.Stdout, os.Stderr, final.selectedProfile, final.sessionTargets(), remoteCommand))
+	}
+	if final.sessionMode == sessionMulti && useTmux && !sshConfigOutput {
+		// A window per instance instead of the commands to start them
+		auditSessions(os.Stderr, final.config, final.selectedProfile, "shell", final.sessionTargets())
+		for _, target := range final.targets {
+			tmuxWindow = tmuxWindowName(final.selectedProfile, target.Region, target)
+			if err := startSession(final.selectedProfile, target.Region, target.ID); err != nil {
+				fmt.Println("Error starting SSM session:", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+	if final.sessionMode == sessionMulti {
+		if sshConfigOutput {
+			fmt.Print(multiSSHConfig(final.selectedProfile, sshUserFor(final.config, final.selectedProfile), final.targets))
+		} else {
+			fmt.Print(multiSessionCommands(final.selectedProfile, final.targets))
+		}
+		return
+	}
+	if final.sessionMode == sessionSSHProxy {
+		target := Instance{ID: final.selectedInstance, Name: final.selectedName}
+		user := sshUserFor(final.config, final.selectedProfile)
+		if sshConfigOutput {
+			fmt.Print(sshConfigBlock(final.selectedProfile, final.selectedRegion, user, target))
+		} else if user != "" {
+			fmt.Println(sshCommand(final.selectedProfile, final.selectedRegion, user, target.ID))
+		} else {
+			fmt.Println(sshProxyCommand(final.selectedProfile, final.selectedRegion, target.ID))
+		}
+		return
+	}
 	// Start SSM session
-	err = startSession(final.selectedProfile, final.selectedRegion, final.selectedInstance)
+	tmuxWindow = tmuxWindowName(final.selectedProfile, final.selectedRegion, Instance{ID: final.selectedInstance, Name: final.selectedName})
+	if final.sessionMode == sessionPortForward {
+		auditSessions(os.Stderr, final.config, final.selectedProfile, "port-forward", final.sessionTargets())
+		local, _ := strconv.Atoi(final.localPort)
+		remote, _ := strconv.Atoi(final.remotePort)
+		err = withReconnect(os.Stderr, func() error {
+			return startPortForwardSession(final.selectedProfile, final.selectedRegion, final.selectedInstance, local, remote)
+		})
+	} else if final.sessionMode == sessionEICE {
+		auditSessions(os.Stderr, final.config, final.selectedProfile, "eice", final.sessionTargets())
+		err = startEICESession(final.selectedProfile, final.selectedRegion, final.selectedInstance)
+	} else {
+		auditSessions(os.Stderr, final.config, final.selectedProfile, "shell", final.sessionTargets())
+		err = withReconnect(os.Stderr, func() error {
+			if methods := connectionChain(final.config); len(methods) > 0 {
+				user := sshUserFor(final.config, final.selectedProfile)
+				return connectWithFallback(os.Stderr, methods, final.selectedProfile, final.selectedRegion, final.selectedInstance, user)
+			}
+			return startSession(final.selectedProfile, final.selectedRegion, final.selectedInstance)
+		})
+	}
 	if err != nil {
 		fmt.Println("Error starting SSM session:", err)
 		os.Exit(1)
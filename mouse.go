@@ -0,0 +1,118 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listLines maps each rendered line of the current list view to the index of
+// the list item drawn on it, or -1 for borders, headers, and group titles.
+// It mirrors the layout in View.
+func (m model) listLines() []int {
+	// borderStyle's top border and padding
+	lines := []int{-1, -1}
+	switch m.step {
+	case stateProfile:
+		// Header and search line
+		lines = append(lines, -1, -1)
+		start, end := windowBounds(m.cursor, len(m.filteredProfiles), m.windowSize())
+		for i := start; i < end; i++ {
+			if m.startsProfileGroup(i, start) {
+				lines = append(lines, -1)
+			}
+			lines = append(lines, i)
+		}
+	case stateRegion:
+		// Header, profile, and search line
+		lines = append(lines, -1, -1, -1)
+		if m.staticRegions {
+			lines = append(lines, -1)
+		}
+		start, end := windowBounds(m.cursor, len(m.filteredRegions), m.windowSize())
+		for i := start; i < end; i++ {
+			if m.startsRegionGroup(i, start) {
+				lines = append(lines, -1)
+			}
+			lines = append(lines, i)
+		}
+	case stateAccount:
+		// Header, profile, and search line
+		lines = append(lines, -1, -1, -1)
+		start, end := windowBounds(m.cursor, len(m.filteredAccounts), m.windowSize())
+		for i := start; i < end; i++ {
+			lines = append(lines, i)
+		}
+	case stateInstance:
+		// Header, profile/region, and search line
+		lines = append(lines, -1, -1, -1)
+		if len(m.regionErrs) > 0 {
+			lines = append(lines, -1)
+		}
+		start, end := windowBounds(m.cursor, len(m.filteredInstances), m.windowSize())
+		for i := start; i < end; i++ {
+			lines = append(lines, i)
+		}
+	case stateFavorites:
+		// Header line
+		lines = append(lines, -1)
+		start, end := windowBounds(m.cursor, len(m.config.Favorites), m.windowSize())
+		for i := start; i < end; i++ {
+			lines = append(lines, i)
+		}
+	case stateRecent:
+		// Header line
+		lines = append(lines, -1)
+		start, end := windowBounds(m.cursor, len(m.recent), m.windowSize())
+		for i := start; i < end; i++ {
+			lines = append(lines, i)
+		}
+	default:
+		return nil
+	}
+	return lines
+}
+
+// listRowAt returns the index of the list item rendered at terminal column x
+// on row y. On the instance screen only the list pane counts, not the preview
+// pane beside it.
+func (m model) listRowAt(x, y int) (int, bool) {
+	if lw, _ := m.paneWidths(); m.step == stateInstance && lw > 0 && x >= lw+borderFrameWidth {
+		return 0, false
+	}
+	lines := m.listLines()
+	if y < 0 || y >= len(lines) || lines[y] < 0 {
+		return 0, false
+	}
+	return lines[y], true
+}
+
+// updateMouse handles mouse input on the list steps: the wheel moves the
+// cursor like the arrow keys, a click highlights a row, and a click on the
+// highlighted row selects it like enter.
+func (m model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.loading || m.listLines() == nil {
+		return m, nil
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case tea.MouseButtonWheelDown:
+		return m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+		row, ok := m.listRowAt(msg.X, msg.Y)
+		if !ok {
+			return m, nil
+		}
+		if row == m.cursor {
+			return m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		}
+		m.cursor = row
+		m.inlineErr = ""
+		if m.step == stateInstance {
+			return m.preview()
+		}
+	}
+	return m, nil
+}
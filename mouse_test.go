@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for building a left click on terminal row y
+func clickAt(y int) tea.MouseMsg {
+	return tea.MouseMsg{Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+}
+
+// Test that list rows are mapped to the lines View renders them on
+func TestListRowAt(t *testing.T) {
+	m := model{
+		step:             stateProfile,
+		profiles:         []string{"dev", "prod", "staging"},
+		filteredProfiles: []string{"dev", "staging", "prod"},
+		groupProfiles:    true,
+		accountAliases:   map[string]string{"dev": "nonprod", "staging": "nonprod", "prod": "prod"},
+	}
+	lines := strings.Split(m.View(), "\n")
+	for _, profile := range m.filteredProfiles {
+		y := -1
+		for i, line := range lines {
+			if strings.Contains(line, " "+profile+" ") {
+				y = i
+			}
+		}
+		require.NotEqual(t, -1, y, profile)
+		row, ok := m.listRowAt(0, y)
+		assert.True(t, ok, profile)
+		assert.Equal(t, profile, m.filteredProfiles[row])
+	}
+	// Group titles and headers aren't rows
+	_, ok := m.listRowAt(0, 4)
+	assert.False(t, ok)
+	_, ok = m.listRowAt(0, 0)
+	assert.False(t, ok)
+}
+
+// Test mouse clicks and wheel scrolling
+func TestMouseInput(t *testing.T) {
+	instances := []Instance{{ID: "i-1", State: "running"}, {ID: "i-2", State: "running"}, {ID: "i-3", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+	}
+
+	// Instance rows start below the border, padding, and three header lines
+	updatedModel, cmd := m.Update(clickAt(7))
+	result := updatedModel.(model)
+	assert.Equal(t, 2, result.cursor)
+	assert.Equal(t, "i-3", result.previewInstanceId)
+	assert.NotNil(t, cmd)
+
+	updatedModel, _ = result.Update(tea.MouseMsg{Button: tea.MouseButtonWheelUp, Action: tea.MouseActionPress})
+	result = updatedModel.(model)
+	assert.Equal(t, 1, result.cursor)
+
+	updatedModel, _ = result.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	result = updatedModel.(model)
+	assert.Equal(t, 2, result.cursor)
+
+	// Clicking outside the list does nothing
+	updatedModel, _ = result.Update(clickAt(2))
+	assert.Equal(t, 2, updatedModel.(model).cursor)
+
+	// Clicking the highlighted row selects it
+	updatedModel, cmd = result.Update(clickAt(7))
+	result = updatedModel.(model)
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, "i-3", result.selectedInstance)
+	assert.NotNil(t, cmd)
+}
+
+// Test that clicks in the preview pane don't select list rows
+func TestClickPreviewPane(t *testing.T) {
+	instances := []Instance{{ID: "i-1", State: "running"}, {ID: "i-2", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		previewDetails:    &InstanceDetails{Tags: []Tag{{Key: "Team", Value: "web"}}},
+		width:             100,
+		height:            30,
+	}
+	lw, _ := m.paneWidths()
+	lines := strings.Split(m.View(), "\n")
+	// The preview pane's border starts right after the list pane
+	assert.Equal(t, "╭", string([]rune(lines[0])[lw+borderFrameWidth]))
+
+	row, ok := m.listRowAt(lw+borderFrameWidth-1, 6)
+	assert.True(t, ok)
+	assert.Equal(t, 1, row)
+	_, ok = m.listRowAt(lw+borderFrameWidth, 6)
+	assert.False(t, ok)
+
+	updatedModel, _ := m.Update(tea.MouseMsg{X: lw + borderFrameWidth + 2, Y: 6, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	assert.Equal(t, 0, updatedModel.(model).cursor)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toggleMark marks or unmarks the highlighted instance for a multi-instance
+// selection. Only connectable instances can be marked.
+func (m model) toggleMark() (tea.Model, tea.Cmd) {
+	if len(m.filteredInstances) == 0 {
+		return m, nil
+	}
+	inst := m.filteredInstances[m.cursor]
+	if m.selectedInstances[inst.ID] {
+		delete(m.selectedInstances, inst.ID)
+		return m, nil
+	}
+	if !inst.Connectable() {
+		m.inlineErr = fmt.Sprintf("Instance is %s; only running instances can be connected to", inst.State)
+		return m, nil
+	}
+	// Copy so earlier models keep their own set
+	marked := map[string]bool{inst.ID: true}
+	for id := range m.selectedInstances {
+		marked[id] = true
+	}
+	m.selectedInstances = marked
+	return m, nil
+}
+
+// selectMarked makes the marked instances the session targets, in list order
+// and each carrying its region.
+func (m model) selectMarked() (tea.Model, tea.Cmd) {
+	m.targets = nil
+	for _, inst := range m.instances {
+		if m.selectedInstances[inst.ID] {
+			inst.Region = m.instanceRegion(inst)
+			m.targets = append(m.targets, inst)
+		}
+	}
+	m.sessionMode = sessionMulti
+	// Without -tmux or -cmd the sessions' commands are printed to run later
+	if currentRoleArn() != "" && !useTmux && remoteCommand == "" && !evalOutput && !describeOutput {
+		m.inlineErr = roleCommandMsg
+		return m, nil
+	}
+	if sshConfigOutput {
+		m.step = stateDone
+		return m, tea.Quit
+	}
+	return m.startSelected()
+}
+
+// markColumn returns the marker shown before an instance row while any
+// instances are marked, or "" otherwise.
+func (m model) markColumn(inst Instance) string {
+	if len(m.selectedInstances) == 0 {
+		return ""
+	}
+	if m.selectedInstances[inst.ID] {
+		return "✓ "
+	}
+	return "  "
+}
+
+// multiSessionCommands renders one copy-pasteable start-session command per
+// target, each preceded by a comment naming the instance.
+func multiSessionCommands(profile string, targets []Instance) string {
+	var b strings.Builder
+	for _, inst := range targets {
+		fmt.Fprintf(&b, "# %s\n", inst.Display())
+		fmt.Fprintf(&b, "aws %s\n", shellJoin(shellSessionArgs(profile, inst.Region, inst.ID)))
+	}
+	return b.String()
+}
+
+// multiSSHConfig renders a Host block per target.
+func multiSSHConfig(profile, user string, targets []Instance) string {
+	blocks := []string{}
+	for _, inst := range targets {
+		blocks = append(blocks, sshConfigBlock(profile, inst.Region, user, inst))
+	}
+	return strings.Join(blocks, "\n")
+}
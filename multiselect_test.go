@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for building an instance list to mark instances in
+func multiModel() model {
+	instances := []Instance{
+		{ID: "i-123", Name: "web-1", State: "running"},
+		{ID: "i-456", Name: "web-2", State: "running"},
+		{ID: "i-789", Name: "web-3", State: "stopped"},
+	}
+	return model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+	}
+}
+
+// Test marking instances and starting a session to each
+func TestMultiSelect(t *testing.T) {
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	t.Run("tab toggles marks", func(t *testing.T) {
+		result := typeKeys(multiModel(), tab, down, tab)
+		assert.Equal(t, map[string]bool{"i-123": true, "i-456": true}, result.selectedInstances)
+		assert.Contains(t, result.View(), "✓ i-123")
+
+		result = typeKeys(result, tab)
+		assert.Equal(t, map[string]bool{"i-123": true}, result.selectedInstances)
+	})
+
+	t.Run("stopped instances can't be marked", func(t *testing.T) {
+		m := multiModel()
+		m.cursor = 2
+		result := typeKeys(m, tab)
+		assert.Empty(t, result.selectedInstances)
+		assert.Contains(t, result.inlineErr, "stopped")
+	})
+
+	t.Run("tab marks while filtering", func(t *testing.T) {
+		result := typeKeys(multiModel(), runeKeys("web-2")...)
+		result = typeKeys(result, tab)
+		assert.Equal(t, map[string]bool{"i-456": true}, result.selectedInstances)
+	})
+
+	t.Run("space is typed into the filter", func(t *testing.T) {
+		result := typeKeys(multiModel(), tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+		assert.Empty(t, result.selectedInstances)
+		assert.Equal(t, " ", result.filter)
+	})
+
+	t.Run("enter targets the marked instances", func(t *testing.T) {
+		result := typeKeys(multiModel(), down, tab, tea.KeyMsg{Type: tea.KeyUp}, tab)
+		updatedModel, cmd := result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result = updatedModel.(model)
+		assert.NotNil(t, cmd)
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, sessionMulti, result.sessionMode)
+		require.Len(t, result.targets, 2)
+		assert.Equal(t, "i-123", result.targets[0].ID)
+		assert.Equal(t, "us-east-1", result.targets[1].Region)
+	})
+
+	t.Run("confirm lists the targets and back keeps the marks", func(t *testing.T) {
+		m := multiModel()
+		m.confirm = true
+		result := typeKeys(m, tab, down, tab, tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, stateConfirm, result.step)
+		assert.Contains(t, result.View(), "Confirm 2 sessions")
+
+		result = typeKeys(result, tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, stateInstance, result.step)
+		assert.Equal(t, sessionShell, result.sessionMode)
+		assert.Len(t, result.selectedInstances, 2)
+	})
+
+	t.Run("going back to regions clears the marks", func(t *testing.T) {
+		result := typeKeys(multiModel(), tab, tea.KeyMsg{Type: tea.KeyLeft})
+		assert.Empty(t, result.selectedInstances)
+	})
+}
+
+// Test the commands printed for a multi-instance selection
+func TestMultiSessionCommands(t *testing.T) {
+	targets := []Instance{
+		{ID: "i-123", Name: "web-1", State: "running", Region: "us-east-1"},
+		{ID: "i-456", Name: "web-2", State: "running", Region: "eu-west-1"},
+	}
+	out := multiSessionCommands("prod", targets)
+	assert.Contains(t, out, "# i-123 (web-1) [running] @ us-east-1\naws ssm start-session")
+	assert.Contains(t, out, "--target i-456")
+	assert.Contains(t, out, "--region eu-west-1")
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Set by -no-color: render without ANSI colors or text attributes
+var noColor bool
+
+// colorDisabled reports whether -no-color or a non-empty NO_COLOR
+// (https://no-color.org) asks for plain output.
+func colorDisabled() bool {
+	return noColor || os.Getenv("NO_COLOR") != ""
+}
+
+// applyColorPreference downgrades every lipgloss style to plain text when
+// color is disabled. Borders and padding are kept, so the layout is unchanged.
+func applyColorPreference() {
+	if colorDisabled() {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
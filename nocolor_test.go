@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// Helper function for rendering in color and restoring the profile afterwards
+func withTrueColor(t *testing.T) {
+	original := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	t.Cleanup(func() { lipgloss.SetColorProfile(original) })
+}
+
+// Test that NO_COLOR and -no-color strip ANSI sequences from the view
+func TestNoColor(t *testing.T) {
+	m := model{step: stateRegion, regions: []string{"us-east-1"}, filteredRegions: []string{"us-east-1"}}
+
+	t.Run("colors by default", func(t *testing.T) {
+		withTrueColor(t)
+		t.Setenv("NO_COLOR", "")
+		applyColorPreference()
+		assert.Contains(t, m.View(), "\x1b[")
+	})
+
+	t.Run("NO_COLOR", func(t *testing.T) {
+		withTrueColor(t)
+		t.Setenv("NO_COLOR", "1")
+		applyColorPreference()
+		view := m.View()
+		assert.NotContains(t, view, "\x1b[")
+		assert.Contains(t, view, "> us-east-1")
+	})
+
+	t.Run("-no-color", func(t *testing.T) {
+		withTrueColor(t)
+		t.Setenv("NO_COLOR", "")
+		noColor = true
+		defer func() { noColor = false }()
+		applyColorPreference()
+		assert.NotContains(t, m.View(), "\x1b[")
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"goversion/pkg/awsssm"
+)
+
+// Set by -org-role: after the profile, pick a member account of its AWS
+// Organization and run every AWS call under this role in that account
+var orgRole string
+
+// accountRoleArn returns the ARN of role in the account with id, in
+// partition.
+func accountRoleArn(partition, id, role string) string {
+	return "arn:" + partition + ":iam::" + id + ":role/" + role
+}
+
+// accountsCmd lists the accounts to pick from: the configured ones, or the
+// active members of the organization profile belongs to.
+func accountsCmd(ctx context.Context, profile string, configured []Account) tea.Cmd {
+	return func() tea.Msg {
+		if len(configured) > 0 {
+			return struct {
+				accounts    []Account
+				accountsErr error
+			}{configured, nil}
+		}
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		accounts, err := awsssm.GetAccounts(ctx, describeRunner, profile)
+		return struct {
+			accounts    []Account
+			accountsErr error
+		}{accounts, lookupErr(ctx, err, "accounts", loadTimeout)}
+	}
+}
+
+// loadAccounts starts listing the accounts of the selected profile's
+// organization. The listing runs as the profile itself, so any role of an
+// account picked earlier is dropped first.
+func (m model) loadAccounts() (model, tea.Cmd) {
+	setRoleArn("")
+	m.loading = true
+	m.loadingMsg = "Fetching accounts for " + m.selectedProfile + "..."
+	return m, accountsCmd(m.lookupContext(), m.selectedProfile, m.config.Accounts)
+}
+
+// updateAccounts shows the listed accounts, with the cursor on the account
+// picked before, if any.
+func (m model) updateAccounts(accounts []Account, err error) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if isExpiredCredentials(err) {
+		return m.promptReauth(err, accountsCmd(m.lookupContext(), m.selectedProfile, m.config.Accounts)), nil
+	}
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.accounts = accounts
+	m.filter = ""
+	m.filteredAccounts = m.accountList()
+	m.cursor = 0
+	for i, a := range m.filteredAccounts {
+		if a.ID == m.selectedAccount.ID {
+			m.cursor = i
+		}
+	}
+	m.step = stateAccount
+	return m, nil
+}
+
+// accountList returns the accounts matching the filter by name or ID.
+func (m model) accountList() []Account {
+	labels := make([]string, len(m.accounts))
+	byLabel := map[string]Account{}
+	for i, a := range m.accounts {
+		labels[i] = a.Display()
+		byLabel[labels[i]] = a
+	}
+	filtered := []Account{}
+	for _, label := range fuzzyFilter(labels, m.filter) {
+		filtered = append(filtered, byLabel[label])
+	}
+	return filtered
+}
+
+// selectAccount switches to orgRole in the highlighted account and goes on
+// to the regions, or to the instances with -all-regions.
+func (m model) selectAccount() (tea.Model, tea.Cmd) {
+	if len(m.filteredAccounts) == 0 {
+		return m, nil
+	}
+	m.selectedAccount = m.filteredAccounts[m.cursor]
+	setRoleArn(accountRoleArn(profilePartition(m.selectedProfile), m.selectedAccount.ID, orgRole))
+	return m.afterProfile()
+}
+
+// backToAccounts returns to the account list, dropping the account's role.
+func (m model) backToAccounts() model {
+	setRoleArn("")
+	m.step = stateAccount
+	m.filteredAccounts = m.accountList()
+	m.cursor = 0
+	for i, a := range m.filteredAccounts {
+		if a.ID == m.selectedAccount.ID {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+func (m model) accountView() string {
+	w := m.contentWidth()
+	content := headerStyle.Render(fit("Select AWS account"+listCount(len(m.filteredAccounts), len(m.accounts)), w, 2)) + "\n"
+	content += infoStyle.Render(fit("Profile:"+m.selectedProfile+" | Role:"+orgRole, w, 2)) + "\n"
+	content += infoStyle.Render(fit("Search:"+m.filter, w, 2)) + "\n"
+	start, end := windowBounds(m.cursor, len(m.filteredAccounts), m.windowSize())
+	for i := start; i < end; i++ {
+		a := fit(m.filteredAccounts[i].Display(), w, 4)
+		_, matched, _ := fuzzyMatch(a, m.filter)
+		if m.cursor == i {
+			content += renderHighlighted("> ", a, matched, selectedStyle) + "\n"
+		} else {
+			content += renderHighlighted("  ", a, matched, itemStyle) + "\n"
+		}
+	}
+	if len(m.filteredAccounts) == 0 {
+		content += mutedStyle.Render(fit(m.emptyListMessage(), w, 2)) + "\n"
+	}
+	content += quitStyle.Render(fit("←/h: back • ?: help • esc: quit", w, 0))
+	return borderStyle.Render(content)
+}
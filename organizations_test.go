@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for setting -org-role and dropping any account role afterwards
+func withOrgRole(t *testing.T, role string) {
+	orgRole = role
+	t.Cleanup(func() {
+		orgRole = ""
+		setRoleArn("")
+	})
+}
+
+// Test picking an organization account between the profile and the regions
+func TestOrgAccountStep(t *testing.T) {
+	setTempHome(t)
+	withOrgRole(t, "OrganizationAccountAccessRole")
+	var calls [][]string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "organizations" {
+			return []byte(`{"Accounts":[{"Id":"222222222222","Name":"payments","Status":"ACTIVE"},{"Id":"111111111111","Name":"network","Status":"ACTIVE"}]}`), nil
+		}
+		return []byte(`{"Regions":[{"RegionName":"us-east-1"}]}`), nil
+	})
+	m := model{step: stateProfile, profiles: []string{"org"}, filteredProfiles: []string{"org"}}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	accounts := updatedModel.(model)
+	require.Equal(t, stateAccount, accounts.step)
+	assert.Equal(t, "network", accounts.filteredAccounts[0].Name)
+	assert.Contains(t, accounts.View(), "payments (222222222222)")
+	assert.Equal(t, "", currentRoleArn(), "the accounts are listed as the profile itself")
+
+	for _, r := range "pay" {
+		updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	require.Len(t, updatedModel.(model).filteredAccounts, 1)
+	updatedModel, cmd = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "arn:aws:iam::222222222222:role/OrganizationAccountAccessRole", currentRoleArn())
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	regions := updatedModel.(model)
+	assert.Equal(t, stateRegion, regions.step)
+	assert.Equal(t, "describe-regions", calls[len(calls)-1][1])
+
+	// Back to the accounts drops the role, with the cursor on the account
+	back := regions.back()
+	assert.Equal(t, stateAccount, back.step)
+	assert.Equal(t, "", currentRoleArn())
+	assert.Equal(t, "222222222222", back.filteredAccounts[back.cursor].ID)
+	assert.Equal(t, stateProfile, back.back().step)
+}
+
+// Test that configured accounts are listed without asking AWS Organizations
+func TestConfiguredAccounts(t *testing.T) {
+	setTempHome(t)
+	withOrgRole(t, "ops")
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		t.Fatalf("unexpected AWS call: %s", strings.Join(args, " "))
+		return nil, nil
+	})
+	m := model{
+		step:             stateProfile,
+		profiles:         []string{"org"},
+		filteredProfiles: []string{"org"},
+		config:           Config{Accounts: []Account{{ID: "333333333333", Name: "sandbox"}}},
+	}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result := updatedModel.(model)
+	assert.Equal(t, stateAccount, result.step)
+	assert.Equal(t, []Account{{ID: "333333333333", Name: "sandbox"}}, result.filteredAccounts)
+}
+
+// Test that each account's instances are cached apart
+func TestRoleCacheSuffix(t *testing.T) {
+	withOrgRole(t, "ops")
+	assert.Equal(t, "", roleCacheSuffix())
+	setRoleArn(accountRoleArn("aws", "111111111111", "ops"))
+	first := instanceCachePath("org", "us-east-1")
+	setRoleArn(accountRoleArn("aws", "222222222222", "ops"))
+	assert.NotEqual(t, first, instanceCachePath("org", "us-east-1"))
+}
+
+// Test that account roles are assumed in the profile's partition
+func TestAccountRolePartition(t *testing.T) {
+	writeAWSConfig(t, "[profile gov]\nregion = us-gov-west-1\n\n[profile china]\nregion = cn-north-1\n")
+	withOrgRole(t, "ops")
+	accounts := []Account{{ID: "111111111111", Name: "network"}}
+	for profile, want := range map[string]string{
+		"gov":   "arn:aws-us-gov:iam::111111111111:role/ops",
+		"china": "arn:aws-cn:iam::111111111111:role/ops",
+	} {
+		m := model{step: stateAccount, selectedProfile: profile, accounts: accounts, filteredAccounts: accounts}
+		m.selectAccount()
+		assert.Equal(t, want, currentRoleArn(), profile)
+	}
+}
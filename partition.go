@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"goversion/pkg/awsssm"
+)
+
+// Set by -partition: the AWS partition (aws, aws-us-gov or aws-cn) regions
+// are listed from. By default it follows the profile's region.
+var partition string
+
+// Regions of GovCloud and China offered when the profile may not call
+// describe-regions, like standardRegions for the commercial partition
+var partitionStandardRegions = map[string][]string{
+	awsssm.PartitionGovCloud: {"us-gov-west-1", "us-gov-east-1"},
+	awsssm.PartitionChina:    {"cn-north-1", "cn-northwest-1"},
+}
+
+// validatePartition checks -partition.
+func validatePartition() error {
+	if partition != "" && !slices.Contains(awsssm.Partitions, partition) {
+		return fmt.Errorf("unknown -partition %q; use one of %s", partition, strings.Join(awsssm.Partitions, ", "))
+	}
+	return nil
+}
+
+// profilePartition returns the partition of profile: -partition when set,
+// otherwise that of the profile's region in the AWS CLI config, or of
+// AWS_REGION.
+func profilePartition(profile string) string {
+	if partition != "" {
+		return partition
+	}
+	if region := profileRegion(profile); region != "" {
+		return awsssm.PartitionOf(region)
+	}
+	return awsssm.PartitionOf(defaultRegion())
+}
+
+// partitionRegions returns the built-in region list of partition.
+func partitionRegions(partition string) []string {
+	if regions, ok := partitionStandardRegions[partition]; ok {
+		return regions
+	}
+	return standardRegions
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+// Test picking the partition from -partition, the profile, or AWS_REGION
+func TestProfilePartition(t *testing.T) {
+	writeAWSConfig(t, "[profile gov]\nregion = us-gov-west-1\n\n[profile prod]\nregion = eu-west-1\n")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	assert.Equal(t, awsssm.PartitionGovCloud, profilePartition("gov"))
+	assert.Equal(t, awsssm.PartitionAWS, profilePartition("prod"))
+	assert.Equal(t, awsssm.PartitionAWS, profilePartition("missing"))
+
+	t.Setenv("AWS_REGION", "cn-north-1")
+	assert.Equal(t, awsssm.PartitionChina, profilePartition("missing"))
+
+	partition = awsssm.PartitionGovCloud
+	t.Cleanup(func() { partition = "" })
+	assert.Equal(t, awsssm.PartitionGovCloud, profilePartition("prod"))
+	assert.NoError(t, validatePartition())
+	partition = "aws-iso"
+	assert.EqualError(t, validatePartition(), `unknown -partition "aws-iso"; use one of aws, aws-us-gov, aws-cn`)
+}
+
+// Test that GovCloud regions are listed from a GovCloud region
+func TestGovCloudRegions(t *testing.T) {
+	writeAWSConfig(t, "[profile gov]\nregion = us-gov-west-1\n")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	var calls [][]string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(`{"Regions":[{"RegionName":"us-gov-west-1"},{"RegionName":"us-gov-east-1"}]}`), nil
+	})
+	regions, err := getRegions(context.Background(), "gov")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"us-gov-east-1", "us-gov-west-1"}, regions)
+	assert.Equal(t, "us-gov-west-1", calls[0][5])
+
+	// A denied describe-regions offers the GovCloud regions
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, &awsssm.CLIError{Args: args, Err: errors.New("exit status 254"), Stderr: "An error occurred (UnauthorizedOperation)"}
+	})
+	msg := regionsCmd(context.Background(), "gov", false)()
+	assert.Equal(t, struct{ staticRegions []string }{[]string{"us-gov-east-1", "us-gov-west-1"}}, msg)
+}
+
+// Test that the region list is asked of the profile's region, falling back
+// to the partition's usual regions when it can't be reached
+func TestDiscoveryRegionFallback(t *testing.T) {
+	writeAWSConfig(t, "[profile optin]\nregion = me-central-1\n")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	var asked []string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		asked = append(asked, args[5])
+		if args[5] == "me-central-1" {
+			return nil, errors.New("could not connect to the endpoint URL")
+		}
+		return []byte(`{"Regions":[{"RegionName":"me-central-1"}]}`), nil
+	})
+	regions, err := getRegions(context.Background(), "optin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me-central-1"}, regions)
+	assert.Equal(t, []string{"me-central-1", "us-west-2"}, asked)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Titles of the two sections of the region list once regions are pinned
+const (
+	pinnedRegionsTitle   = "Pinned"
+	unpinnedRegionsTitle = "All regions"
+)
+
+// regionList returns the regions matching the filter with the pinned ones
+// first, each part in its usual order.
+func (m model) regionList() []string {
+	filtered := fuzzyFilter(m.regions, m.filter)
+	pinned, rest := []string{}, []string{}
+	for _, region := range filtered {
+		if m.isPinnedRegion(region) {
+			pinned = append(pinned, region)
+		} else {
+			rest = append(rest, region)
+		}
+	}
+	return append(pinned, rest...)
+}
+
+// isPinnedRegion reports whether region was pinned with tab.
+func (m model) isPinnedRegion(region string) bool {
+	return slices.Contains(m.config.PinnedRegions, region)
+}
+
+// hasPinnedRegions reports whether the region list is split into sections.
+func (m model) hasPinnedRegions() bool {
+	return len(m.filteredRegions) > 0 && m.isPinnedRegion(m.filteredRegions[0])
+}
+
+// startsRegionGroup reports whether a section title is rendered above region
+// i in a window starting at start.
+func (m model) startsRegionGroup(i, start int) bool {
+	if !m.hasPinnedRegions() {
+		return false
+	}
+	return i == start || m.isPinnedRegion(m.filteredRegions[i-1]) != m.isPinnedRegion(m.filteredRegions[i])
+}
+
+// regionGroupTitle returns the title of the section region is listed in.
+func (m model) regionGroupTitle(region string) string {
+	if m.isPinnedRegion(region) {
+		return pinnedRegionsTitle
+	}
+	return unpinnedRegionsTitle
+}
+
+// togglePin pins the highlighted region to the top of the list, or unpins
+// it, and writes the pinned regions to the config file. The cursor stays on
+// the region as it moves between the sections.
+func (m model) togglePin() (tea.Model, tea.Cmd) {
+	if len(m.filteredRegions) == 0 {
+		return m, nil
+	}
+	region := m.filteredRegions[m.cursor]
+	if region == otherRegionsItem {
+		return m, nil
+	}
+	pinned := []string{}
+	for _, r := range m.config.PinnedRegions {
+		if r != region {
+			pinned = append(pinned, r)
+		}
+	}
+	if len(pinned) == len(m.config.PinnedRegions) {
+		pinned = append(pinned, region)
+	}
+	m.config.PinnedRegions = pinned
+	m.filteredRegions = m.regionList()
+	m.cursor = indexOf(m.filteredRegions, region)
+	return m, savePinnedRegionsCmd(pinned)
+}
+
+// savePinnedRegionsCmd writes the pinned regions in the background; like
+// favorites, failing to save only means they aren't remembered.
+func savePinnedRegionsCmd(pinned []string) tea.Cmd {
+	return func() tea.Msg {
+		_ = saveConfigValue("pinned_regions", pinned)
+		return nil
+	}
+}
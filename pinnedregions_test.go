@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test pinning regions to the top of the list and saving them
+func TestTogglePin(t *testing.T) {
+	setTempHome(t)
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1", "ap-south-1"}
+	m := model{step: stateRegion, selectedProfile: "prod", regions: regions, filteredRegions: regions, cursor: 2}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	result := updatedModel.(model)
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Equal(t, []string{"eu-west-1", "us-east-1", "us-west-2", "ap-south-1"}, result.filteredRegions)
+	assert.Equal(t, 0, result.cursor, "the cursor follows the region")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1"}, cfg.PinnedRegions)
+
+	view := result.View()
+	assert.Contains(t, view, pinnedRegionsTitle)
+	assert.Contains(t, view, unpinnedRegionsTitle)
+
+	// Filtering keeps the sections, and tab pins while filtering
+	for _, r := range "west" {
+		updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	result = updatedModel.(model)
+	assert.Equal(t, []string{"eu-west-1", "us-west-2"}, result.filteredRegions)
+	result.cursor = 1
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyTab})
+	result = updatedModel.(model)
+	assert.Equal(t, []string{"eu-west-1", "us-west-2"}, result.config.PinnedRegions)
+	assert.NotContains(t, result.View(), unpinnedRegionsTitle)
+
+	// Pressing it again unpins
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyTab})
+	result = updatedModel.(model)
+	assert.Equal(t, []string{"eu-west-1"}, result.config.PinnedRegions)
+	assert.Equal(t, "us-west-2", result.filteredRegions[result.cursor])
+}
+
+// Test that pinned regions lead the list when it loads
+func TestPinnedRegionsLoad(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	m := model{
+		step:       stateProfile,
+		loading:    true,
+		lastRegion: "us-east-1",
+		config:     Config{PinnedRegions: []string{"ap-south-1"}},
+		width:      80,
+		height:     30,
+	}
+	updatedModel, _ := m.Update(struct {
+		regions []string
+		err     error
+	}{[]string{"us-east-1", "ap-south-1"}, nil})
+	result := updatedModel.(model)
+	assert.Equal(t, []string{"ap-south-1", "us-east-1"}, result.filteredRegions)
+	assert.Equal(t, 1, result.cursor)
+
+	// Clicks skip the section titles
+	_, ok := result.listRowAt(0, 5)
+	assert.False(t, ok)
+	row, ok := result.listRowAt(0, 6)
+	assert.True(t, ok)
+	assert.Equal(t, 0, row)
+}
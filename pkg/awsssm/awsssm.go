@@ -0,0 +1,115 @@
+// Package awsssm discovers the AWS profiles, regions and EC2 instances that
+// ssmssh connects to. It shells out to the AWS CLI through a Runner, so
+// callers decide how the CLI is run and tests can supply canned output.
+package awsssm
+
+import "time"
+
+type Tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// TagValue returns the value of the tag with the given key, or "".
+func TagValue(tags []Tag, key string) string {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// Instance is an EC2 instance as shown in the picker.
+type Instance struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Tags   []Tag  `json:"tags"`
+	Region string `json:"region,omitempty"`
+	// Private addresses, matched by the filter so a pasted IP or hostname
+	// finds its instance
+	PrivateIP  string `json:"private_ip,omitempty"`
+	PrivateDNS string `json:"private_dns,omitempty"`
+	// VPC the instance runs in, used to find an EC2 Instance Connect Endpoint
+	VpcId      string    `json:"vpc_id,omitempty"`
+	LaunchTime time.Time `json:"launch_time,omitzero"`
+}
+
+// Display returns the list label, e.g. "i-123 (web-server) [running]". In the
+// all-regions view the region is appended: "i-123 (web-server) [running] @ us-east-1".
+func (i Instance) Display() string {
+	display := i.ID
+	if i.Name != "" {
+		display += " (" + i.Name + ")"
+	}
+	if i.State != "" {
+		display += " [" + i.State + "]"
+	}
+	if i.Region != "" {
+		display += " @ " + i.Region
+	}
+	return display
+}
+
+// Connectable reports whether an SSM session can be started. Instances with
+// an unknown state are given the benefit of the doubt.
+func (i Instance) Connectable() bool {
+	return i.State == "" || i.State == "running"
+}
+
+// InstanceConnectEndpoint is an EC2 Instance Connect Endpoint, which tunnels
+// to instances in its VPC without SSM.
+type InstanceConnectEndpoint struct {
+	ID    string
+	VpcId string
+	State string
+}
+
+// Ready reports whether the endpoint has finished creating and accepts tunnels.
+func (e InstanceConnectEndpoint) Ready() bool {
+	return e.State == "create-complete"
+}
+
+// InstanceDetails is what the preview pane shows for the highlighted instance.
+type InstanceDetails struct {
+	AvailabilityZone string
+	InstanceType     string
+	PrivateIP        string
+	PublicIP         string
+	LaunchTime       time.Time
+	Tags             []Tag
+}
+
+// Lines returns the non-empty details as "Label: value" lines, e.g. "Type: t3.micro".
+func (d InstanceDetails) Lines() []string {
+	lines := []string{}
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	add("Type", d.InstanceType)
+	add("AZ", d.AvailabilityZone)
+	add("Private IP", d.PrivateIP)
+	add("Public IP", d.PublicIP)
+	if !d.LaunchTime.IsZero() {
+		add("Launched", d.LaunchTime.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	return lines
+}
+
+// Account is a member account of an AWS Organization.
+type Account struct {
+	ID     string `yaml:"id" json:"id"`
+	Name   string `yaml:"name" json:"name"`
+	Status string `yaml:"-" json:"-"`
+}
+
+// Display returns the picker label, e.g. "payments (123456789012)".
+func (a Account) Display() string {
+	if a.Name == "" {
+		return a.ID
+	}
+	return a.Name + " (" + a.ID + ")"
+}
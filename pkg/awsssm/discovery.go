@@ -0,0 +1,272 @@
+package awsssm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// Page size requested from describe-instances; remaining pages are followed via NextToken
+const describeInstancesPageSize = "1000"
+
+// GetProfiles returns the profile names defined in the shared credentials
+// file at path.
+func GetProfiles(path string) ([]string, error) {
+	cfg, err := ini.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{}
+	for _, section := range cfg.Sections() {
+		if section.Name() != "DEFAULT" {
+			profiles = append(profiles, section.Name())
+		}
+	}
+	return profiles, nil
+}
+
+// GetRegions returns the regions enabled for profile in alphabetical order.
+// They are asked of the region in AWS_REGION or AWS_DEFAULT_REGION, falling
+// back to the commercial partition's usual regions. A nil runner runs the aws
+// binary on the PATH, as with every lookup here.
+func GetRegions(ctx context.Context, runner Runner, profile string) ([]string, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return GetRegionsFromAny(ctx, runner, profile, DiscoveryRegions(PartitionOf(region), region))
+}
+
+// GetRegionsFromAny returns the regions enabled for profile, asking each of
+// regions in turn until one answers. Every region must belong to the same
+// partition, as DiscoveryRegions returns them. If none answers, the error of
+// the first is returned.
+func GetRegionsFromAny(ctx context.Context, runner Runner, profile string, regions []string) ([]string, error) {
+	var firstErr error
+	for _, region := range regions {
+		found, err := GetRegionsFrom(ctx, runner, profile, region)
+		if err == nil {
+			return found, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = errors.New("no region to ask for the region list")
+	}
+	return nil, firstErr
+}
+
+// GetRegionsFrom returns the regions enabled for profile in alphabetical
+// order, asking region. Only the regions of region's partition are listed,
+// so use PartitionDiscoveryRegion for GovCloud and China.
+func GetRegionsFrom(ctx context.Context, runner Runner, profile, region string) ([]string, error) {
+	out, err := runnerOrDefault(runner).Run(ctx, "ec2", "describe-regions", "--profile", profile, "--region", region, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Regions []struct {
+			RegionName string `json:"RegionName"`
+		}
+	}
+	if err := DecodeOutput(out, &result); err != nil {
+		return nil, err
+	}
+	regions := []string{}
+	for _, r := range result.Regions {
+		regions = append(regions, r.RegionName)
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// GetInstances lists every instance in profile/region, following
+// describe-instances pagination.
+func GetInstances(ctx context.Context, runner Runner, profile, region string) ([]Instance, error) {
+	return FindInstances(ctx, runner, profile, region)
+}
+
+// TagFilter returns a describe-instances filter matching instances whose tag
+// key has value, which may contain * and ? wildcards.
+func TagFilter(key, value string) string {
+	return "Name=tag:" + key + ",Values=" + value
+}
+
+// FindInstances lists the instances in profile/region that match all of
+// filters, given in the AWS CLI's Name=...,Values=... form such as TagFilter
+// returns. Without filters it lists every instance.
+func FindInstances(ctx context.Context, runner Runner, profile, region string, filters ...string) ([]Instance, error) {
+	instances := []Instance{}
+	err := FindInstancePages(ctx, runner, profile, region, filters, func(page []Instance, _ bool) {
+		instances = append(instances, page...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// FindInstancePages is FindInstances calling page with the instances of each
+// describe-instances page as it arrives, so callers can report progress on
+// accounts large enough to need several pages. more reports whether another
+// page follows.
+func FindInstancePages(ctx context.Context, runner Runner, profile, region string, filters []string, page func(instances []Instance, more bool)) error {
+	runner = runnerOrDefault(runner)
+	token := ""
+	for {
+		args := []string{"ec2", "describe-instances", "--profile", profile, "--region", region, "--output", "json", "--max-items", describeInstancesPageSize}
+		if len(filters) > 0 {
+			args = append(args, "--filters")
+			args = append(args, filters...)
+		}
+		if token != "" {
+			args = append(args, "--starting-token", token)
+		}
+		out, err := runner.Run(ctx, args...)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Reservations []struct {
+				Instances []struct {
+					InstanceId string `json:"InstanceId"`
+					State      struct {
+						Name string `json:"Name"`
+					} `json:"State"`
+					PrivateIpAddress string    `json:"PrivateIpAddress"`
+					PrivateDnsName   string    `json:"PrivateDnsName"`
+					VpcId            string    `json:"VpcId"`
+					LaunchTime       time.Time `json:"LaunchTime"`
+					Tags             []Tag     `json:"Tags"`
+				}
+			}
+			NextToken string `json:"NextToken"`
+		}
+		if err := DecodeOutput(out, &result); err != nil {
+			return err
+		}
+		instances := []Instance{}
+		for _, res := range result.Reservations {
+			for _, inst := range res.Instances {
+				instances = append(instances, Instance{
+					ID:         inst.InstanceId,
+					Name:       TagValue(inst.Tags, "Name"),
+					State:      inst.State.Name,
+					Tags:       inst.Tags,
+					PrivateIP:  inst.PrivateIpAddress,
+					PrivateDNS: inst.PrivateDnsName,
+					VpcId:      inst.VpcId,
+					LaunchTime: inst.LaunchTime,
+				})
+			}
+		}
+		more := result.NextToken != "" && result.NextToken != token
+		page(instances, more)
+		if !more {
+			return nil
+		}
+		token = result.NextToken
+	}
+}
+
+// GetInstanceDetails describes a single instance for the preview pane.
+func GetInstanceDetails(ctx context.Context, runner Runner, profile, region, instanceId string) (InstanceDetails, error) {
+	out, err := runnerOrDefault(runner).Run(ctx, "ec2", "describe-instances", "--profile", profile, "--region", region, "--instance-ids", instanceId, "--output", "json")
+	if err != nil {
+		return InstanceDetails{}, err
+	}
+	var result struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceType     string    `json:"InstanceType"`
+				PrivateIpAddress string    `json:"PrivateIpAddress"`
+				PublicIpAddress  string    `json:"PublicIpAddress"`
+				LaunchTime       time.Time `json:"LaunchTime"`
+				Placement        struct {
+					AvailabilityZone string `json:"AvailabilityZone"`
+				} `json:"Placement"`
+				Tags []Tag `json:"Tags"`
+			}
+		}
+	}
+	if err := DecodeOutput(out, &result); err != nil {
+		return InstanceDetails{}, err
+	}
+	details := InstanceDetails{Tags: []Tag{}}
+	for _, res := range result.Reservations {
+		for _, inst := range res.Instances {
+			details.AvailabilityZone = inst.Placement.AvailabilityZone
+			details.InstanceType = inst.InstanceType
+			details.PrivateIP = inst.PrivateIpAddress
+			details.PublicIP = inst.PublicIpAddress
+			details.LaunchTime = inst.LaunchTime
+			details.Tags = append(details.Tags, inst.Tags...)
+		}
+	}
+	return details, nil
+}
+
+// GetInstanceConnectEndpoints lists the EC2 Instance Connect Endpoints in
+// profile/region.
+func GetInstanceConnectEndpoints(ctx context.Context, runner Runner, profile, region string) ([]InstanceConnectEndpoint, error) {
+	out, err := runnerOrDefault(runner).Run(ctx, "ec2", "describe-instance-connect-endpoints", "--profile", profile, "--region", region, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		InstanceConnectEndpoints []struct {
+			InstanceConnectEndpointId string `json:"InstanceConnectEndpointId"`
+			VpcId                     string `json:"VpcId"`
+			State                     string `json:"State"`
+		} `json:"InstanceConnectEndpoints"`
+	}
+	if err := DecodeOutput(out, &result); err != nil {
+		return nil, err
+	}
+	endpoints := []InstanceConnectEndpoint{}
+	for _, e := range result.InstanceConnectEndpoints {
+		endpoints = append(endpoints, InstanceConnectEndpoint{ID: e.InstanceConnectEndpointId, VpcId: e.VpcId, State: e.State})
+	}
+	return endpoints, nil
+}
+
+// GetAccounts returns the active member accounts of the organization that
+// profile belongs to, ordered by name. The AWS CLI follows the pagination of
+// list-accounts itself.
+func GetAccounts(ctx context.Context, runner Runner, profile string) ([]Account, error) {
+	out, err := runnerOrDefault(runner).Run(ctx, "organizations", "list-accounts", "--profile", profile, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Accounts []struct {
+			Id     string `json:"Id"`
+			Name   string `json:"Name"`
+			Status string `json:"Status"`
+		} `json:"Accounts"`
+	}
+	if err := DecodeOutput(out, &result); err != nil {
+		return nil, err
+	}
+	accounts := []Account{}
+	for _, a := range result.Accounts {
+		// Suspended and closing accounts can't be assumed into
+		if a.Status != "" && a.Status != "ACTIVE" {
+			continue
+		}
+		accounts = append(accounts, Account{ID: a.Id, Name: a.Name, Status: a.Status})
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts, nil
+}
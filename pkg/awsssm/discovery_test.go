@@ -0,0 +1,229 @@
+package awsssm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for a Runner that answers every call with the same output
+func fakeRun(out string) Runner {
+	return RunnerFunc(func(ctx context.Context, args ...string) ([]byte, error) {
+		return []byte(out), nil
+	})
+}
+
+// Helper function for a Runner that records the arguments of each call and
+// answers with the next response
+func recordingRun(calls *[][]string, responses ...string) Runner {
+	return RunnerFunc(func(ctx context.Context, args ...string) ([]byte, error) {
+		*calls = append(*calls, args)
+		if len(responses) == 0 {
+			return nil, errors.New("unexpected call")
+		}
+		out := responses[0]
+		responses = responses[1:]
+		return []byte(out), nil
+	})
+}
+
+// Test reading profile names from a credentials file
+func TestGetProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(path, []byte("[default]\naws_access_key_id = x\n\n[prod]\naws_access_key_id = y\n"), 0600))
+
+	profiles, err := GetProfiles(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "prod"}, profiles)
+
+	_, err = GetProfiles(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+// Test listing regions
+func TestGetRegions(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	var calls [][]string
+	run := recordingRun(&calls, `{"Regions": [{"RegionName": "us-west-2"}, {"RegionName": "ap-south-1"}]}`)
+
+	regions, err := GetRegions(context.Background(), run, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ap-south-1", "us-west-2"}, regions)
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"ec2", "describe-regions", "--profile", "prod", "--region", "us-west-2", "--output", "json"}, calls[0])
+
+	failing := RunnerFunc(func(ctx context.Context, args ...string) ([]byte, error) {
+		return nil, errors.New("access denied")
+	})
+	_, err = GetRegions(context.Background(), failing, "prod")
+	assert.EqualError(t, err, "access denied")
+}
+
+// Test that instance listing follows pagination and reads names from tags
+func TestGetInstances(t *testing.T) {
+	var calls [][]string
+	run := recordingRun(&calls,
+		`{"Reservations": [{"Instances": [{"InstanceId": "i-1", "State": {"Name": "running"}, "PrivateIpAddress": "10.0.0.1", "VpcId": "vpc-1", "LaunchTime": "2026-10-01T08:00:00Z", "Tags": [{"Key": "Name", "Value": "web"}]}]}], "NextToken": "page2"}`,
+		`{"Reservations": [{"Instances": [{"InstanceId": "i-2", "State": {"Name": "stopped"}, "PrivateDnsName": "ip-10-0-0-2.ec2.internal"}]}]}`,
+	)
+
+	instances, err := GetInstances(context.Background(), run, "prod", "eu-west-1")
+	require.NoError(t, err)
+	assert.Equal(t, []Instance{
+		{ID: "i-1", Name: "web", State: "running", Tags: []Tag{{Key: "Name", Value: "web"}}, PrivateIP: "10.0.0.1", VpcId: "vpc-1", LaunchTime: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)},
+		{ID: "i-2", State: "stopped", PrivateDNS: "ip-10-0-0-2.ec2.internal"},
+	}, instances)
+	require.Len(t, calls, 2)
+	assert.NotContains(t, calls[0], "--starting-token")
+	assert.Equal(t, []string{"--starting-token", "page2"}, calls[1][len(calls[1])-2:])
+}
+
+// Test listing the instances that match a tag filter
+func TestFindInstances(t *testing.T) {
+	var calls [][]string
+	run := recordingRun(&calls, `{"Reservations": [{"Instances": [{"InstanceId": "i-1", "State": {"Name": "running"}, "Tags": [{"Key": "Role", "Value": "web"}]}]}]}`)
+
+	instances, err := FindInstances(context.Background(), run, "prod", "eu-west-1", TagFilter("Role", "web*"))
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-1", instances[0].ID)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "ec2 describe-instances --profile prod --region eu-west-1 --output json --max-items 1000 --filters Name=tag:Role,Values=web*", strings.Join(calls[0], " "))
+}
+
+// Test that each describe-instances page is reported as it arrives
+func TestFindInstancePages(t *testing.T) {
+	var calls [][]string
+	run := recordingRun(&calls,
+		`{"Reservations": [{"Instances": [{"InstanceId": "i-1"}, {"InstanceId": "i-2"}]}], "NextToken": "page2"}`,
+		`{"Reservations": [{"Instances": [{"InstanceId": "i-3"}]}]}`,
+	)
+
+	var pages [][]string
+	var more []bool
+	err := FindInstancePages(context.Background(), run, "prod", "eu-west-1", nil, func(page []Instance, hasMore bool) {
+		ids := []string{}
+		for _, inst := range page {
+			ids = append(ids, inst.ID)
+		}
+		pages = append(pages, ids)
+		more = append(more, hasMore)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"i-1", "i-2"}, {"i-3"}}, pages)
+	assert.Equal(t, []bool{true, false}, more)
+}
+
+// Test describing a single instance
+func TestGetInstanceDetails(t *testing.T) {
+	var calls [][]string
+	run := recordingRun(&calls, `{"Reservations": [{"Instances": [{
+		"InstanceType": "t3.micro",
+		"PrivateIpAddress": "10.0.0.1",
+		"LaunchTime": "2024-05-01T12:30:00+00:00",
+		"Placement": {"AvailabilityZone": "eu-west-1a"},
+		"Tags": [{"Key": "Team", "Value": "payments"}]
+	}]}]}`)
+
+	details, err := GetInstanceDetails(context.Background(), run, "prod", "eu-west-1", "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, "t3.micro", details.InstanceType)
+	assert.Equal(t, "eu-west-1a", details.AvailabilityZone)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), details.LaunchTime.UTC())
+	assert.Equal(t, "payments", TagValue(details.Tags, "Team"))
+	assert.Contains(t, calls[0], "i-1")
+}
+
+// Test listing EC2 Instance Connect Endpoints
+func TestGetInstanceConnectEndpoints(t *testing.T) {
+	var calls [][]string
+	run := recordingRun(&calls, `{"InstanceConnectEndpoints": [
+		{"InstanceConnectEndpointId": "eice-1", "VpcId": "vpc-1", "State": "create-complete"},
+		{"InstanceConnectEndpointId": "eice-2", "VpcId": "vpc-2", "State": "create-in-progress"}
+	]}`)
+
+	endpoints, err := GetInstanceConnectEndpoints(context.Background(), run, "prod", "eu-west-1")
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, InstanceConnectEndpoint{ID: "eice-1", VpcId: "vpc-1", State: "create-complete"}, endpoints[0])
+	assert.True(t, endpoints[0].Ready())
+	assert.False(t, endpoints[1].Ready())
+	assert.Equal(t, "describe-instance-connect-endpoints", calls[0][1])
+}
+
+// Test listing the active accounts of an organization
+func TestGetAccounts(t *testing.T) {
+	var calls [][]string
+	run := recordingRun(&calls, `{"Accounts": [
+		{"Id": "222222222222", "Name": "payments", "Status": "ACTIVE"},
+		{"Id": "333333333333", "Name": "old", "Status": "SUSPENDED"},
+		{"Id": "111111111111", "Name": "network", "Status": "ACTIVE"}
+	]}`)
+
+	accounts, err := GetAccounts(context.Background(), run, "org")
+	require.NoError(t, err)
+	assert.Equal(t, []Account{
+		{ID: "111111111111", Name: "network", Status: "ACTIVE"},
+		{ID: "222222222222", Name: "payments", Status: "ACTIVE"},
+	}, accounts)
+	assert.Equal(t, []string{"organizations", "list-accounts", "--profile", "org", "--output", "json"}, calls[0])
+	assert.Equal(t, "network (111111111111)", accounts[0].Display())
+	assert.Equal(t, "111111111111", Account{ID: "111111111111"}.Display())
+}
+
+// Test that the region asked for the region list is derived, not fixed
+func TestGetRegionsDiscoveryRegion(t *testing.T) {
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	var asked []string
+	run := RunnerFunc(func(ctx context.Context, args ...string) ([]byte, error) {
+		region := args[5]
+		asked = append(asked, region)
+		if region != "eu-west-1" {
+			return nil, errors.New("could not connect to the endpoint URL for " + region)
+		}
+		return []byte(`{"Regions": [{"RegionName": "eu-west-1"}]}`), nil
+	})
+
+	// AWS_REGION is asked first
+	t.Setenv("AWS_REGION", "ap-south-2")
+	regions, err := GetRegions(context.Background(), run, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1"}, regions)
+	assert.Equal(t, []string{"ap-south-2", "us-west-2", "us-east-1", "eu-west-1"}, asked)
+
+	// The preferred region only counts in its own partition
+	assert.Equal(t, []string{"cn-north-1", "cn-northwest-1"}, DiscoveryRegions(PartitionChina, "us-east-1"))
+	assert.Equal(t, []string{"us-east-1", "us-west-2", "eu-west-1"}, DiscoveryRegions(PartitionAWS, "us-east-1", ""))
+
+	// With none answering, the first failure is reported
+	asked = nil
+	_, err = GetRegionsFromAny(context.Background(), run, "prod", []string{"us-east-1", "us-east-2"})
+	assert.EqualError(t, err, "could not connect to the endpoint URL for us-east-1")
+	assert.Len(t, asked, 2)
+}
+
+// Test telling the partitions apart by region
+func TestPartitions(t *testing.T) {
+	assert.Equal(t, PartitionGovCloud, PartitionOf("us-gov-west-1"))
+	assert.Equal(t, PartitionChina, PartitionOf("cn-northwest-1"))
+	assert.Equal(t, PartitionAWS, PartitionOf("us-east-1"))
+	assert.Equal(t, PartitionAWS, PartitionOf(""))
+
+	assert.Equal(t, []string{"us-gov-west-1", "us-gov-east-1"}, DiscoveryRegions(PartitionGovCloud))
+	assert.Empty(t, DiscoveryRegions("aws-iso"))
+
+	var calls [][]string
+	run := recordingRun(&calls, `{"Regions": [{"RegionName": "cn-north-1"}]}`)
+	regions, err := GetRegionsFrom(context.Background(), run, "china", "cn-north-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cn-north-1"}, regions)
+	assert.Equal(t, "cn-north-1", calls[0][5])
+}
@@ -0,0 +1,53 @@
+package awsssm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// How much of unparseable AWS CLI output is quoted in the error
+const outputSnippetSize = 200
+
+// DecodeOutput parses AWS CLI output into v. Lines printed before the JSON
+// document, such as CLI deprecation warnings, are skipped. When the output
+// isn't JSON at all (e.g. an HTML error page from a proxy) the error quotes
+// the start of it so it's clear what came back.
+func DecodeOutput(out []byte, v any) error {
+	doc := stripLeadingNoise(out)
+	if doc == nil {
+		return fmt.Errorf("AWS CLI output is not JSON: %s", outputSnippet(out))
+	}
+	if err := json.Unmarshal(doc, v); err != nil {
+		return fmt.Errorf("invalid JSON from the AWS CLI (%v): %s", err, outputSnippet(out))
+	}
+	return nil
+}
+
+// stripLeadingNoise returns out from the first line that opens a JSON object
+// or array, or nil if there is none.
+func stripLeadingNoise(out []byte) []byte {
+	rest := out
+	for len(rest) > 0 {
+		line := bytes.TrimLeft(rest, " \t\r\n")
+		if len(line) > 0 && (line[0] == '{' || line[0] == '[') {
+			return line
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+		rest = rest[i+1:]
+	}
+	return nil
+}
+
+// outputSnippet quotes the start of out for error messages.
+func outputSnippet(out []byte) string {
+	snippet := bytes.TrimSpace(out)
+	suffix := ""
+	if len(snippet) > outputSnippetSize {
+		snippet, suffix = snippet[:outputSnippetSize], "…"
+	}
+	return fmt.Sprintf("%q%s", snippet, suffix)
+}
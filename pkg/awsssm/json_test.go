@@ -0,0 +1,45 @@
+package awsssm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test parsing AWS CLI output that isn't clean JSON
+func TestDecodeOutput(t *testing.T) {
+	t.Run("warning before the JSON is skipped", func(t *testing.T) {
+		run := fakeRun("WARNING: Python 3.7 is deprecated and will not be supported by the AWS CLI after 2024-01-01\n" +
+			`{"Regions": [{"RegionName": "us-east-1"}, {"RegionName": "eu-west-1"}]}`)
+		regions, err := GetRegions(context.Background(), run, "default")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"eu-west-1", "us-east-1"}, regions)
+	})
+
+	t.Run("non-JSON output is quoted in the error", func(t *testing.T) {
+		run := fakeRun("<html><body><h1>403 Forbidden</h1>Blocked by proxy</body></html>")
+		_, err := GetRegions(context.Background(), run, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "AWS CLI output is not JSON")
+		assert.Contains(t, err.Error(), "403 Forbidden")
+	})
+
+	t.Run("broken JSON is quoted in the error", func(t *testing.T) {
+		var v struct{}
+		err := DecodeOutput([]byte(`{"Regions": [`), &v)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid JSON from the AWS CLI")
+		assert.Contains(t, err.Error(), `{\"Regions\": [`)
+	})
+
+	t.Run("long output is cut short", func(t *testing.T) {
+		var v struct{}
+		err := DecodeOutput([]byte(strings.Repeat("x", 1000)), &v)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), strings.Repeat("x", outputSnippetSize)+`"…`)
+		assert.NotContains(t, err.Error(), strings.Repeat("x", outputSnippetSize+1))
+	})
+}
@@ -0,0 +1,52 @@
+package awsssm
+
+import (
+	"slices"
+	"strings"
+)
+
+// AWS partitions: the commercial regions, AWS GovCloud (US), and the China
+// regions. Each has its own endpoints and credentials.
+const (
+	PartitionAWS      = "aws"
+	PartitionGovCloud = "aws-us-gov"
+	PartitionChina    = "aws-cn"
+)
+
+// Partitions lists the supported partitions.
+var Partitions = []string{PartitionAWS, PartitionGovCloud, PartitionChina}
+
+// Regions describe-regions is tried in, in order, for each partition when
+// no region of the profile is known. Several, since a region can be out of
+// reach, e.g. denied by a service control policy.
+var partitionDiscoveryRegions = map[string][]string{
+	PartitionAWS:      {"us-west-2", "us-east-1", "eu-west-1"},
+	PartitionGovCloud: {"us-gov-west-1", "us-gov-east-1"},
+	PartitionChina:    {"cn-north-1", "cn-northwest-1"},
+}
+
+// PartitionOf returns the partition region belongs to, e.g. aws-us-gov for
+// us-gov-west-1. An empty region belongs to the commercial partition.
+func PartitionOf(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionGovCloud
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionChina
+	}
+	return PartitionAWS
+}
+
+// DiscoveryRegions returns the regions to try describe-regions in for
+// partition: preferred first, such as the profile's own region, when it
+// belongs to partition, then the partition's usual regions. An unknown
+// partition has none of its own.
+func DiscoveryRegions(partition string, preferred ...string) []string {
+	regions := []string{}
+	for _, r := range append(preferred, partitionDiscoveryRegions[partition]...) {
+		if r != "" && PartitionOf(r) == partition && !slices.Contains(regions, r) {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
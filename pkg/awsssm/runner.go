@@ -0,0 +1,73 @@
+package awsssm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner executes the AWS CLI with the given arguments and returns its stdout.
+type Runner interface {
+	Run(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// RunnerFunc adapts an ordinary function to the Runner interface.
+type RunnerFunc func(ctx context.Context, args ...string) ([]byte, error)
+
+func (f RunnerFunc) Run(ctx context.Context, args ...string) ([]byte, error) {
+	return f(ctx, args...)
+}
+
+// ExecRunner runs the AWS CLI as a subprocess. It is the Runner used when a
+// lookup is given nil.
+type ExecRunner struct {
+	// CLI binary to run; "aws" on the PATH when empty
+	Path string
+}
+
+func (r ExecRunner) Run(ctx context.Context, args ...string) ([]byte, error) {
+	path := r.Path
+	if path == "" {
+		path = "aws"
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, &CLIError{Args: args, Err: err, Stderr: strings.TrimSpace(stderr.String())}
+	}
+	return out, nil
+}
+
+// runnerOrDefault returns r, or an ExecRunner when r is nil.
+func runnerOrDefault(r Runner) Runner {
+	if r == nil {
+		return ExecRunner{}
+	}
+	return r
+}
+
+// CLIError is a failed AWS CLI invocation along with what it printed to stderr.
+type CLIError struct {
+	Args   []string
+	Err    error
+	Stderr string
+}
+
+func (e *CLIError) Error() string {
+	command := "aws"
+	if len(e.Args) >= 2 {
+		command += " " + e.Args[0] + " " + e.Args[1]
+	}
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s failed: %v", command, e.Err)
+	}
+	return fmt.Sprintf("%s failed (%v):\n%s", command, e.Err, e.Stderr)
+}
+
+func (e *CLIError) Unwrap() error {
+	return e.Err
+}
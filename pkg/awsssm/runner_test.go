@@ -0,0 +1,38 @@
+package awsssm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for a stand-in aws binary running the given shell script
+func fakeCLI(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "aws")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return path
+}
+
+// Test that the exec runner returns stdout and passes the arguments through
+func TestExecRunner(t *testing.T) {
+	runner := ExecRunner{Path: fakeCLI(t, `echo "$@"`)}
+	out, err := runner.Run(context.Background(), "ec2", "describe-regions")
+	require.NoError(t, err)
+	assert.Equal(t, "ec2 describe-regions\n", string(out))
+}
+
+// Test that a failing CLI call carries its stderr
+func TestExecRunnerError(t *testing.T) {
+	runner := ExecRunner{Path: fakeCLI(t, `echo "An error occurred (AccessDenied)" >&2; exit 255`)}
+	_, err := GetRegions(context.Background(), runner, "prod")
+	require.Error(t, err)
+	var cliErr *CLIError
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, "An error occurred (AccessDenied)", cliErr.Stderr)
+	assert.Contains(t, err.Error(), "aws ec2 describe-regions failed")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SSM document used for port forwarding sessions
+const portForwardDocument = "AWS-StartPortForwardingSession"
+
+// Port forward input fields, in tab order
+const (
+	portFieldRemote = iota
+	portFieldLocal
+)
+
+// portForwardArgs returns the AWS CLI arguments for forwarding localPort to
+// remotePort on the instance. The parameters are passed as a single JSON
+// argument so no shell quoting is involved.
+func portForwardArgs(profile, region, instanceId string, localPort, remotePort int) []string {
+	params, _ := json.Marshal(map[string][]string{
+		"portNumber":      {strconv.Itoa(remotePort)},
+		"localPortNumber": {strconv.Itoa(localPort)},
+	})
+	args := sessionArgs(profile, region, instanceId)
+	return append(args, "--document-name", portForwardDocument, "--parameters", string(params))
+}
+
+// parsePort validates a TCP port number typed by the user.
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q: must be between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+// updatePortForward handles key input on the port forward prompt.
+func (m model) updatePortForward(s string) (tea.Model, tea.Cmd) {
+	field := &m.remotePort
+	if m.portField == portFieldLocal {
+		field = &m.localPort
+	}
+	// Printable keys are left to the port fields
+	if !isFilterKey(s) && m.keys().matches(actionBack, s) {
+		return m.back(), nil
+	}
+	switch s {
+	case "tab", "shift+tab", "up", "down":
+		m.portField = 1 - m.portField
+	case "backspace":
+		if len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
+		}
+	case "enter":
+		remote, err := parsePort(m.remotePort)
+		if err != nil {
+			m.inlineErr = "Remote " + err.Error()
+			return m, nil
+		}
+		// Local port defaults to the remote port
+		if m.localPort == "" {
+			m.localPort = m.remotePort
+		}
+		local, err := parsePort(m.localPort)
+		if err != nil {
+			m.inlineErr = "Local " + err.Error()
+			return m, nil
+		}
+		m.remotePort, m.localPort = strconv.Itoa(remote), strconv.Itoa(local)
+		m.sessionMode = sessionPortForward
+		return m.startSelected()
+	default:
+		if len(s) == 1 && s[0] >= '0' && s[0] <= '9' && len(*field) < 5 {
+			*field += s
+		}
+	}
+	return m, nil
+}
+
+func (m model) portForwardView() string {
+	w := m.contentWidth()
+	content := headerStyle.Render(fit("Port forwarding", w, 2)) + "\n"
+	content += infoStyle.Render(fit("Profile:"+m.selectedProfile+" | Region:"+m.selectedRegion+" | Instance:"+m.selectedInstance, w, 2)) + "\n"
+	fields := []struct {
+		label string
+		value string
+		hint  string
+	}{
+		{"Remote port", m.remotePort, ""},
+		{"Local port", m.localPort, " (defaults to remote port)"},
+	}
+	for i, f := range fields {
+		line := fmt.Sprintf("%s: %s", f.label, f.value)
+		if m.portField == i {
+			content += selectedStyle.Render("> "+line+"_") + "\n"
+		} else {
+			content += itemStyle.Render("  "+line) + quitStyle.Render(f.hint) + "\n"
+		}
+	}
+	if m.inlineErr != "" {
+		content += errorStyle.Render(fit(m.inlineErr, w, 0)) + "\n"
+	}
+	content += quitStyle.Render(fit("tab: switch field • enter: start • ←: back • esc: quit", w, 0))
+	return borderStyle.Render(content)
+}
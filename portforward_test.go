@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for sending a sequence of key strings to the model
+func typeKeys(m model, keys ...tea.KeyMsg) model {
+	for _, k := range keys {
+		updated, _ := m.Update(k)
+		m = updated.(model)
+	}
+	return m
+}
+
+func runeKeys(s string) []tea.KeyMsg {
+	keys := []tea.KeyMsg{}
+	for _, r := range s {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+// Helper function for alt+key presses, which the list actions are bound to
+func altKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}, Alt: true}
+}
+
+// Test port forwarding argument construction
+func TestPortForwardArgs(t *testing.T) {
+	args := portForwardArgs("default", "us-east-1", "i-123", 8080, 80)
+
+	assert.Equal(t, []string{
+		"ssm", "start-session", "--profile", "default", "--region", "us-east-1", "--target", "i-123",
+		"--document-name", "AWS-StartPortForwardingSession",
+		"--parameters", `{"localPortNumber":["8080"],"portNumber":["80"]}`,
+	}, args)
+	assert.Equal(t,
+		`ssm start-session --profile default --region us-east-1 --target i-123 --document-name AWS-StartPortForwardingSession --parameters '{"localPortNumber":["8080"],"portNumber":["80"]}'`,
+		shellJoin(args))
+}
+
+// Test port validation
+func TestParsePort(t *testing.T) {
+	port, err := parsePort("443")
+	assert.NoError(t, err)
+	assert.Equal(t, 443, port)
+
+	for _, bad := range []string{"", "0", "65536", "abc"} {
+		_, err := parsePort(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// Test the port forward prompt flow
+func TestPortForwardFlow(t *testing.T) {
+	m := model{
+		step:              stateInstance,
+		filteredInstances: []Instance{{ID: "i-123", Name: "web-server", State: "running"}},
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+	}
+
+	m = typeKeys(m, altKey('p'))
+	require.Equal(t, statePortForward, m.step)
+	assert.Equal(t, "i-123", m.selectedInstance)
+	assert.Contains(t, m.View(), "Remote port")
+
+	t.Run("local port defaults to remote port", func(t *testing.T) {
+		result := typeKeys(m, runeKeys("5432")...)
+		updated, cmd := result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result = updated.(model)
+
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, sessionPortForward, result.sessionMode)
+		assert.Equal(t, "5432", result.remotePort)
+		assert.Equal(t, "5432", result.localPort)
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("tab switches to the local port", func(t *testing.T) {
+		keys := append(runeKeys("80x"), tea.KeyMsg{Type: tea.KeyTab})
+		keys = append(keys, runeKeys("8080")...)
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyEnter})
+		result := typeKeys(m, keys...)
+
+		assert.Equal(t, stateDone, result.step)
+		assert.Equal(t, "80", result.remotePort)
+		assert.Equal(t, "8080", result.localPort)
+	})
+
+	t.Run("invalid port shows inline error", func(t *testing.T) {
+		result := typeKeys(m, append(runeKeys("99999"), tea.KeyMsg{Type: tea.KeyEnter})...)
+
+		assert.Equal(t, statePortForward, result.step)
+		assert.Contains(t, result.inlineErr, "between 1 and 65535")
+	})
+
+	t.Run("stopped instances cannot be forwarded", func(t *testing.T) {
+		stopped := model{
+			step:              stateInstance,
+			filteredInstances: []Instance{{ID: "i-456", State: "stopped"}},
+		}
+		result := typeKeys(stopped, altKey('p'))
+
+		assert.Equal(t, stateInstance, result.step)
+		assert.Contains(t, result.inlineErr, "stopped")
+	})
+}
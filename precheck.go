@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"goversion/pkg/awsssm"
+)
+
+// Set by -precheck: check that the SSM agent is online before starting a session
+var precheckAgent bool
+
+// agentStatus is the SSM agent state of an instance that can't take a session.
+type agentStatus struct {
+	instance Instance
+	// PingStatus as reported by SSM, or "" when the instance isn't registered
+	pingStatus string
+	lastPing   time.Time
+}
+
+func (s agentStatus) String() string {
+	name := s.instance.Name
+	if name == "" {
+		name = s.instance.ID
+	}
+	if s.pingStatus == "" {
+		return name + ": not registered with SSM"
+	}
+	msg := fmt.Sprintf("%s: SSM agent is %s", name, s.pingStatus)
+	if !s.lastPing.IsZero() {
+		msg += ", last ping " + s.lastPing.UTC().Format("2006-01-02 15:04 MST")
+	}
+	return msg
+}
+
+// getAgentStatuses returns the instances among ids whose SSM agent is not
+// online, in the order of ids.
+func getAgentStatuses(ctx context.Context, profile, region string, instances []Instance) ([]agentStatus, error) {
+	ids := make([]string, len(instances))
+	for i, inst := range instances {
+		ids[i] = inst.ID
+	}
+	out, err := describeAWS(ctx, "ssm", "describe-instance-information", "--profile", profile, "--region", region,
+		"--filters", "Key=InstanceIds,Values="+strings.Join(ids, ","), "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		InstanceInformationList []struct {
+			InstanceId       string    `json:"InstanceId"`
+			PingStatus       string    `json:"PingStatus"`
+			LastPingDateTime time.Time `json:"LastPingDateTime"`
+		} `json:"InstanceInformationList"`
+	}
+	if err := awsssm.DecodeOutput(out, &result); err != nil {
+		return nil, err
+	}
+	offline := []agentStatus{}
+	for _, inst := range instances {
+		status := agentStatus{instance: inst}
+		for _, info := range result.InstanceInformationList {
+			if info.InstanceId == inst.ID {
+				status.pingStatus = info.PingStatus
+				status.lastPing = info.LastPingDateTime
+			}
+		}
+		if status.pingStatus != "Online" {
+			offline = append(offline, status)
+		}
+	}
+	return offline, nil
+}
+
+// precheckCmd checks the SSM agent of every target, one call per region.
+func precheckCmd(ctx context.Context, profile string, targets []Instance) tea.Cmd {
+	byRegion := map[string][]Instance{}
+	for _, inst := range targets {
+		byRegion[inst.Region] = append(byRegion[inst.Region], inst)
+	}
+	regions := make([]string, 0, len(byRegion))
+	for region := range byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		offline := []agentStatus{}
+		for _, region := range regions {
+			statuses, err := getAgentStatuses(ctx, profile, region, byRegion[region])
+			if err != nil {
+				return struct {
+					offline     []agentStatus
+					precheckErr error
+				}{nil, lookupErr(ctx, err, "SSM agent status", loadTimeout)}
+			}
+			offline = append(offline, statuses...)
+		}
+		return struct {
+			offline     []agentStatus
+			precheckErr error
+		}{offline, nil}
+	}
+}
+
+// sessionTargets returns the instances the pending session connects to, each
+// carrying its region.
+func (m model) sessionTargets() []Instance {
+	if m.sessionMode == sessionMulti {
+		return m.targets
+	}
+	inst := m.selectedInstanceInfo()
+	inst.Region = m.selectedRegion
+	return []Instance{inst}
+}
+
+// updatePrecheck handles the agent status of the session targets: all online
+// carries on to the session, otherwise the instance list explains why not.
+func (m model) updatePrecheck(offline []agentStatus, err error) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if isExpiredCredentials(err) {
+		return m.promptReauth(err, precheckCmd(m.lookupContext(), m.selectedProfile, m.sessionTargets())), nil
+	}
+	if err == nil && len(offline) == 0 {
+		return m.proceed()
+	}
+	msg := ""
+	if err != nil {
+		msg = "SSM pre-check failed: " + err.Error()
+	} else {
+		parts := make([]string, len(offline))
+		for i, status := range offline {
+			parts[i] = status.String()
+		}
+		msg = "Not connecting; " + strings.Join(parts, "; ")
+	}
+	m.sessionMode = sessionShell
+	m.targets = nil
+	m = m.backToInstances()
+	m.inlineErr = msg
+	return m, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInstanceInformation = `{"InstanceInformationList": [
+	{"InstanceId": "i-123", "PingStatus": "Online", "LastPingDateTime": "2026-10-17T09:00:00.000000+00:00"},
+	{"InstanceId": "i-456", "PingStatus": "ConnectionLost", "LastPingDateTime": "2026-10-16T22:15:30.000000+00:00"}
+]}`
+
+// Helper function for running the precheck for the instance under the cursor
+func precheckModel(t *testing.T, cursor int) model {
+	instances := []Instance{
+		{ID: "i-123", Name: "web-1", State: "running"},
+		{ID: "i-456", Name: "web-2", State: "running"},
+		{ID: "i-789", Name: "web-3", State: "running"},
+	}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		cursor:            cursor,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+		precheck:          true,
+	}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	result := updatedModel.(model)
+	require.True(t, result.loading)
+	msg := precheckCmd(context.Background(), result.selectedProfile, result.sessionTargets())()
+	updatedModel, _ = result.Update(msg)
+	return updatedModel.(model)
+}
+
+// Test checking the SSM agent before starting a session
+func TestPrecheck(t *testing.T) {
+	var calls [][]string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(testInstanceInformation), nil
+	})
+
+	t.Run("online agent starts the session", func(t *testing.T) {
+		calls = nil
+		result := precheckModel(t, 0)
+		assert.Equal(t, stateDone, result.step)
+		require.Len(t, calls, 1)
+		assert.Equal(t, "ssm describe-instance-information --profile default --region us-east-1 --filters Key=InstanceIds,Values=i-123 --output json", strings.Join(calls[0], " "))
+	})
+
+	t.Run("lost connection warns with the last ping", func(t *testing.T) {
+		result := precheckModel(t, 1)
+		assert.Equal(t, stateInstance, result.step)
+		assert.Equal(t, "", result.selectedInstance)
+		assert.Equal(t, "Not connecting; web-2: SSM agent is ConnectionLost, last ping 2026-10-16 22:15 UTC", result.inlineErr)
+	})
+
+	t.Run("unregistered instance warns", func(t *testing.T) {
+		result := precheckModel(t, 2)
+		assert.Equal(t, stateInstance, result.step)
+		assert.Equal(t, "Not connecting; web-3: not registered with SSM", result.inlineErr)
+	})
+}
+
+// Test that a failing precheck call keeps the user on the instance list
+func TestPrecheckError(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, errors.New("AccessDeniedException")
+	})
+	result := precheckModel(t, 0)
+	assert.Nil(t, result.err)
+	assert.Equal(t, stateInstance, result.step)
+	assert.Equal(t, "SSM pre-check failed: AccessDeniedException", result.inlineErr)
+}
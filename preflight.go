@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// lookPath finds the external tools ssmssh depends on.
+// It is a variable so tests can simulate missing binaries.
+var lookPath = exec.LookPath
+
+// missingToolError reports a required binary that isn't on PATH, with
+// instructions for installing it.
+type missingToolError struct {
+	tool    string
+	purpose string
+	install string
+}
+
+func (e missingToolError) Error() string {
+	return fmt.Sprintf("%s was not found on your PATH; it is needed %s.\nInstall it: %s", e.tool, e.purpose, e.install)
+}
+
+var (
+	awsCLIMissing = missingToolError{
+		tool:    "aws",
+		purpose: "to list profiles' regions and instances",
+		install: "https://docs.aws.amazon.com/cli/latest/userguide/getting-started-install.html",
+	}
+	sessionManagerPluginMissing = missingToolError{
+		tool:    "session-manager-plugin",
+		purpose: "by `aws ssm start-session` to open sessions",
+		install: "https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html (macOS: brew install --cask session-manager-plugin)",
+	}
+)
+
+// preflight checks that the AWS CLI, and when sessions will be started the
+// Session Manager plugin, are installed.
+func preflight(sessions bool) error {
+	if _, err := lookPath("aws"); err != nil {
+		return awsCLIMissing
+	}
+	if sessions {
+		if _, err := lookPath("session-manager-plugin"); err != nil {
+			return sessionManagerPluginMissing
+		}
+	}
+	return nil
+}
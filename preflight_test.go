@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Helper function for simulating which binaries are installed
+func stubLookPath(t *testing.T, installed ...string) {
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/local/bin/" + file, nil
+			}
+		}
+		return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+	}
+	t.Cleanup(func() { lookPath = original })
+}
+
+// Test detection of missing AWS CLI and Session Manager plugin
+func TestPreflight(t *testing.T) {
+	t.Run("everything installed", func(t *testing.T) {
+		stubLookPath(t, "aws", "session-manager-plugin")
+		assert.NoError(t, preflight(true))
+	})
+
+	t.Run("missing aws cli", func(t *testing.T) {
+		stubLookPath(t, "session-manager-plugin")
+		err := preflight(false)
+		assert.Equal(t, awsCLIMissing, err)
+		assert.Contains(t, err.Error(), "aws was not found on your PATH")
+		assert.Contains(t, err.Error(), "getting-started-install")
+	})
+
+	t.Run("missing plugin only matters for sessions", func(t *testing.T) {
+		stubLookPath(t, "aws")
+		assert.NoError(t, preflight(false))
+		err := preflight(true)
+		var missing missingToolError
+		assert.True(t, errors.As(err, &missing))
+		assert.Equal(t, "session-manager-plugin", missing.tool)
+	})
+
+	t.Run("shown in the error view", func(t *testing.T) {
+		stubLookPath(t)
+		view := model{err: preflight(true)}.View()
+		assert.Contains(t, view, "aws was not found")
+		assert.Contains(t, view, "Install it:")
+	})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showRightPane reports whether the instance screen has a right-hand pane:
+// the details preview unless hidden, or the tag matrix when toggled on.
+func (m model) showRightPane() bool {
+	return !m.config.HidePreview || m.showTagMatrix
+}
+
+// togglePreview shows or hides the details preview and remembers the choice
+// in the config file. Showing it again loads the highlighted instance.
+func (m model) togglePreview() (tea.Model, tea.Cmd) {
+	m.config.HidePreview = !m.config.HidePreview
+	save := savePreviewCmd(m.config.HidePreview)
+	if m.config.HidePreview || len(m.filteredInstances) == 0 {
+		m = m.cancelPreviewLoad()
+		m.previewDetails = nil
+		m.previewErr = nil
+		m.previewLoading = false
+		m.previewInstanceId = ""
+		return m, save
+	}
+	m, cmd := m.preview()
+	return m, tea.Batch(save, cmd)
+}
+
+// savePreviewCmd writes the preview preference in the background. Failing to
+// save only means the choice isn't remembered, so errors are dropped.
+func savePreviewCmd(hidden bool) tea.Cmd {
+	return func() tea.Msg {
+		_ = saveConfigValue("hide_preview", hidden)
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test hiding and showing the details preview
+func TestTogglePreview(t *testing.T) {
+	setTempHome(t)
+	instances := []Instance{
+		{ID: "i-123", Name: "web-1", State: "running"},
+		{ID: "i-456", Name: "web-2", State: "running"},
+	}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		previewDetails:    &InstanceDetails{Tags: []Tag{{Key: "Team", Value: "platform"}}},
+		width:             120,
+		height:            30,
+	}
+	require.Contains(t, m.View(), "Instance Tags")
+
+	updatedModel, cmd := m.Update(altKey('t'))
+	hidden := updatedModel.(model)
+	require.NotNil(t, cmd)
+	cmd()
+	assert.True(t, hidden.config.HidePreview)
+	assert.NotContains(t, hidden.View(), "Instance Tags")
+	lw, _ := hidden.paneWidths()
+	assert.Equal(t, hidden.contentWidth(), lw)
+
+	// Moving the cursor fetches nothing while the pane is hidden
+	updatedModel, cmd = hidden.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Nil(t, cmd)
+	assert.False(t, updatedModel.(model).previewLoading)
+
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.True(t, cfg.HidePreview, "the choice is saved")
+
+	updatedModel, _ = updatedModel.(model).Update(altKey('t'))
+	shown := updatedModel.(model)
+	assert.False(t, shown.config.HidePreview)
+	assert.True(t, shown.previewLoading)
+	assert.Equal(t, "i-456", shown.previewInstanceId)
+}
+
+// Test that scrolling past rows only loads the preview of the row the cursor
+// stops on
+func TestPreviewDebounce(t *testing.T) {
+	var mu sync.Mutex
+	described := []string{}
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		mu.Lock()
+		described = append(described, argValue(args, "--instance-ids"))
+		mu.Unlock()
+		return []byte(`{"Reservations": []}`), nil
+	})
+	instances := []Instance{{ID: "i-1"}, {ID: "i-2"}, {ID: "i-3"}}
+	var m tea.Model = model{step: stateInstance, instances: instances, filteredInstances: instances}
+	cmds := []tea.Cmd{}
+	for i := 0; i < 2; i++ {
+		var cmd tea.Cmd
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		require.NotNil(t, cmd)
+		cmds = append(cmds, cmd)
+	}
+
+	msgs := make([]tea.Msg, len(cmds))
+	var wg sync.WaitGroup
+	for i, cmd := range cmds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msgs[i] = cmd()
+		}()
+	}
+	wg.Wait()
+	assert.Nil(t, msgs[0], "the skipped row's preview is dropped")
+	assert.NotNil(t, msgs[1])
+	assert.Equal(t, []string{"i-3"}, described)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// previewData is what a preview_template is executed against: the fields of
+// the highlighted instance and its details, with the tags keyed by name so
+// that {{.Tags.Owner}} works. Missing tags render as "".
+type previewData struct {
+	ID               string
+	Name             string
+	State            string
+	Region           string
+	InstanceType     string
+	AvailabilityZone string
+	PrivateIP        string
+	PublicIP         string
+	LaunchTime       time.Time
+	Tags             map[string]string
+}
+
+// parsePreviewTemplate parses a preview_template from the config file.
+func parsePreviewTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("preview_template").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("preview_template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderPreviewTemplate executes text for inst, living in region, and returns
+// the output as lines. Blank lines at the end are dropped.
+func renderPreviewTemplate(text string, inst Instance, region string, details InstanceDetails) ([]string, error) {
+	tmpl, err := parsePreviewTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	data := previewData{
+		ID:               inst.ID,
+		Name:             inst.Name,
+		State:            inst.State,
+		Region:           region,
+		InstanceType:     details.InstanceType,
+		AvailabilityZone: details.AvailabilityZone,
+		PrivateIP:        details.PrivateIP,
+		PublicIP:         details.PublicIP,
+		LaunchTime:       details.LaunchTime,
+		Tags:             map[string]string{},
+	}
+	// The listing's tags stand in when the details lookup returned none
+	for _, tag := range inst.Tags {
+		data.Tags[tag.Key] = tag.Value
+	}
+	for _, tag := range details.Tags {
+		data.Tags[tag.Key] = tag.Value
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("preview_template: %w", err)
+	}
+	return strings.Split(strings.TrimRight(out.String(), " \t\n"), "\n"), nil
+}
+
+// previewInstance returns the instance the preview pane shows.
+func (m model) previewInstance() Instance {
+	for _, inst := range m.filteredInstances {
+		if inst.ID == m.previewInstanceId {
+			return inst
+		}
+	}
+	return Instance{ID: m.previewInstanceId}
+}
+
+// templatePreviewLines renders the preview pane from the configured template,
+// each line fitted to width w.
+func (m model) templatePreviewLines(w int) []string {
+	inst := m.previewInstance()
+	lines, err := renderPreviewTemplate(m.config.PreviewTemplate, inst, m.instanceRegion(inst), *m.previewDetails)
+	if err != nil {
+		return []string{errorStyle.Render(fit(err.Error(), w, 2))}
+	}
+	out := []string{headerStyle.Render(fit("Instance Details", w, 2))}
+	for _, line := range lines {
+		out = append(out, infoStyle.Render(fit(line, w, 2)))
+	}
+	return out
+}
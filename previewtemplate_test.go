@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test rendering the preview pane from a template
+func TestRenderPreviewTemplate(t *testing.T) {
+	inst := Instance{ID: "i-123", Name: "web-1", State: "running", Tags: []Tag{{Key: "Team", Value: "web"}}}
+	details := InstanceDetails{InstanceType: "t3.micro", Tags: []Tag{{Key: "Owner", Value: "alice"}, {Key: "Cost Center", Value: "42"}}}
+
+	lines, err := renderPreviewTemplate("Owner: {{.Tags.Owner}}\nCost: {{index .Tags \"Cost Center\"}}\n{{.State}} {{.InstanceType}} in {{.Region}}\n", inst, "us-east-1", details)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Owner: alice", "Cost: 42", "running t3.micro in us-east-1"}, lines)
+
+	// Missing tags render as nothing rather than "<no value>"
+	lines, err = renderPreviewTemplate("Env: {{.Tags.Environment}}|{{.Tags.Team}}", inst, "us-east-1", details)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Env: |web"}, lines)
+
+	_, err = renderPreviewTemplate("{{.Nope}}", inst, "us-east-1", details)
+	assert.ErrorContains(t, err, "preview_template")
+}
+
+// Test that the configured template replaces the built-in preview
+func TestPreviewTemplateView(t *testing.T) {
+	instances := []Instance{{ID: "i-123", Name: "web-1", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		previewInstanceId: "i-123",
+		previewDetails:    &InstanceDetails{Tags: []Tag{{Key: "Owner", Value: "alice"}, {Key: "Team", Value: "web"}}},
+		config:            Config{PreviewTemplate: "Owner={{.Tags.Owner}}"},
+		width:             120,
+		height:            30,
+	}
+	view := m.View()
+	assert.Contains(t, view, "Owner=alice")
+	assert.NotContains(t, view, "Team: web")
+
+	m.config.PreviewTemplate = ""
+	assert.Contains(t, m.View(), "Team: web")
+}
+
+// Test that an unparseable template is reported when the config is loaded
+func TestLoadConfigPreviewTemplate(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "preview_template: \"{{.Tags.Owner\"\n")
+	_, err := loadConfig()
+	assert.ErrorContains(t, err, "preview_template")
+}
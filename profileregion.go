@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Set by -profile-region: go straight to the instances of a profile's
+// configured region instead of offering it on the region step
+var useProfileRegion bool
+
+// Last entry of the region step offered for a profile with a region; it
+// loads every region
+const otherRegionsItem = "Other regions..."
+
+// profileRegion returns the region set for profile in the AWS CLI config
+// file, or "" when it has none.
+func profileRegion(profile string) string {
+	if demoMode {
+		return ""
+	}
+	section, ok := awsProfileSection(profile)
+	if !ok {
+		return ""
+	}
+	return section.Key("region").String()
+}
+
+// isRegionOffer reports whether regions is the offer of a profile's region
+// rather than a real region list.
+func isRegionOffer(regions []string) bool {
+	return slices.Contains(regions, otherRegionsItem)
+}
+
+// loadRegions fetches the region list of the selected profile, or uses the
+// configured regions when there are any.
+func (m model) loadRegions() (model, tea.Cmd) {
+	m.loading = true
+	if len(m.config.Regions) > 0 {
+		// Configured regions skip the describe-regions round trip
+		m.loadingMsg = "Loading configured regions..."
+		return m, configuredRegionsCmd(m.config.Regions)
+	}
+	m.loadingMsg = "Fetching regions for " + m.selectedProfile + "..."
+	return m, regionsCmd(m.lookupContext(), m.selectedProfile, false)
+}
+
+// offerProfileRegion handles a profile with a region in the AWS CLI config.
+// The region step lists only that region and an entry for the others, so no
+// describe-regions call is needed; with -profile-region (or the config's
+// use_profile_region) the region is used straight away, and going back
+// shows the same short list.
+func (m model) offerProfileRegion(region string) (model, tea.Cmd) {
+	offer := []string{region, otherRegionsItem}
+	m.loading = true
+	if !m.useProfileRegion {
+		m.loadingMsg = "Loading the region of " + m.selectedProfile + "..."
+		return m, configuredRegionsCmd(offer)
+	}
+	m.regions = offer
+	m.filteredRegions = offer
+	m.selectedRegion = region
+	m.loadingMsg = "Fetching instances in " + region + "..."
+	return m, instancesCmd(m.lookupContext(), m.selectedProfile, region, false)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegionConfig = `[default]
+output = json
+
+[profile prod]
+region = eu-west-1
+`
+
+// Test reading a profile's region from the AWS CLI config
+func TestProfileRegion(t *testing.T) {
+	writeAWSConfig(t, testRegionConfig)
+	assert.Equal(t, "eu-west-1", profileRegion("prod"))
+	assert.Equal(t, "", profileRegion("default"))
+	assert.Equal(t, "", profileRegion("missing"))
+}
+
+// Test that a profile's region is offered without calling describe-regions
+func TestProfileRegionOffer(t *testing.T) {
+	writeAWSConfig(t, testRegionConfig)
+	var calls []string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args[:2], " "))
+		if args[1] == "describe-regions" {
+			return []byte(`{"Regions": [{"RegionName": "us-east-1"}, {"RegionName": "eu-west-1"}]}`), nil
+		}
+		return []byte(`{"Reservations": []}`), nil
+	})
+	m := model{step: stateProfile, filteredProfiles: []string{"prod"}, regionCounts: true}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result := updatedModel.(model)
+	assert.Equal(t, stateRegion, result.step)
+	assert.Equal(t, []string{"eu-west-1", otherRegionsItem}, result.regions)
+	assert.Nil(t, result.instanceCounts, "nothing is counted for the offer")
+	assert.Empty(t, calls)
+
+	// The other entry loads every region
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updatedModel, cmd = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	assert.Equal(t, []string{"ec2 describe-regions"}, calls)
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, updatedModel.(model).regions)
+}
+
+// Test skipping the region step with -profile-region
+func TestUseProfileRegion(t *testing.T) {
+	writeAWSConfig(t, testRegionConfig)
+	var calls []string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return []byte(`{"Reservations": [{"Instances": [{"InstanceId": "i-123", "State": {"Name": "running"}}]}]}`), nil
+	})
+	m := model{step: stateProfile, filteredProfiles: []string{"prod"}, useProfileRegion: true}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result := updatedModel.(model)
+	assert.Equal(t, stateInstance, result.step)
+	assert.Equal(t, "eu-west-1", result.selectedRegion)
+	require.Len(t, calls, 1)
+	assert.Contains(t, calls[0], "ec2 describe-instances --profile prod --region eu-west-1")
+
+	// Going back lands on the short region list
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	result = updatedModel.(model)
+	assert.Equal(t, stateRegion, result.step)
+	assert.Equal(t, []string{"eu-west-1", otherRegionsItem}, result.filteredRegions)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Longest list that alt+1 to alt+9 pick rows from
+const quickSelectMax = 9
+
+// quickSelectRow returns the row alt+1 to alt+9 stand for. Plain digits are
+// left to the filter, where they are common in names and IP addresses.
+func quickSelectRow(s string) (int, bool) {
+	digit, ok := strings.CutPrefix(s, "alt+")
+	if !ok || len(digit) != 1 || digit[0] < '1' || digit[0] > '9' {
+		return 0, false
+	}
+	return int(digit[0] - '1'), true
+}
+
+// quickSelect moves the cursor to row once the list has been narrowed to a
+// handful of items; on the highlighted row it selects it, like a click.
+func (m model) quickSelect(row int) (tea.Model, tea.Cmd) {
+	n := m.listLen()
+	if n > quickSelectMax || row >= n {
+		return m, nil
+	}
+	if row == m.cursor {
+		return m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	return m.jumpTo(row)
+}
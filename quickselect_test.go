@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for pressing alt with a digit
+func altDigit(d rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{d}, Alt: true}
+}
+
+// Test which keys pick rows
+func TestQuickSelectRow(t *testing.T) {
+	row, ok := quickSelectRow("alt+1")
+	assert.True(t, ok)
+	assert.Equal(t, 0, row)
+	row, ok = quickSelectRow("alt+9")
+	assert.True(t, ok)
+	assert.Equal(t, 8, row)
+	for _, s := range []string{"1", "alt+0", "alt+a", "alt+10", "ctrl+1"} {
+		_, ok := quickSelectRow(s)
+		assert.False(t, ok, s)
+	}
+}
+
+// Test jumping to a row and selecting it with alt+digit
+func TestQuickSelect(t *testing.T) {
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1"}
+	m := model{step: stateRegion, selectedProfile: "default", regions: regions, filteredRegions: regions}
+
+	updatedModel, cmd := m.Update(altDigit('2'))
+	result := updatedModel.(model)
+	assert.Equal(t, 1, result.cursor)
+	assert.Nil(t, cmd)
+
+	// Rows past the end of the list are ignored
+	updatedModel, _ = result.Update(altDigit('5'))
+	assert.Equal(t, 1, updatedModel.(model).cursor)
+
+	// The same digit again selects the row
+	updatedModel, cmd = result.Update(altDigit('2'))
+	result = updatedModel.(model)
+	assert.Equal(t, "us-west-2", result.selectedRegion)
+	assert.True(t, result.loading)
+	require.NotNil(t, cmd)
+
+	t.Run("plain digits filter", func(t *testing.T) {
+		updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+		result := updatedModel.(model)
+		assert.Equal(t, "2", result.filter)
+		assert.Equal(t, 0, result.cursor)
+	})
+
+	t.Run("long lists are ignored", func(t *testing.T) {
+		long := make([]string, quickSelectMax+1)
+		for i := range long {
+			long[i] = "region-" + string(rune('a'+i))
+		}
+		m := model{step: stateRegion, regions: long, filteredRegions: long}
+		updatedModel, _ := m.Update(altDigit('3'))
+		assert.Equal(t, 0, updatedModel.(model).cursor)
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fragments of AWS CLI errors that mean the credentials or SSO session have
+// expired and a fresh `aws sso login` will fix things
+var expiredCredentialMarkers = []string{
+	"expiredtoken",
+	"token has expired",
+	"token is expired",
+	"sso session associated with this profile has expired",
+	"error loading sso token",
+	"error when retrieving token from sso",
+	"the sso session has expired",
+}
+
+// isExpiredCredentials classifies err as an expired token or SSO session.
+func isExpiredCredentials(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range expiredCredentialMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// promptReauth switches to the re-login prompt, remembering the step to
+// return to and the command to retry once logged in.
+func (m model) promptReauth(err error, retry tea.Cmd) model {
+	m.reauthFrom = m.step
+	m.reauthErr = err
+	m.retry = retry
+	m.step = stateReauth
+	return m
+}
+
+// ssoLoginCmd suspends the TUI and runs `aws sso login` in the terminal.
+func ssoLoginCmd(profile string) tea.Cmd {
+	args := []string{"sso", "login", "--profile", profile}
+	cmd := execCommand(discovery, "aws", args...)
+	start := time.Now()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		logCommand("aws", args, start, err, "")
+		return struct {
+			loginErr error
+		}{err}
+	})
+}
+
+// updateReauth handles key input on the re-login prompt.
+func (m model) updateReauth(s string) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	switch {
+	case keys.matches(actionSelect, s), s == "l":
+		return m, ssoLoginCmd(m.selectedProfile)
+	case keys.matches(actionBack, s), s == "n":
+		m.step = m.reauthFrom
+		m.reauthErr = nil
+		m.retry = nil
+	}
+	return m, nil
+}
+
+func (m model) reauthView() string {
+	w := m.contentWidth()
+	wrap := errorWrapWidth
+	if w > 0 {
+		wrap = min(wrap, w)
+	}
+	content := headerStyle.Render(fit("Credentials expired", w, 2)) + "\n"
+	content += infoStyle.Render(fit("Profile:"+m.selectedProfile, w, 2)) + "\n"
+	if m.reauthErr != nil {
+		content += errorStyle.Copy().Width(wrap).Render(m.reauthErr.Error()) + "\n"
+	}
+	prompt := "Press enter to run `aws sso login --profile " + m.selectedProfile + "` and retry."
+	if lipgloss.Width(prompt)+2 > wrap {
+		content += infoStyle.Copy().Width(wrap).Render(prompt) + "\n"
+	} else {
+		content += infoStyle.Render(prompt) + "\n"
+	}
+	content += quitStyle.Render(fit("enter/l: log in and retry • ←/n: back • esc: quit", w, 0))
+	return borderStyle.Render(content)
+}
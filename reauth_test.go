@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test classification of expired credential errors
+func TestIsExpiredCredentials(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New("An error occurred (ExpiredToken) when calling the DescribeRegions operation"), true},
+		{errors.New("Error when retrieving token from sso: Token has expired and refresh failed"), true},
+		{errors.New("The SSO session associated with this profile has expired or is otherwise invalid"), true},
+		{errors.New("An error occurred (UnauthorizedOperation) when calling the DescribeInstances operation"), false},
+		{errors.New("Could not connect to the endpoint URL"), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isExpiredCredentials(tt.err), "%v", tt.err)
+	}
+}
+
+// Test the re-login prompt flow
+func TestReauthFlow(t *testing.T) {
+	expired := errors.New("An error occurred (ExpiredToken) when calling the DescribeRegions operation")
+	m := model{step: stateProfile, loading: true, selectedProfile: "production"}
+
+	updatedModel, _ := m.Update(struct {
+		regions []string
+		err     error
+	}{nil, expired})
+	result := updatedModel.(model)
+
+	assert.Nil(t, result.err)
+	assert.Equal(t, stateReauth, result.step)
+	assert.Equal(t, stateProfile, result.reauthFrom)
+	assert.NotNil(t, result.retry)
+	assert.Contains(t, result.View(), "aws sso login --profile production")
+
+	t.Run("enter runs sso login", func(t *testing.T) {
+		_, cmd := result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("successful login retries", func(t *testing.T) {
+		updatedModel, cmd := result.Update(struct {
+			loginErr error
+		}{nil})
+		retried := updatedModel.(model)
+
+		assert.Equal(t, stateProfile, retried.step)
+		assert.True(t, retried.loading)
+		assert.Nil(t, retried.retry)
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("failed login shows error", func(t *testing.T) {
+		updatedModel, _ := result.Update(struct {
+			loginErr error
+		}{assert.AnError})
+		assert.Error(t, updatedModel.(model).err)
+	})
+
+	t.Run("n goes back without retrying", func(t *testing.T) {
+		updatedModel, _ := result.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		back := updatedModel.(model)
+
+		assert.Equal(t, stateProfile, back.step)
+		assert.Nil(t, back.retry)
+	})
+
+	t.Run("other errors are shown directly", func(t *testing.T) {
+		updatedModel, _ := m.Update(struct {
+			regions []string
+			err     error
+		}{nil, errors.New("UnauthorizedOperation")})
+		assert.Error(t, updatedModel.(model).err)
+		assert.Equal(t, stateProfile, updatedModel.(model).step)
+	})
+}
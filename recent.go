@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Most instances remembered in the recently used list
+const recentSize = 20
+
+// Set by -recent: start on the recently used instances
+var startOnRecent bool
+
+// Opens the recently used instances from the list screens, and closes them
+const recentKey = "ctrl+r"
+
+// recentInstance is a target a session was started with. Unlike favorites
+// the list is kept automatically, across every profile and region.
+type recentInstance struct {
+	Profile    string `json:"profile"`
+	Region     string `json:"region"`
+	InstanceID string `json:"instance_id"`
+	Name       string `json:"name,omitempty"`
+}
+
+func (r recentInstance) Display() string {
+	label := r.InstanceID
+	if r.Name != "" {
+		label = r.Name + " (" + r.InstanceID + ")"
+	}
+	return label + "  " + r.Profile + "/" + r.Region
+}
+
+func recentPath() string {
+	return filepath.Join(configDir(), "recent.json")
+}
+
+// loadRecent returns the recently used instances, most recent first. A
+// missing or corrupt file yields none.
+func loadRecent() []recentInstance {
+	if noRemember {
+		return nil
+	}
+	data, err := os.ReadFile(recentPath())
+	if err != nil {
+		return nil
+	}
+	var recent []recentInstance
+	if err := json.Unmarshal(data, &recent); err != nil {
+		return nil
+	}
+	return recent
+}
+
+func saveRecent(recent []recentInstance) error {
+	if noRemember {
+		return nil
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(recent)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recentPath(), data, 0644)
+}
+
+// rememberRecent moves the targets of a session to the front of recent,
+// dropping the oldest entries beyond recentSize.
+func rememberRecent(recent []recentInstance, profile string, targets []Instance) []recentInstance {
+	updated := []recentInstance{}
+	for _, inst := range targets {
+		updated = append(updated, recentInstance{Profile: profile, Region: inst.Region, InstanceID: inst.ID, Name: inst.Name})
+	}
+	for _, r := range recent {
+		if len(updated) >= recentSize {
+			break
+		}
+		if !containsRecent(updated, r) {
+			updated = append(updated, r)
+		}
+	}
+	return updated
+}
+
+// containsRecent reports whether recent already has r's instance.
+func containsRecent(recent []recentInstance, r recentInstance) bool {
+	for _, e := range recent {
+		if e.Profile == r.Profile && e.Region == r.Region && e.InstanceID == r.InstanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// recentMatch is a recently used instance checked against its region's
+// current instances.
+type recentMatch struct {
+	recentInstance
+	// The live instance, once found
+	inst Instance
+	// Every instance of the region, for the instance list behind the session
+	instances []Instance
+	// The region couldn't be listed; the entry is kept, unchecked
+	err error
+}
+
+// resolveRecent looks up every recently used instance, listing each profile
+// and region once, from the cache when fresh. Instances that no longer exist
+// or were terminated are dropped; entries whose region fails to list are kept
+// with the error, so an expired login doesn't forget them.
+func resolveRecent(ctx context.Context, recent []recentInstance) []recentMatch {
+	type listing struct {
+		instances []Instance
+		err       error
+	}
+	listings := map[recentInstance]*listing{}
+	var wg sync.WaitGroup
+	for _, r := range recent {
+		key := recentInstance{Profile: r.Profile, Region: r.Region}
+		if listings[key] != nil {
+			continue
+		}
+		l := &listing{}
+		listings[key] = l
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.instances, l.err = getInstances(ctx, key.Profile, key.Region, false)
+			l.err = lookupErr(ctx, l.err, "instances", loadTimeout)
+		}()
+	}
+	wg.Wait()
+	matches := []recentMatch{}
+	for _, r := range recent {
+		l := listings[recentInstance{Profile: r.Profile, Region: r.Region}]
+		if l.err != nil {
+			matches = append(matches, recentMatch{recentInstance: r, err: l.err})
+			continue
+		}
+		for _, inst := range l.instances {
+			if inst.ID == r.InstanceID && inst.State != "terminated" {
+				matches = append(matches, recentMatch{recentInstance: r, inst: inst, instances: l.instances})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// resolveRecentCmd checks the recently used instances in the background.
+func resolveRecentCmd(ctx context.Context, recent []recentInstance) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		return struct {
+			recentMatches []recentMatch
+		}{resolveRecent(ctx, recent)}
+	}
+}
+
+// saveRecentCmd writes the pruned list in the background; failing to save
+// only means the gone instances are checked again next time.
+func saveRecentCmd(recent []recentInstance) tea.Cmd {
+	return func() tea.Msg {
+		_ = saveRecent(recent)
+		return nil
+	}
+}
+
+// showRecent switches to the recently used instances, checking them first
+// when there are any. The current screen is remembered for going back.
+func (m model) showRecent() (model, tea.Cmd) {
+	recent := loadRecent()
+	m = m.leaveScreen()
+	m.recentFrom, m.recentFromCursor = m.step, m.cursor
+	m.step = stateRecent
+	m.cursor = 0
+	m.recent = nil
+	if len(recent) == 0 {
+		return m, nil
+	}
+	m.loading = true
+	m.loadingMsg = fmt.Sprintf("Checking %d recently used instances...", len(recent))
+	return m, resolveRecentCmd(m.lookupContext(), recent)
+}
+
+// updateRecentMatches shows the recently used instances that still exist,
+// forgetting the rest.
+func (m model) updateRecentMatches(matches []recentMatch) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.recent = matches
+	m.cursor = 0
+	kept := make([]recentInstance, len(matches))
+	for i, match := range matches {
+		kept[i] = match.recentInstance
+	}
+	return m, saveRecentCmd(kept)
+}
+
+// updateRecent handles key input on the recently used instances screen.
+func (m model) updateRecent(s string) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	switch {
+	case keys.matches(actionUp, s):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case keys.matches(actionDown, s):
+		if m.cursor < len(m.recent)-1 {
+			m.cursor++
+		}
+	case keys.matches(actionBack, s), s == recentKey:
+		m = m.leaveScreen()
+		m.step, m.cursor = m.recentFrom, m.recentFromCursor
+		switch {
+		case m.step == stateProfile:
+			m.filteredProfiles = m.profileList()
+		case m.step == stateInstance && len(m.filteredInstances) > 0:
+			return m.preview()
+		}
+	case keys.matches(actionSelect, s):
+		if len(m.recent) == 0 {
+			return m, nil
+		}
+		return m.selectRecent(m.recent[m.cursor])
+	}
+	return m, nil
+}
+
+// selectRecent connects to a checked recently used instance. The instance
+// list of its region stays behind it, so going back from the session prompts
+// lands there.
+func (m model) selectRecent(match recentMatch) (tea.Model, tea.Cmd) {
+	if match.err != nil {
+		m.inlineErr = "Couldn't check this instance: " + match.err.Error()
+		return m, nil
+	}
+	if !match.inst.Connectable() {
+		m.inlineErr = fmt.Sprintf("Instance is %s; only running instances can be connected to", match.inst.State)
+		return m, nil
+	}
+	m.selectedProfile, m.selectedRegion = match.Profile, match.Region
+	m.instances = sortInstances(match.instances, m.instanceSort)
+	m.filteredInstances = m.instances
+	m.filter = ""
+	m.cursor = max(indexOfInstance(m.instances, match.inst.ID), 0)
+	m = m.selectInstance(match.inst)
+	if sshConfigOutput {
+		m.sessionMode = sessionSSHProxy
+		m.step = stateDone
+		return m, tea.Quit
+	}
+	return m.startSelected()
+}
+
+// indexOfInstance returns the position of the instance with id, or -1.
+func indexOfInstance(instances []Instance, id string) int {
+	for i, inst := range instances {
+		if inst.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m model) recentView() string {
+	w := m.contentWidth()
+	content := headerStyle.Render(fit(fmt.Sprintf("Recently used (%d)", len(m.recent)), w, 2)) + "\n"
+	start, end := windowBounds(m.cursor, len(m.recent), m.windowSize())
+	for i := start; i < end; i++ {
+		match := m.recent[i]
+		label := match.Display()
+		switch {
+		case match.err != nil:
+			label += " [unchecked]"
+		case match.inst.State != "":
+			label += " [" + match.inst.State + "]"
+		}
+		display := fit(label, w, 4)
+		if m.cursor == i {
+			content += selectedStyle.Render("> "+display) + "\n"
+		} else {
+			content += itemStyle.Render("  "+display) + "\n"
+		}
+	}
+	if len(m.recent) == 0 {
+		content += mutedStyle.Render(fit("No recently used instances yet — they are added when a session starts", w, 2)) + "\n"
+	}
+	if m.inlineErr != "" {
+		content += errorStyle.Render(fit(m.inlineErr, w, 0)) + "\n"
+	}
+	content += quitStyle.Render(fit("enter: connect • ←/ctrl+r: back • ?: help • esc: quit", w, 0))
+	return borderStyle.Render(content)
+}
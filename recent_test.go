@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for stubbing describe-instances per region
+func stubRegionInstances(t *testing.T, byRegion map[string]string) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		region := args[slices.Index(args, "--region")+1]
+		out, ok := byRegion[region]
+		if !ok {
+			return nil, errors.New("An error occurred (ExpiredToken) when calling the DescribeInstances operation")
+		}
+		return []byte(out), nil
+	})
+}
+
+// Test that sessions move their targets to the front of a bounded list
+func TestRememberRecent(t *testing.T) {
+	web := Instance{ID: "i-1", Name: "web", Region: "us-east-1"}
+	db := Instance{ID: "i-2", Name: "db", Region: "eu-west-1"}
+
+	recent := rememberRecent(nil, "prod", []Instance{web})
+	recent = rememberRecent(recent, "prod", []Instance{db})
+	recent = rememberRecent(recent, "prod", []Instance{web})
+	assert.Equal(t, []recentInstance{
+		{Profile: "prod", Region: "us-east-1", InstanceID: "i-1", Name: "web"},
+		{Profile: "prod", Region: "eu-west-1", InstanceID: "i-2", Name: "db"},
+	}, recent)
+
+	// The same instance ID in another profile is another entry
+	recent = rememberRecent(recent, "dev", []Instance{web})
+	assert.Len(t, recent, 3)
+
+	for i := range recentSize + 5 {
+		recent = rememberRecent(recent, "prod", []Instance{{ID: fmt.Sprintf("i-%d", 100+i), Region: "us-east-1"}})
+	}
+	assert.Len(t, recent, recentSize)
+	assert.Equal(t, "i-124", recent[0].InstanceID)
+}
+
+// Test persisting the list, and that -no-remember neither reads nor writes it
+func TestRecentStore(t *testing.T) {
+	setTempHome(t)
+	assert.Empty(t, loadRecent())
+
+	recent := []recentInstance{{Profile: "prod", Region: "us-east-1", InstanceID: "i-1", Name: "web"}}
+	require.NoError(t, saveRecent(recent))
+	assert.Equal(t, recent, loadRecent())
+
+	noRemember = true
+	t.Cleanup(func() { noRemember = false })
+	assert.Empty(t, loadRecent())
+	require.NoError(t, saveRecent(nil))
+	noRemember = false
+	assert.Equal(t, recent, loadRecent())
+}
+
+// Test checking recently used instances against their regions
+func TestResolveRecent(t *testing.T) {
+	setTempHome(t)
+	stubRegionInstances(t, map[string]string{
+		"us-east-1": `{"Reservations":[{"Instances":[
+			{"InstanceId":"i-1","State":{"Name":"running"},"Tags":[{"Key":"Name","Value":"web"}]},
+			{"InstanceId":"i-2","State":{"Name":"terminated"}}
+		]}]}`,
+	})
+
+	matches := resolveRecent(context.Background(), []recentInstance{
+		{Profile: "prod", Region: "us-east-1", InstanceID: "i-1", Name: "web"},
+		{Profile: "prod", Region: "us-east-1", InstanceID: "i-2"},
+		{Profile: "prod", Region: "us-east-1", InstanceID: "i-gone"},
+		{Profile: "prod", Region: "eu-west-1", InstanceID: "i-3"},
+	})
+	require.Len(t, matches, 2)
+	assert.Equal(t, "i-1", matches[0].inst.ID)
+	// The terminated instance isn't listed
+	assert.Len(t, matches[0].instances, 1)
+	assert.NoError(t, matches[0].err)
+	// A region that fails to list keeps its entries
+	assert.Equal(t, "i-3", matches[1].InstanceID)
+	assert.ErrorContains(t, matches[1].err, "ExpiredToken")
+}
+
+// Test the recently used screen from opening it to connecting
+func TestRecentScreen(t *testing.T) {
+	setTempHome(t)
+	require.NoError(t, saveRecent([]recentInstance{
+		{Profile: "prod", Region: "us-east-1", InstanceID: "i-gone"},
+		{Profile: "prod", Region: "us-east-1", InstanceID: "i-1", Name: "web"},
+	}))
+	stubRegionInstances(t, map[string]string{
+		"us-east-1": `{"Reservations":[{"Instances":[{"InstanceId":"i-1","State":{"Name":"running"},"Tags":[{"Key":"Name","Value":"web"}]}]}]}`,
+	})
+
+	profiles := []string{"dev", "prod"}
+	m := model{step: stateProfile, profiles: profiles, filteredProfiles: profiles, cursor: 1}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	result := updatedModel.(model)
+	assert.Equal(t, stateRecent, result.step)
+	assert.True(t, result.loading)
+	require.NotNil(t, cmd)
+
+	updatedModel, cmd = result.Update(cmd())
+	result = updatedModel.(model)
+	require.Len(t, result.recent, 1)
+	assert.Contains(t, result.View(), "web (i-1)  prod/us-east-1 [running]")
+	// The instance that is gone is forgotten
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Len(t, loadRecent(), 1)
+
+	t.Run("back returns to the screen it was opened from", func(t *testing.T) {
+		updatedModel, _ := result.Update(tea.KeyMsg{Type: tea.KeyLeft})
+		back := updatedModel.(model)
+		assert.Equal(t, stateProfile, back.step)
+		assert.Equal(t, 1, back.cursor)
+	})
+
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result = updatedModel.(model)
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, "prod", result.selectedProfile)
+	assert.Equal(t, "us-east-1", result.selectedRegion)
+	assert.Equal(t, "i-1", result.selectedInstance)
+}
+
+// Test the screen with nothing remembered yet
+func TestRecentScreenEmpty(t *testing.T) {
+	setTempHome(t)
+	m := model{step: stateInstance}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	result := updatedModel.(model)
+	assert.Nil(t, cmd)
+	assert.Equal(t, stateRecent, result.step)
+	assert.False(t, result.loading)
+	assert.Contains(t, result.View(), "No recently used instances yet")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Set by -reconnect and -reconnect-attempts: start a shell or port forwarding
+// session again when it ends abnormally, e.g. after a network blip
+var (
+	reconnect         bool
+	reconnectAttempts = 3
+)
+
+// Pause before reconnecting, giving the network a moment to come back. A
+// variable so tests don't have to wait.
+var reconnectDelay = 2 * time.Second
+
+// isAbnormalExit reports whether err is a session that ended with a non-zero
+// exit code, as the Session Manager plugin does when the connection drops. A
+// clean logout exits 0; a session stopped by a signal, or that never started,
+// isn't retried.
+func isAbnormalExit(err error) bool {
+	var exitErr *exec.ExitError
+	// ExitCode is -1 for a process killed by a signal
+	return errors.As(err, &exitErr) && exitErr.ExitCode() > 0
+}
+
+// withReconnect runs start, and with -reconnect runs it again after
+// reconnectDelay each time it ends abnormally, up to reconnectAttempts more
+// times. Each reconnect is announced on w.
+func withReconnect(w io.Writer, start func() error) error {
+	err := start()
+	if !reconnect {
+		return err
+	}
+	for attempt := 1; attempt <= reconnectAttempts && isAbnormalExit(err); attempt++ {
+		fmt.Fprintf(w, "Session ended unexpectedly (%v); reconnecting in %s (%d/%d)...\n", err, reconnectDelay, attempt, reconnectAttempts)
+		time.Sleep(reconnectDelay)
+		err = start()
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Helper function for enabling -reconnect without the delay
+func withReconnectFlag(t *testing.T, attempts int) {
+	reconnect, reconnectAttempts, reconnectDelay = true, attempts, 0
+	t.Cleanup(func() {
+		reconnect, reconnectAttempts = false, 3
+	})
+}
+
+// Test telling dropped sessions from clean logouts
+func TestIsAbnormalExit(t *testing.T) {
+	assert.False(t, isAbnormalExit(nil))
+	assert.False(t, isAbnormalExit(errors.New("executable file not found")))
+	assert.True(t, isAbnormalExit(exec.Command("sh", "-c", "exit 255").Run()))
+	assert.False(t, isAbnormalExit(exec.Command("sh", "-c", "kill -TERM $$").Run()))
+}
+
+// Test reconnecting after abnormal exits, up to the attempt limit
+func TestWithReconnect(t *testing.T) {
+	withReconnectFlag(t, 2)
+	dropped := exec.Command("sh", "-c", "exit 255").Run()
+
+	t.Run("until the session ends cleanly", func(t *testing.T) {
+		runs := 0
+		var out bytes.Buffer
+		err := withReconnect(&out, func() error {
+			runs++
+			if runs == 1 {
+				return dropped
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, runs)
+		assert.Contains(t, out.String(), "Session ended unexpectedly (exit status 255); reconnecting in 0s (1/2)...")
+	})
+
+	t.Run("up to the limit", func(t *testing.T) {
+		runs := 0
+		err := withReconnect(&bytes.Buffer{}, func() error {
+			runs++
+			return dropped
+		})
+		assert.Equal(t, dropped, err)
+		assert.Equal(t, 3, runs)
+	})
+
+	t.Run("not without -reconnect", func(t *testing.T) {
+		reconnect = false
+		runs := 0
+		_ = withReconnect(&bytes.Buffer{}, func() error {
+			runs++
+			return dropped
+		})
+		assert.Equal(t, 1, runs)
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Set by -region-counts: count the instances of every region in the
+// background and show the counts in the region list
+var regionCounts bool
+
+// regionCountsCmd counts the instances of each region, at most
+// allRegionsWorkers at a time. Each region reports back on its own, so counts
+// appear as they arrive instead of after the slowest region. The listings go
+// through the instance cache, so picking a counted region is instant. Counts
+// still pending when the user leaves the region list are dropped.
+func regionCountsCmd(ctx context.Context, profile string, regions []string) tea.Cmd {
+	slots := make(chan struct{}, allRegionsWorkers)
+	cmds := make([]tea.Cmd, len(regions))
+	for i, region := range regions {
+		cmds[i] = func() tea.Msg {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			instances, err := getRegionInstances(ctx, profile, region, false)
+			if lookupCancelled(ctx) {
+				return nil
+			}
+			return struct {
+				countProfile string
+				countRegion  string
+				count        int
+				countErr     error
+			}{profile, region, len(instances), err}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// updateRegionCount records one region's instance count, ignoring counts
+// for a profile that is no longer selected.
+func (m model) updateRegionCount(profile, region string, count int, err error) model {
+	if profile != m.selectedProfile || m.instanceCounts == nil {
+		return m
+	}
+	if err != nil {
+		// Unknown; the region may still be selected to see the error
+		count = -1
+	}
+	m.instanceCounts[region] = count
+	return m
+}
+
+// regionCountLabel returns the count shown after a region, e.g.
+// " (12 instances)", or "" while it is still loading.
+func (m model) regionCountLabel(region string) string {
+	count, ok := m.instanceCounts[region]
+	switch {
+	case !ok:
+		return ""
+	case count < 0:
+		return " (? instances)"
+	case count == 1:
+		return " (1 instance)"
+	}
+	return fmt.Sprintf(" (%d instances)", count)
+}
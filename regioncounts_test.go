@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test annotating the region list with instance counts as they arrive
+func TestRegionCounts(t *testing.T) {
+	setTempHome(t)
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		switch argValue(args, "--region") {
+		case "us-east-1":
+			return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-1"},{"InstanceId":"i-2"}]}]}`), nil
+		case "eu-west-1":
+			return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-3"}]}]}`), nil
+		}
+		return nil, errors.New("AccessDenied")
+	})
+	m := model{step: stateProfile, loading: true, selectedProfile: "default", regionCounts: true}
+
+	updatedModel, cmd := m.Update(struct {
+		regions []string
+		err     error
+	}{[]string{"us-east-1", "eu-west-1", "ap-south-1"}, nil})
+	result := updatedModel.(model)
+	require.NotNil(t, cmd)
+	assert.Equal(t, stateRegion, result.step)
+	assert.NotContains(t, result.View(), "instances)", "nothing shown before counts arrive")
+
+	batch, ok := cmd().(tea.BatchMsg)
+	require.True(t, ok)
+	require.Len(t, batch, 3)
+	// The first count shows up before the others finish
+	updatedModel, _ = result.Update(batch[0]())
+	result = updatedModel.(model)
+	assert.Contains(t, result.View(), "us-east-1 (2 instances)")
+	assert.NotContains(t, result.View(), "eu-west-1 (")
+
+	for _, c := range batch[1:] {
+		updatedModel, _ = result.Update(c())
+		result = updatedModel.(model)
+	}
+	view := result.View()
+	assert.Contains(t, view, "eu-west-1 (1 instance)")
+	assert.Contains(t, view, "ap-south-1 (? instances)")
+
+	// Counts for a profile that's no longer selected are dropped
+	result.selectedProfile = "other"
+	result.instanceCounts = map[string]int{}
+	updatedModel, _ = result.Update(batch[0]())
+	assert.Empty(t, updatedModel.(model).instanceCounts)
+}
+
+// Test that counting is off by default
+func TestRegionCountsDisabled(t *testing.T) {
+	m := model{step: stateProfile, loading: true, selectedProfile: "default"}
+	_, cmd := m.Update(struct {
+		regions []string
+		err     error
+	}{[]string{"us-east-1"}, nil})
+	assert.Nil(t, cmd)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Region prefixes in display order: the Americas, Europe and the Middle
+// East, Africa, then Asia Pacific. Unknown prefixes sort after these.
+var regionGeographyOrder = []string{"us", "ca", "mx", "sa", "eu", "il", "me", "af", "ap", "cn"}
+
+// defaultRegion returns the user's default region from the environment.
+func defaultRegion() string {
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		return r
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// regionCursor returns where the cursor starts on the region list: on the
+// default region from the environment when the list has it, since a shell
+// with AWS_REGION set most likely wants that region, then on the last used
+// region, then on the first.
+func regionCursor(regions []string, last string) int {
+	if i := slices.Index(regions, defaultRegion()); i >= 0 {
+		return i
+	}
+	return indexOf(regions, last)
+}
+
+// sortRegions orders regions by geography and then by name, with the
+// default region (AWS_REGION / AWS_DEFAULT_REGION) first.
+func sortRegions(regions []string) []string {
+	sorted := append([]string{}, regions...)
+	first := defaultRegion()
+	rank := func(region string) int {
+		if region == first {
+			return -1
+		}
+		prefix, _, _ := strings.Cut(region, "-")
+		for i, p := range regionGeographyOrder {
+			if p == prefix {
+				return i
+			}
+		}
+		return len(regionGeographyOrder)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank(sorted[i]), rank(sorted[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// Commercial regions enabled in every account, offered when the profile may
+// not call describe-regions. Opt-in regions are left out; list them under
+// regions in the config file instead.
+var standardRegions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"ca-central-1", "sa-east-1",
+	"eu-west-1", "eu-west-2", "eu-west-3", "eu-central-1", "eu-north-1",
+	"ap-south-1", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3", "ap-southeast-1", "ap-southeast-2",
+}
+
+// Fragments of AWS CLI errors that mean the call was denied by IAM or an SCP
+var accessDeniedMarkers = []string{
+	"unauthorizedoperation",
+	"accessdenied",
+	"not authorized to perform",
+}
+
+// isAccessDenied classifies err as a permission error rather than a failure
+// that retrying or logging in again would fix.
+func isAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range accessDeniedMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
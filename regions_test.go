@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+// Test that regions are grouped by geography and sorted by name
+func TestSortRegions(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	regions := []string{"ap-south-1", "eu-west-1", "us-west-2", "sa-east-1", "ap-northeast-1", "us-east-1", "me-south-1", "eu-central-1", "ca-central-1", "us-east-2", "af-south-1", "xx-new-1"}
+	expected := []string{"us-east-1", "us-east-2", "us-west-2", "ca-central-1", "sa-east-1", "eu-central-1", "eu-west-1", "me-south-1", "af-south-1", "ap-northeast-1", "ap-south-1", "xx-new-1"}
+	assert.Equal(t, expected, sortRegions(regions))
+	// The input is left untouched
+	assert.Equal(t, "ap-south-1", regions[0])
+
+	t.Run("default region first", func(t *testing.T) {
+		t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+		assert.Equal(t, []string{"eu-west-1", "us-east-1", "ap-south-1"}, sortRegions([]string{"ap-south-1", "us-east-1", "eu-west-1"}))
+
+		// AWS_REGION takes precedence, as in the AWS CLI
+		t.Setenv("AWS_REGION", "ap-south-1")
+		assert.Equal(t, []string{"ap-south-1", "us-east-1", "eu-west-1"}, sortRegions([]string{"eu-west-1", "us-east-1", "ap-south-1"}))
+	})
+}
+
+// Test that describe-regions results come back sorted
+func TestGetRegionsSorted(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return []byte(`{"Regions":[{"RegionName":"eu-west-1"},{"RegionName":"ap-south-1"},{"RegionName":"us-west-2"}]}`), nil
+	})
+	regions, err := getRegions(context.Background(), "default")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"us-west-2", "eu-west-1", "ap-south-1"}, regions)
+}
+
+// Test that a denied describe-regions falls back to the built-in region list
+func TestRegionsAccessDeniedFallback(t *testing.T) {
+	setTempHome(t)
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, &awsssm.CLIError{
+			Args:   args,
+			Err:    errors.New("exit status 254"),
+			Stderr: "An error occurred (UnauthorizedOperation) when calling the DescribeRegions operation: You are not authorized to perform this operation.",
+		}
+	})
+
+	m := model{step: stateProfile, selectedProfile: "locked-down", loading: true}
+	updatedModel, _ := m.Update(regionsCmd(context.Background(), "locked-down", false)())
+	result := updatedModel.(model)
+	require.NoError(t, result.err)
+	assert.Equal(t, stateRegion, result.step)
+	assert.True(t, result.staticRegions)
+	assert.Equal(t, sortRegions(standardRegions), result.regions)
+	assert.Equal(t, "us-east-1", result.regions[0])
+	assert.Contains(t, result.View(), "Built-in region list")
+
+	// A region list fetched later drops the note
+	updatedModel, _ = result.Update(struct {
+		regions []string
+		err     error
+	}{[]string{"eu-west-1"}, nil})
+	assert.False(t, updatedModel.(model).staticRegions)
+}
+
+// Test classifying permission errors
+func TestIsAccessDenied(t *testing.T) {
+	assert.True(t, isAccessDenied(errors.New("An error occurred (UnauthorizedOperation) when calling the DescribeRegions operation")))
+	assert.True(t, isAccessDenied(errors.New("An error occurred (AccessDeniedException): User is not authorized to perform: ec2:DescribeRegions with an explicit deny in a service control policy")))
+	assert.False(t, isAccessDenied(errors.New("Could not connect to the endpoint URL")))
+	assert.False(t, isAccessDenied(nil))
+}
+
+// Test that the region cursor starts on the environment's region
+func TestRegionCursor(t *testing.T) {
+	regions := []string{"us-east-1", "eu-west-1", "ap-south-1"}
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	assert.Equal(t, 0, regionCursor(regions, ""))
+	assert.Equal(t, 2, regionCursor(regions, "ap-south-1"))
+
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	assert.Equal(t, 1, regionCursor(regions, "ap-south-1"))
+
+	// A region the account hasn't enabled falls back to the last used one
+	t.Setenv("AWS_REGION", "me-central-1")
+	assert.Equal(t, 2, regionCursor(regions, "ap-south-1"))
+	assert.Equal(t, 0, regionCursor(regions, ""))
+
+	t.Run("regions message", func(t *testing.T) {
+		t.Setenv("AWS_REGION", "eu-west-1")
+		m := model{step: stateProfile, selectedProfile: "default", loading: true, lastRegion: "ap-south-1"}
+		updatedModel, _ := m.Update(struct {
+			regions []string
+			err     error
+		}{regions, nil})
+		assert.Equal(t, 1, updatedModel.(model).cursor)
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// First argument that runs the headless resolve subcommand instead of the TUI
+const resolveCommand = "resolve"
+
+// runResolve implements `ssmssh resolve -profile X -region Y -name N`: it
+// prints the ID of the single running instance matching the Name tag (or
+// -tag Key=Value) so scripts can run `id=$(ssmssh resolve ...)`. Zero or
+// several matches exit 1, with the candidates listed on stderr. The lookup
+// always goes to AWS rather than to the instance cache.
+func runResolve(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(resolveCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", "", "AWS profile to search")
+	region := fs.String("region", "", "AWS region to search")
+	name := fs.String("name", "", "Name tag of the instance; * and ? are wildcards")
+	tag := fs.String("tag", "", "match this Key=Value tag instead of -name; the value may use * and ? wildcards")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: ssmssh [flags] %s -profile X -region Y (-name N | -tag Key=Value)\n", resolveCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	c := Connection{Profile: *profile, Region: *region, Tag: *tag}
+	if (*name == "") == (*tag == "") {
+		fmt.Fprintln(stderr, "Error: resolve needs exactly one of -name or -tag")
+		return 2
+	}
+	if *name != "" {
+		c.Tag = "Name=" + *name
+	}
+	if c.Profile == "" || c.Region == "" {
+		fmt.Fprintln(stderr, "Error: resolve needs -profile and -region")
+		return 2
+	}
+	if key, _ := c.tagMatcher(); key == "" || !strings.Contains(c.Tag, "=") {
+		fmt.Fprintln(stderr, "Error: -tag needs Key=Value, e.g. Name=web-server")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(discovery, loadTimeout)
+	defer cancel()
+	instances, err := getInstances(ctx, c.Profile, c.Region, true)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", lookupErr(ctx, err, "instances", loadTimeout))
+		return 1
+	}
+	matches := sortInstances(c.resolve(instances), sortByName)
+	switch len(matches) {
+	case 0:
+		fmt.Fprintf(stderr, "Error: no running instance matches %s\n", c.Display())
+		return 1
+	case 1:
+		fmt.Fprintln(stdout, matches[0].ID)
+		return 0
+	}
+	fmt.Fprintf(stderr, "Error: %d running instances match %s:\n", len(matches), c.Display())
+	_ = writeInstancesText(stderr, matches)
+	return 1
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test resolving a single instance ID for scripts
+func TestRunResolve(t *testing.T) {
+	setTempHome(t)
+	withDemoMode(t)
+
+	t.Run("single match", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runResolve([]string{"-profile", "demo-dev", "-region", "us-east-1", "-name", "web-server-1"}, &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "i-0a1b2c3d4e5f60001\n", stdout.String())
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("tag match", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runResolve([]string{"-profile", "demo-dev", "-region", "us-east-1", "-tag", "Name=web-server-2"}, &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "i-0a1b2c3d4e5f60002\n", stdout.String())
+	})
+
+	t.Run("several matches list the candidates", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runResolve([]string{"-profile", "demo-dev", "-region", "us-east-1", "-name", "web-*"}, &stdout, &stderr)
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout.String())
+		assert.Contains(t, stderr.String(), "2 running instances match Name=web-*")
+		assert.Contains(t, stderr.String(), "i-0a1b2c3d4e5f60001")
+		assert.Contains(t, stderr.String(), "i-0a1b2c3d4e5f60002")
+	})
+
+	t.Run("stopped instances don't match", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runResolve([]string{"-profile", "demo-dev", "-region", "us-east-1", "-name", "batch-worker"}, &stdout, &stderr)
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout.String())
+		assert.Contains(t, stderr.String(), "no running instance matches Name=batch-worker")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-region", "us-east-1", "-name", "web-server-1"},
+			{"-profile", "demo-dev", "-region", "us-east-1"},
+			{"-profile", "demo-dev", "-region", "us-east-1", "-name", "a", "-tag", "Name=a"},
+			{"-profile", "demo-dev", "-region", "us-east-1", "-tag", "web"},
+			{"-profile", "demo-dev", "-region", "us-east-1", "-name", "a", "extra"},
+			{"-unknown"},
+		} {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, 2, runResolve(args, &stdout, &stderr), args)
+			assert.Empty(t, stdout.String())
+			assert.NotEmpty(t, stderr.String())
+		}
+	})
+}
+
+// Test that lookup errors exit 1 and bypass the instance cache
+func TestRunResolveLookupError(t *testing.T) {
+	setTempHome(t)
+	require.NoError(t, writeInstanceCache("default", "us-east-1", []Instance{{ID: "i-cached", Name: "web", State: "running", Tags: []Tag{{Key: "Name", Value: "web"}}}}))
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, errors.New("AccessDenied")
+	})
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, runResolve([]string{"-profile", "default", "-region", "us-east-1", "-name", "web"}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "AccessDenied")
+}
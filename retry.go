@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Attempts made at a describe call before giving up on throttling
+const describeAttempts = 3
+
+// Delay before the first retry; each later retry doubles it. A variable so
+// tests don't have to wait.
+var retryBaseDelay = 500 * time.Millisecond
+
+// Number of AWS calls currently backing off, shown next to the spinner
+var retriesInFlight atomic.Int32
+
+// Fragments of AWS CLI errors that are worth retrying: throttling and
+// transient service-side failures. Auth errors never match.
+var retryableMarkers = []string{
+	"requestlimitexceeded",
+	"throttling",
+	"throttled",
+	"toomanyrequests",
+	"rate exceeded",
+	"serviceunavailable",
+	"internalerror",
+	"requesttimeout",
+}
+
+// isRetryable classifies err as throttling or a transient AWS failure.
+func isRetryable(err error) bool {
+	if err == nil || isExpiredCredentials(err) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range retryableMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry calls fn up to attempts times, backing off exponentially with
+// jitter between calls, for as long as it fails with errors retryable
+// accepts. It returns the last error, or ctx's error if ctx is done while
+// waiting to retry.
+func withRetry(ctx context.Context, attempts int, retryable func(error) bool, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			// Full jitter keeps parallel region lookups from retrying in lockstep
+			delay := retryBaseDelay << (attempt - 1)
+			select {
+			case <-time.After(delay/2 + rand.N(delay/2+1)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = fn(); err == nil || !retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// describeAWS runs a read-only AWS CLI call, retrying when AWS throttles it.
+func describeAWS(ctx context.Context, args ...string) ([]byte, error) {
+	var out []byte
+	retrying := false
+	err := withRetry(ctx, describeAttempts, isRetryable, func() error {
+		var err error
+		out, err = runAWS(ctx, args...)
+		if isRetryable(err) && !retrying {
+			retrying = true
+			retriesInFlight.Add(1)
+		}
+		return err
+	})
+	if retrying {
+		retriesInFlight.Add(-1)
+	}
+	return out, err
+}
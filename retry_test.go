@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errThrottled = errors.New("An error occurred (RequestLimitExceeded) when calling the DescribeInstances operation: Request limit exceeded.")
+
+// Helper function for retrying without waiting
+func noRetryDelay(t *testing.T) {
+	original := retryBaseDelay
+	retryBaseDelay = 0
+	t.Cleanup(func() { retryBaseDelay = original })
+}
+
+// Test classification of errors worth retrying
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errThrottled, true},
+		{errors.New("An error occurred (Throttling) when calling the DescribeRegions operation: Rate exceeded"), true},
+		{errors.New("An error occurred (ServiceUnavailable) when calling the DescribeInstances operation"), true},
+		{errors.New("An error occurred (UnauthorizedOperation) when calling the DescribeInstances operation"), false},
+		{errors.New("An error occurred (ExpiredToken) when calling the DescribeInstances operation"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isRetryable(tt.err), "%v", tt.err)
+	}
+}
+
+// Test the retry loop
+func TestWithRetry(t *testing.T) {
+	noRetryDelay(t)
+
+	t.Run("succeeds after two failures", func(t *testing.T) {
+		calls := 0
+		err := withRetry(context.Background(), 3, isRetryable, func() error {
+			calls++
+			if calls < 3 {
+				return errThrottled
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		calls := 0
+		err := withRetry(context.Background(), 3, isRetryable, func() error {
+			calls++
+			return errThrottled
+		})
+		assert.Equal(t, errThrottled, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		denied := errors.New("AccessDenied")
+		calls := 0
+		err := withRetry(context.Background(), 3, isRetryable, func() error {
+			calls++
+			return denied
+		})
+		assert.Equal(t, denied, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("backs off between attempts", func(t *testing.T) {
+		retryBaseDelay = 20 * time.Millisecond
+		defer func() { retryBaseDelay = 0 }()
+		start := time.Now()
+		_ = withRetry(context.Background(), 3, isRetryable, func() error { return errThrottled })
+		// Two waits of at least half of 20ms and 40ms
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	})
+
+	t.Run("stops waiting once cancelled", func(t *testing.T) {
+		retryBaseDelay = time.Minute
+		defer func() { retryBaseDelay = 0 }()
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := withRetry(ctx, 3, isRetryable, func() error {
+			calls++
+			cancel()
+			return errThrottled
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+// Test that describe calls are retried and reported to the spinner
+func TestDescribeAWSRetries(t *testing.T) {
+	noRetryDelay(t)
+	calls := 0
+	var inFlight int32
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		calls++
+		inFlight = retriesInFlight.Load()
+		if calls < 3 {
+			return nil, errThrottled
+		}
+		return []byte(`{}`), nil
+	})
+
+	out, err := describeAWS(context.Background(), "ec2", "describe-regions")
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(out))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int32(1), inFlight, "retrying while the last attempt ran")
+	assert.Equal(t, int32(0), retriesInFlight.Load())
+
+	retriesInFlight.Add(1)
+	defer retriesInFlight.Add(-1)
+	assert.Contains(t, model{loading: true, loadingMsg: "Fetching regions..."}.View(), "retrying")
+}
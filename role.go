@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"goversion/pkg/awsssm"
+)
+
+// Set by -role-arn, -external-id, and -role-session-name: every AWS call runs
+// under this role, assumed from the selected profile
+var (
+	roleArn         string
+	roleExternalId  string
+	roleSessionName = "ssmssh"
+)
+
+// Assumed credentials are refreshed this long before they expire
+const roleRefreshMargin = time.Minute
+
+// Shown instead of printing or copying aws commands while a role is active:
+// they run outside ssmssh with --profile, as the profile rather than the role
+const roleCommandMsg = "Commands can't be printed or copied while a role is assumed; they would run as the profile"
+
+// roleCredentials are the temporary credentials returned by sts assume-role.
+type roleCredentials struct {
+	AccessKeyId     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// env returns the credentials as AWS CLI environment variables.
+func (c roleCredentials) env() []string {
+	return []string{
+		"AWS_ACCESS_KEY_ID=" + c.AccessKeyId,
+		"AWS_SECRET_ACCESS_KEY=" + c.SecretAccessKey,
+		"AWS_SESSION_TOKEN=" + c.SessionToken,
+	}
+}
+
+var (
+	// Guards roleArn, which changes as accounts are picked with -org-role,
+	// and assumedRoles
+	assumedMu sync.Mutex
+	// Credentials keyed by the role and the base profile they were assumed from
+	assumedRoles = map[string]roleCredentials{}
+)
+
+// currentRoleArn returns the role AWS calls run under, or "" for none.
+func currentRoleArn() string {
+	assumedMu.Lock()
+	defer assumedMu.Unlock()
+	return roleArn
+}
+
+// setRoleArn switches the role AWS calls run under from now on.
+func setRoleArn(arn string) {
+	assumedMu.Lock()
+	defer assumedMu.Unlock()
+	roleArn = arn
+}
+
+// roleCacheSuffix keeps the instance lists of each role, which usually means
+// each account, apart in the cache.
+func roleCacheSuffix() string {
+	arn := currentRoleArn()
+	if arn == "" {
+		return ""
+	}
+	sum := sha1.Sum([]byte(arn))
+	return "-" + hex.EncodeToString(sum[:6])
+}
+
+// assumeRoleArgs returns the sts assume-role arguments for arn from profile.
+func assumeRoleArgs(arn, profile string) []string {
+	args := []string{"sts", "assume-role", "--profile", profile, "--role-arn", arn, "--role-session-name", roleSessionName}
+	if roleExternalId != "" {
+		args = append(args, "--external-id", roleExternalId)
+	}
+	return append(args, "--output", "json")
+}
+
+// assumeRole returns credentials for arn assumed from profile, reusing
+// earlier credentials until they are about to expire. It is safe for
+// concurrent use.
+func assumeRole(ctx context.Context, arn, profile string) (roleCredentials, error) {
+	assumedMu.Lock()
+	defer assumedMu.Unlock()
+	key := arn + " " + profile
+	if creds, ok := assumedRoles[key]; ok && time.Until(creds.Expiration) > roleRefreshMargin {
+		return creds, nil
+	}
+	// Called directly rather than through runAWS, which itself assumes the role
+	out, err := execAWS(ctx, assumeRoleArgs(arn, profile)...)
+	if err != nil {
+		return roleCredentials{}, fmt.Errorf("assuming role %s: %w", arn, err)
+	}
+	var result struct {
+		Credentials roleCredentials `json:"Credentials"`
+	}
+	if err := awsssm.DecodeOutput(out, &result); err != nil {
+		return roleCredentials{}, fmt.Errorf("assuming role %s: %w", arn, err)
+	}
+	assumedRoles[key] = result.Credentials
+	return result.Credentials, nil
+}
+
+// awsCommand builds the aws CLI command for args. With -role-arn the
+// --profile argument is dropped and the assumed role's credentials are passed
+// in the environment instead, since --profile would take precedence over them.
+// The assume-role call itself is checked first: it runs while assumeRole holds
+// assumedMu.
+func awsCommand(ctx context.Context, args []string) (*exec.Cmd, error) {
+	if len(args) >= 2 && args[0] == "sts" && args[1] == "assume-role" {
+		return execCommand(ctx, "aws", args...), nil
+	}
+	arn := currentRoleArn()
+	if arn == "" {
+		return execCommand(ctx, "aws", args...), nil
+	}
+	creds, err := assumeRole(ctx, arn, argValue(args, "--profile"))
+	if err != nil {
+		return nil, err
+	}
+	cmd := execCommand(ctx, "aws", withoutProfile(args)...)
+	env := []string{}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "AWS_PROFILE=") {
+			env = append(env, kv)
+		}
+	}
+	cmd.Env = append(env, creds.env()...)
+	return cmd, nil
+}
+
+// withoutProfile returns args with any --profile flag and its value removed.
+func withoutProfile(args []string) []string {
+	out := []string{}
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--profile" {
+			i++
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return out
+}
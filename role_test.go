@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for setting -role-arn and forgetting assumed credentials afterwards
+func withRole(t *testing.T, arn, externalId string) {
+	roleArn, roleExternalId = arn, externalId
+	assumedRoles = map[string]roleCredentials{}
+	t.Cleanup(func() {
+		roleArn, roleExternalId = "", ""
+		assumedRoles = map[string]roleCredentials{}
+	})
+}
+
+// Test that AWS calls run under the assumed role
+func TestAssumeRole(t *testing.T) {
+	setTempHome(t)
+	withRole(t, "arn:aws:iam::123456789012:role/ops", "ext-42")
+	t.Setenv("AWS_PROFILE", "ambient")
+	expiration := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	calls := [][]string{}
+	original := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		calls = append(calls, arg)
+		if arg[0] == "sts" {
+			return exec.CommandContext(ctx, "echo", `{"Credentials":{"AccessKeyId":"ASIAEXAMPLE","SecretAccessKey":"secret","SessionToken":"token","Expiration":"`+expiration+`"}}`)
+		}
+		// Echo what the CLI would see: its arguments and credential environment
+		return exec.CommandContext(ctx, "sh", "-c", `echo "$AWS_ACCESS_KEY_ID $AWS_SESSION_TOKEN profile=$AWS_PROFILE $*"`, "sh", strings.Join(arg, " "))
+	}
+	t.Cleanup(func() { execCommand = original })
+
+	out, err := runAWS(context.Background(), "ec2", "describe-regions", "--profile", "base", "--region", "us-west-2")
+	require.NoError(t, err)
+	assert.Equal(t, "ASIAEXAMPLE token profile= ec2 describe-regions --region us-west-2\n", string(out))
+
+	require.Len(t, calls, 2)
+	assert.Equal(t, []string{
+		"sts", "assume-role", "--profile", "base",
+		"--role-arn", "arn:aws:iam::123456789012:role/ops",
+		"--role-session-name", "ssmssh",
+		"--external-id", "ext-42",
+		"--output", "json",
+	}, calls[0])
+
+	// Credentials are reused until they near expiry
+	_, err = runAWS(context.Background(), "ec2", "describe-instances", "--profile", "base")
+	require.NoError(t, err)
+	assert.Len(t, calls, 3)
+}
+
+// Test that a failed assume-role stops the call it was for
+func TestAssumeRoleFailure(t *testing.T) {
+	setTempHome(t)
+	withRole(t, "arn:aws:iam::123456789012:role/ops", "")
+	stubExec(t, `echo "An error occurred (AccessDenied) when calling the AssumeRole operation" >&2; exit 254`)
+
+	_, err := runAWS(context.Background(), "ec2", "describe-regions", "--profile", "base")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "assuming role arn:aws:iam::123456789012:role/ops")
+	assert.Contains(t, err.Error(), "AccessDenied")
+	assert.NotContains(t, assumeRoleArgs("arn:aws:iam::123456789012:role/ops", "base"), "--external-id")
+}
+
+// Test stripping --profile from CLI arguments
+func TestWithoutProfile(t *testing.T) {
+	assert.Equal(t, []string{"ssm", "start-session", "--target", "i-123"}, withoutProfile([]string{"ssm", "start-session", "--profile", "dev", "--target", "i-123"}))
+	assert.Equal(t, []string{"sts"}, withoutProfile([]string{"sts"}))
+}
+
+// Test that commands to run later aren't printed or copied under a role
+func TestRoleCommandsRefused(t *testing.T) {
+	withRole(t, "arn:aws:iam::123456789012:role/ops", "")
+	copied := stubClipboard(t, nil)
+
+	result := typeKeys(clipboardModel(), altKey('s'))
+	assert.Equal(t, stateInstance, result.step, "no ProxyCommand is printed")
+	assert.Equal(t, roleCommandMsg, result.inlineErr)
+
+	result = typeKeys(clipboardModel(), altKey('Y'))
+	assert.Empty(t, *copied)
+	assert.Empty(t, result.pendingOutput)
+	assert.Equal(t, roleCommandMsg, result.inlineErr)
+
+	result = typeKeys(multiModel(), tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stateInstance, result.step, "no session commands are printed")
+	assert.Equal(t, roleCommandMsg, result.inlineErr)
+
+	// A tmux window per instance runs the sessions under the role
+	useTmux = true
+	t.Cleanup(func() { useTmux = false })
+	result = typeKeys(multiModel(), tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stateDone, result.step)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"goversion/pkg/awsssm"
+)
+
+// Set by -cmd: run this shell command on the selected instances with
+// send-command instead of opening a session
+var remoteCommand string
+
+// Time between get-command-invocation polls. A variable so tests don't have
+// to wait.
+var commandPollInterval = time.Second
+
+// Document send-command runs remoteCommand with
+const runShellScriptDocument = "AWS-RunShellScript"
+
+// Invocation statuses after which the command will not change any more
+var terminalCommandStatuses = map[string]bool{
+	"Success":   true,
+	"Failed":    true,
+	"Cancelled": true,
+	"TimedOut":  true,
+	// Reported by get-command-invocation as StatusDetails values
+	"DeliveryTimedOut":  true,
+	"ExecutionTimedOut": true,
+	"Undeliverable":     true,
+	"Terminated":        true,
+	"InvalidPlatform":   true,
+	"AccessDenied":      true,
+}
+
+// commandInvocation is the outcome of a command on one instance.
+type commandInvocation struct {
+	Status        string `json:"Status"`
+	StatusDetails string `json:"StatusDetails"`
+	ResponseCode  int    `json:"ResponseCode"`
+	Stdout        string `json:"StandardOutputContent"`
+	Stderr        string `json:"StandardErrorContent"`
+}
+
+// done reports whether the invocation has reached a terminal status.
+func (c commandInvocation) done() bool {
+	return terminalCommandStatuses[c.Status] || terminalCommandStatuses[c.StatusDetails]
+}
+
+// commandExitError is a command that ran but exited non-zero.
+type commandExitError struct {
+	instanceId string
+	code       int
+}
+
+func (e commandExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d on %s", e.code, e.instanceId)
+}
+
+// commandNotRunError is a command SSM couldn't run to completion, e.g. it
+// timed out or never reached the agent.
+type commandNotRunError struct {
+	instanceId string
+	status     string
+}
+
+func (e commandNotRunError) Error() string {
+	return fmt.Sprintf("command did not complete on %s: %s", e.instanceId, e.status)
+}
+
+// sendCommandArgs returns the AWS CLI arguments that run command on instanceId.
+func sendCommandArgs(profile, region, instanceId, command string) []string {
+	params, _ := json.Marshal(map[string][]string{"commands": {command}})
+	return []string{"ssm", "send-command", "--profile", profile, "--region", region, "--instance-ids", instanceId,
+		"--document-name", runShellScriptDocument, "--parameters", string(params), "--output", "json"}
+}
+
+// sendCommand starts command on instanceId and returns the command ID.
+func sendCommand(ctx context.Context, profile, region, instanceId, command string) (string, error) {
+	out, err := runAWS(ctx, sendCommandArgs(profile, region, instanceId, command)...)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Command struct {
+			CommandId string `json:"CommandId"`
+		} `json:"Command"`
+	}
+	if err := awsssm.DecodeOutput(out, &result); err != nil {
+		return "", err
+	}
+	return result.Command.CommandId, nil
+}
+
+// waitForCommand polls get-command-invocation until the command reaches a
+// terminal status. The invocation can take a moment to appear after
+// send-command, so InvocationDoesNotExist is polled through.
+func waitForCommand(ctx context.Context, profile, region, instanceId, commandId string) (commandInvocation, error) {
+	args := []string{"ssm", "get-command-invocation", "--profile", profile, "--region", region,
+		"--command-id", commandId, "--instance-id", instanceId, "--output", "json"}
+	for {
+		out, err := describeAWS(ctx, args...)
+		if err != nil && !strings.Contains(err.Error(), "InvocationDoesNotExist") {
+			return commandInvocation{}, err
+		}
+		if err == nil {
+			var inv commandInvocation
+			if err := awsssm.DecodeOutput(out, &inv); err != nil {
+				return commandInvocation{}, err
+			}
+			if inv.done() {
+				return inv, nil
+			}
+		}
+		select {
+		case <-time.After(commandPollInterval):
+		case <-ctx.Done():
+			return commandInvocation{}, ctx.Err()
+		}
+	}
+}
+
+// runRemoteCommand runs command on instanceId and copies its output to stdout and
+// stderr. A command that ran and exited non-zero returns commandExitError;
+// one SSM couldn't run returns commandNotRunError.
+func runRemoteCommand(ctx context.Context, stdout, stderr io.Writer, profile, region, instanceId, command string) error {
+	if demoMode {
+		fmt.Fprintf(stdout, "Demo mode, would run: aws %s\n", shellJoin(sendCommandArgs(profile, region, instanceId, command)))
+		return nil
+	}
+	commandId, err := sendCommand(ctx, profile, region, instanceId, command)
+	if err != nil {
+		return err
+	}
+	inv, err := waitForCommand(ctx, profile, region, instanceId, commandId)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, inv.Stdout)
+	fmt.Fprint(stderr, inv.Stderr)
+	switch {
+	case inv.Status == "Success":
+		return nil
+	case inv.Status == "Failed" && inv.ResponseCode > 0:
+		return commandExitError{instanceId: instanceId, code: inv.ResponseCode}
+	}
+	status := inv.Status
+	if inv.StatusDetails != "" && inv.StatusDetails != inv.Status {
+		status += " (" + inv.StatusDetails + ")"
+	}
+	return commandNotRunError{instanceId: instanceId, status: status}
+}
+
+// runRemoteCommands runs command on each target in turn, headed by the
+// instance when there are several, and returns the process exit code: the
+// command's own code when a single instance exited non-zero, 1 for any other
+// failure.
+func runRemoteCommands(stdout, stderr io.Writer, profile string, targets []Instance, command string) int {
+	code := 0
+	for _, inst := range targets {
+		if len(targets) > 1 {
+			fmt.Fprintf(stdout, "=== %s ===\n", inst.Display())
+		}
+		err := runRemoteCommand(context.Background(), stdout, stderr, profile, inst.Region, inst.ID, command)
+		if err == nil {
+			continue
+		}
+		fmt.Fprintln(stderr, "Error:", err)
+		var exitErr commandExitError
+		if errors.As(err, &exitErr) && len(targets) == 1 {
+			code = min(exitErr.code, 255)
+		} else {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for answering send-command and then each poll in turn
+func stubCommand(t *testing.T, polls ...string) *int {
+	t.Helper()
+	original := commandPollInterval
+	commandPollInterval = 0
+	t.Cleanup(func() { commandPollInterval = original })
+	calls := 0
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		if args[1] == "send-command" {
+			return []byte(`{"Command": {"CommandId": "cmd-1"}}`), nil
+		}
+		require.Equal(t, "cmd-1", argValue(args, "--command-id"))
+		poll := polls[min(calls, len(polls)-1)]
+		calls++
+		if poll == "" {
+			return nil, errors.New("An error occurred (InvocationDoesNotExist) when calling the GetCommandInvocation operation")
+		}
+		return []byte(poll), nil
+	})
+	return &calls
+}
+
+// Test the send-command arguments
+func TestSendCommandArgs(t *testing.T) {
+	args := sendCommandArgs("prod", "eu-west-1", "i-123", "echo a,b 'c'")
+	assert.Equal(t, "AWS-RunShellScript", argValue(args, "--document-name"))
+	assert.Equal(t, `{"commands":["echo a,b 'c'"]}`, argValue(args, "--parameters"))
+	assert.Equal(t, "i-123", argValue(args, "--instance-ids"))
+}
+
+// Test running a command and waiting for its output
+func TestRunRemoteCommand(t *testing.T) {
+	t.Run("success prints the output", func(t *testing.T) {
+		calls := stubCommand(t, "",
+			`{"Status": "InProgress", "StatusDetails": "InProgress"}`,
+			`{"Status": "Success", "StatusDetails": "Success", "ResponseCode": 0, "StandardOutputContent": " 10:00:00 up 3 days\n"}`)
+		var stdout, stderr bytes.Buffer
+		code := runRemoteCommands(&stdout, &stderr, "prod", []Instance{{ID: "i-123", Region: "eu-west-1"}}, "uptime")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, " 10:00:00 up 3 days\n", stdout.String())
+		assert.Empty(t, stderr.String())
+		assert.Equal(t, 3, *calls, "polled past the missing invocation and InProgress")
+	})
+
+	t.Run("non-zero exit keeps the command's code", func(t *testing.T) {
+		stubCommand(t, `{"Status": "Failed", "StatusDetails": "Failed", "ResponseCode": 3, "StandardErrorContent": "no such file\n"}`)
+		var stdout, stderr bytes.Buffer
+		code := runRemoteCommands(&stdout, &stderr, "prod", []Instance{{ID: "i-123", Region: "eu-west-1"}}, "cat /nope")
+		assert.Equal(t, 3, code)
+		assert.Contains(t, stderr.String(), "no such file\n")
+		assert.Contains(t, stderr.String(), "Error: command exited with code 3 on i-123")
+	})
+
+	t.Run("undelivered command is reported differently", func(t *testing.T) {
+		stubCommand(t, `{"Status": "TimedOut", "StatusDetails": "DeliveryTimedOut", "ResponseCode": -1}`)
+		var stdout, stderr bytes.Buffer
+		code := runRemoteCommands(&stdout, &stderr, "prod", []Instance{{ID: "i-123", Region: "eu-west-1"}}, "uptime")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "Error: command did not complete on i-123: TimedOut (DeliveryTimedOut)")
+	})
+
+	t.Run("several instances are headed by name", func(t *testing.T) {
+		stubCommand(t, `{"Status": "Success", "StandardOutputContent": "ok\n"}`)
+		var stdout, stderr bytes.Buffer
+		targets := []Instance{{ID: "i-1", Name: "web-1"}, {ID: "i-2", Name: "web-2"}}
+		code := runRemoteCommands(&stdout, &stderr, "prod", targets, "true")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "=== i-1 (web-1) ===\nok\n=== i-2 (web-2) ===\nok\n", stdout.String())
+	})
+}
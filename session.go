@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// runInteractive runs a session subprocess attached to the terminal until it
+// exits, so ssmssh never quits and leaves it orphaned.
+//
+// Ctrl+C already reaches the subprocess, which shares the terminal's process
+// group, and the Session Manager plugin passes it on to the remote shell; so
+// SIGINT is only kept from killing ssmssh. SIGTERM and SIGHUP, which are sent
+// to ssmssh alone, are relayed. The terminal mode is restored afterwards in
+// case the subprocess dies without undoing its raw mode.
+func runInteractive(cmd *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	if state, err := term.GetState(int(os.Stdin.Fd())); err == nil {
+		defer term.Restore(int(os.Stdin.Fd()), state)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	for {
+		select {
+		case sig := <-signals:
+			if sig != os.Interrupt {
+				_ = cmd.Process.Signal(sig)
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
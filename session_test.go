@@ -0,0 +1,45 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that signals sent to ssmssh during a session reach the subprocess
+func TestRunInteractiveForwardsSignals(t *testing.T) {
+	// Exits 7 on SIGTERM and ignores SIGINT, like a session that outlives Ctrl+C
+	ready := filepath.Join(t.TempDir(), "ready")
+	cmd := exec.Command("sh", "-c", `trap "exit 7" TERM; trap "" INT; touch "$0"; while :; do sleep 0.01; done`, ready)
+	done := make(chan error, 1)
+	go func() { done <- runInteractive(cmd) }()
+
+	// Wait for the subprocess to set its traps
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(ready)
+		return err == nil
+	}, 2*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+	select {
+	case err := <-done:
+		t.Fatalf("session ended on SIGINT: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	select {
+	case err := <-done:
+		assert.Equal(t, 7, exitCode(err))
+	case <-time.After(2 * time.Second):
+		t.Fatal("SIGTERM was not relayed to the session")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long a lookup runs before the loading screen offers to cancel or retry
+// it. A variable so tests don't have to wait.
+var slowLoadingAfter = 5 * time.Second
+
+// trackLoading notes when after started loading: the model as it was before,
+// so that cancelling can go back to it, and the input that started the load,
+// so that retrying can replay it. cmd is watched for taking longer than
+// slowLoadingAfter. A nested Update that already started tracking is left be.
+func (m model) trackLoading(after model, msg tea.Msg, cmd tea.Cmd) (model, tea.Cmd) {
+	if !after.loading {
+		after.slowLoading = false
+		after.loadingFrom, after.loadingTrigger = nil, nil
+		return after, cmd
+	}
+	if m.loading || after.loadingId != m.loadingId {
+		return after, cmd
+	}
+	after.loadingId++
+	after.slowLoading = false
+	after.loadingFrom, after.loadingTrigger = nil, nil
+	// Loads that follow an AWS response, such as the retry after logging in,
+	// depend on state that is gone by now and can't be replayed
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		from := m
+		after.loadingFrom, after.loadingTrigger = &from, msg
+	}
+	return after, watchLoading(cmd, after.loadingId)
+}
+
+// watchLoading runs cmd, and when it hasn't finished after slowLoadingAfter,
+// reports that the load with id is slow. Its message then follows, tagged
+// with id so that the message of a cancelled load can be dropped. The
+// commands of a batch are each watched.
+func watchLoading(cmd tea.Cmd, id int) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		done := make(chan tea.Msg, 1)
+		go func() { done <- cmd() }()
+		select {
+		case msg := <-done:
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				watched := make(tea.BatchMsg, len(batch))
+				for i, c := range batch {
+					watched[i] = watchLoading(c, id)
+				}
+				return watched
+			}
+			return msg
+		case <-time.After(slowLoadingAfter):
+			return struct {
+				slowLoadingId int
+				rest          tea.Cmd
+			}{id, func() tea.Msg {
+				return struct {
+					slowLoadedId int
+					loaded       tea.Msg
+				}{id, <-done}
+			}}
+		}
+	}
+}
+
+// continueLoading watches next, which waits for the following message of a
+// streaming load, like the command that started the load, so a load that
+// stalls partway through still shows as slow. Progress clears an earlier
+// slow mark.
+func (m model) continueLoading(next tea.Cmd) (tea.Model, tea.Cmd) {
+	if !m.loading {
+		return m, next
+	}
+	m.slowLoading = false
+	return m, watchLoading(next, m.loadingId)
+}
+
+// updateSlowLoading marks the current load as slow, unless id is an earlier
+// one, and keeps waiting for its message.
+func (m model) updateSlowLoading(id int, rest tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.loading && id == m.loadingId {
+		m.slowLoading = true
+	}
+	return m, rest
+}
+
+// updateSlowLoaded passes on the message of a slow load, dropping it when the
+// load was cancelled or retried in the meantime.
+func (m model) updateSlowLoaded(id int, loaded tea.Msg) (tea.Model, tea.Cmd) {
+	if !m.loading || id != m.loadingId || loaded == nil {
+		return m, nil
+	}
+	return m, func() tea.Msg { return loaded }
+}
+
+// canAbandonLoading reports whether the loading screen offers to cancel or
+// retry the load.
+func (m model) canAbandonLoading() bool {
+	return m.slowLoading && m.loadingFrom != nil
+}
+
+// abandonLoading stops the AWS CLI processes of the current load and returns
+// the model from before it started, with the loading ID kept so the load's
+// message is dropped if it still arrives.
+func (m model) abandonLoading() model {
+	if m.cancelLookups != nil {
+		m.cancelLookups()
+	}
+	from := *m.loadingFrom
+	from.loadingId = m.loadingId
+	return from.leaveScreen()
+}
+
+// cancelLoading goes back to the screen the slow load was started from.
+func (m model) cancelLoading() (tea.Model, tea.Cmd) {
+	from := m.abandonLoading()
+	if from.step == stateInstance && from.previewLoading && len(from.filteredInstances) > 0 {
+		// The preview lookup was stopped along with the load
+		return from.preview()
+	}
+	return from, nil
+}
+
+// retryLoading stops the slow load and starts it again from the same input.
+func (m model) retryLoading() (tea.Model, tea.Cmd) {
+	return m.abandonLoading().Update(m.loadingTrigger)
+}
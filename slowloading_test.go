@@ -0,0 +1,128 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for a describe-instances call that hangs until released
+func stubSlowAWS(t *testing.T) chan struct{} {
+	original := slowLoadingAfter
+	slowLoadingAfter = 10 * time.Millisecond
+	t.Cleanup(func() { slowLoadingAfter = original })
+	release := make(chan struct{})
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		<-release
+		return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-1","State":{"Name":"running"}}]}]}`), nil
+	})
+	return release
+}
+
+// Helper function for waiting out a slow load
+func slowLoad(t *testing.T, m model, cmd tea.Cmd) (model, tea.Cmd) {
+	require.NotNil(t, cmd)
+	updatedModel, rest := m.Update(cmd())
+	result := updatedModel.(model)
+	require.True(t, result.slowLoading)
+	require.NotNil(t, rest)
+	return result, rest
+}
+
+// Test cancelling a slow load back to the screen it started from
+func TestCancelSlowLoading(t *testing.T) {
+	setTempHome(t)
+	release := stubSlowAWS(t)
+	regions := []string{"us-east-1", "eu-west-1"}
+	m := model{step: stateRegion, selectedProfile: "prod", regions: regions, filteredRegions: regions, cursor: 1}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loading := updatedModel.(model)
+	require.True(t, loading.loading)
+	assert.NotContains(t, loading.View(), "press c to cancel")
+	// Other input stays ignored until the load is slow
+	updatedModel, _ = loading.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	assert.True(t, updatedModel.(model).loading)
+
+	slow, rest := slowLoad(t, loading, cmd)
+	assert.Contains(t, slow.View(), "Still loading... press c to cancel, r to retry")
+
+	updatedModel, _ = slow.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	cancelled := updatedModel.(model)
+	assert.False(t, cancelled.loading)
+	assert.Equal(t, stateRegion, cancelled.step)
+	assert.Equal(t, 1, cancelled.cursor)
+
+	// The cancelled load's result is dropped
+	close(release)
+	updatedModel, cmd = cancelled.Update(rest())
+	assert.Nil(t, cmd)
+	assert.Equal(t, stateRegion, updatedModel.(model).step)
+	assert.NoError(t, updatedModel.(model).err)
+}
+
+// Test retrying a slow load
+func TestRetrySlowLoading(t *testing.T) {
+	setTempHome(t)
+	release := stubSlowAWS(t)
+	regions := []string{"us-east-1"}
+	m := model{step: stateRegion, selectedProfile: "prod", regions: regions, filteredRegions: regions}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	slow, rest := slowLoad(t, updatedModel.(model), cmd)
+
+	updatedModel, cmd = slow.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	retried := updatedModel.(model)
+	assert.True(t, retried.loading)
+	assert.False(t, retried.slowLoading)
+	assert.Greater(t, retried.loadingId, slow.loadingId)
+	require.NotNil(t, cmd)
+
+	close(release)
+	updatedModel, _ = retried.Update(rest())
+	assert.True(t, updatedModel.(model).loading, "the first attempt's result is dropped")
+	updatedModel, _ = retried.Update(cmd())
+	result := updatedModel.(model)
+	assert.False(t, result.loading)
+	assert.Equal(t, stateInstance, result.step)
+	assert.Len(t, result.instances, 1)
+}
+
+// Test that a streaming load stalling after its first progress message shows
+// as slow
+func TestSlowLoadingAfterProgress(t *testing.T) {
+	setTempHome(t)
+	original := slowLoadingAfter
+	slowLoadingAfter = 10 * time.Millisecond
+	t.Cleanup(func() { slowLoadingAfter = original })
+	release := make(chan struct{})
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		if slices.Contains(args, "--starting-token") {
+			<-release
+			return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-003","State":{"Name":"running"}}]}]}`), nil
+		}
+		return []byte(`{"Reservations":[{"Instances":[{"InstanceId":"i-001","State":{"Name":"running"}}]}],"NextToken":"page-2"}`), nil
+	})
+	regions := []string{"us-east-1"}
+	m := model{step: stateRegion, selectedProfile: "prod", regions: regions, filteredRegions: regions}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updatedModel, next := updatedModel.(model).Update(cmd())
+	progress := updatedModel.(model)
+	require.Equal(t, 1, progress.instancesLoaded)
+	assert.False(t, progress.slowLoading)
+
+	slow, rest := slowLoad(t, progress, next)
+	assert.Contains(t, slow.View(), "press c to cancel")
+
+	close(release)
+	updatedModel, cmd = slow.Update(rest())
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	result := updatedModel.(model)
+	assert.False(t, result.loading)
+	assert.Len(t, result.instances, 2)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Order of the instance list, cycled with o on the instance screen
+type instanceSort int
+
+const (
+	// Case-insensitive Name tag, unnamed instances last
+	sortByName instanceSort = iota
+	sortByID
+	// Newest first, to find a box that was just launched
+	sortByLaunchTime
+	// Running instances first
+	sortByState
+)
+
+// Names used in the config file and shown in the header, in cycling order
+var instanceSortNames = []string{"name", "id", "launch-time", "state"}
+
+func (s instanceSort) String() string {
+	return instanceSortNames[s]
+}
+
+// parseInstanceSort reads the config's instance_sort; empty means by name.
+func parseInstanceSort(name string) (instanceSort, error) {
+	if name == "" {
+		return sortByName, nil
+	}
+	for i, n := range instanceSortNames {
+		if n == name {
+			return instanceSort(i), nil
+		}
+	}
+	return sortByName, fmt.Errorf("unknown instance_sort %q; use one of %s", name, strings.Join(instanceSortNames, ", "))
+}
+
+// Position of each state when sorting by state; unknown states go last
+var stateOrder = map[string]int{
+	"running":       0,
+	"pending":       1,
+	"stopping":      2,
+	"stopped":       3,
+	"shutting-down": 4,
+	"terminated":    5,
+}
+
+// stateRank returns where state sorts when sorting by state.
+func stateRank(state string) int {
+	if rank, ok := stateOrder[state]; ok {
+		return rank
+	}
+	return len(stateOrder)
+}
+
+// nameLess orders instances by Name tag, then ID, with unnamed instances last.
+func nameLess(a, b Instance) bool {
+	if (a.Name == "") != (b.Name == "") {
+		return a.Name != ""
+	}
+	if an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name); an != bn {
+		return an < bn
+	}
+	return a.ID < b.ID
+}
+
+// sortInstances returns a copy of instances ordered by s. Ties fall back to
+// the name order so the list doesn't shuffle between refreshes.
+func sortInstances(instances []Instance, s instanceSort) []Instance {
+	sorted := append([]Instance{}, instances...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch s {
+		case sortByID:
+			return a.ID < b.ID
+		case sortByLaunchTime:
+			if !a.LaunchTime.Equal(b.LaunchTime) {
+				return a.LaunchTime.After(b.LaunchTime)
+			}
+		case sortByState:
+			if ra, rb := stateRank(a.State), stateRank(b.State); ra != rb {
+				return ra < rb
+			}
+		}
+		return nameLess(a, b)
+	})
+	return sorted
+}
+
+// cycleSort switches the instance list to the next order, keeping the
+// highlighted instance under the cursor.
+func (m model) cycleSort() (tea.Model, tea.Cmd) {
+	highlighted := ""
+	if len(m.filteredInstances) > 0 {
+		highlighted = m.filteredInstances[m.cursor].ID
+	}
+	m.instanceSort = (m.instanceSort + 1) % instanceSort(len(instanceSortNames))
+	m.instances = sortInstances(m.instances, m.instanceSort)
+	m.filteredInstances = filterInstances(m.instances, m.filter)
+	m.cursor = 0
+	for i, inst := range m.filteredInstances {
+		if inst.ID == highlighted {
+			m.cursor = i
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sortTestInstances = []Instance{
+	{ID: "i-3", Name: "web-b", State: "stopped", LaunchTime: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+	{ID: "i-1", State: "running", LaunchTime: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+	{ID: "i-2", Name: "Web-a", State: "running", LaunchTime: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)},
+	{ID: "i-4", Name: "db", State: "pending"},
+}
+
+// Helper function for the IDs of instances in order
+func instanceIds(instances []Instance) []string {
+	ids := []string{}
+	for _, inst := range instances {
+		ids = append(ids, inst.ID)
+	}
+	return ids
+}
+
+// Test each instance order
+func TestSortInstances(t *testing.T) {
+	tests := []struct {
+		sort     instanceSort
+		expected []string
+	}{
+		{sortByName, []string{"i-4", "i-2", "i-3", "i-1"}},
+		{sortByID, []string{"i-1", "i-2", "i-3", "i-4"}},
+		{sortByLaunchTime, []string{"i-1", "i-3", "i-2", "i-4"}},
+		{sortByState, []string{"i-2", "i-1", "i-4", "i-3"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, instanceIds(sortInstances(sortTestInstances, tt.sort)), tt.sort.String())
+	}
+	assert.Equal(t, "i-3", sortTestInstances[0].ID, "the input is left alone")
+}
+
+// Test cycling the order on the instance screen
+func TestCycleSort(t *testing.T) {
+	instances := sortInstances(sortTestInstances, sortByName)
+	m := model{step: stateInstance, instances: instances, filteredInstances: instances, cursor: 2}
+	assert.Contains(t, m.View(), "by name")
+
+	updatedModel, _ := m.Update(altKey('o'))
+	result := updatedModel.(model)
+	assert.Equal(t, sortByID, result.instanceSort)
+	assert.Equal(t, []string{"i-1", "i-2", "i-3", "i-4"}, instanceIds(result.filteredInstances))
+	assert.Equal(t, "i-3", result.filteredInstances[result.cursor].ID, "the cursor follows the highlighted instance")
+	assert.Equal(t, "", result.filter)
+	assert.Contains(t, result.View(), "by id")
+
+	// The order is kept under a filter where matches score the same
+	result.filter = "web"
+	result.instanceSort = sortByState - 1
+	updatedModel, _ = result.cycleSort()
+	assert.Equal(t, []string{"i-2", "i-3"}, instanceIds(updatedModel.(model).filteredInstances))
+}
+
+// Test the instance_sort config setting
+func TestLoadConfigInstanceSort(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "instance_sort: launch-time\n")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	order, err := parseInstanceSort(cfg.InstanceSort)
+	require.NoError(t, err)
+	assert.Equal(t, sortByLaunchTime, order)
+
+	writeConfig(t, "instance_sort: size\n")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, `unknown instance_sort "size"`)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Set by -spinner: the loading spinner style, overriding the config's spinner
+var spinnerName string
+
+// Built-in spinner styles; dots and line are plain ASCII
+var spinnerStyles = map[string][]string{
+	"braille": {"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	"dots":    {".  ", ".. ", "...", "   "},
+	"line":    {"-", "\\", "|", "/"},
+}
+
+// spinnerFramesFor returns the frames of the spinner style name. Without a
+// name the braille spinner is used, unless the locale is set to a character
+// set other than UTF-8, where the ASCII line spinner is used instead.
+func spinnerFramesFor(name string) ([]string, error) {
+	if name == "" {
+		name = "braille"
+		if nonUnicodeLocale() {
+			name = "line"
+		}
+	}
+	frames, ok := spinnerStyles[name]
+	if !ok {
+		names := make([]string, 0, len(spinnerStyles))
+		for n := range spinnerStyles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown spinner %q; use one of %s", name, strings.Join(names, ", "))
+	}
+	return frames, nil
+}
+
+// nonUnicodeLocale reports whether the locale is explicitly set to a
+// character set other than UTF-8, going by the first of LC_ALL, LC_CTYPE, and
+// LANG that is set, as the C library does. No locale at all, as in many
+// containers and macOS GUI terminals, doesn't count.
+func nonUnicodeLocale() bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			v = strings.ToLower(v)
+			return !strings.Contains(v, "utf-8") && !strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
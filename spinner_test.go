@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test choosing the spinner style, explicitly or from the locale
+func TestSpinnerFramesFor(t *testing.T) {
+	frames, err := spinnerFramesFor("line")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-", "\\", "|", "/"}, frames)
+
+	_, err = spinnerFramesFor("moon")
+	assert.EqualError(t, err, `unknown spinner "moon"; use one of braille, dots, line`)
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	frames, _ = spinnerFramesFor("")
+	assert.Equal(t, spinnerStyles["braille"], frames)
+
+	// LC_ALL takes precedence over LANG
+	t.Setenv("LC_ALL", "C")
+	frames, _ = spinnerFramesFor("")
+	assert.Equal(t, spinnerStyles["line"], frames)
+
+	// No locale at all keeps the default
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "")
+	frames, _ = spinnerFramesFor("")
+	assert.Equal(t, spinnerStyles["braille"], frames)
+}
+
+// Test that an unknown configured spinner is reported
+func TestConfigSpinner(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "spinner: moon\n")
+	_, err := loadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown spinner "moon"`)
+
+	writeConfig(t, "spinner: dots\n")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "dots", cfg.Spinner)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SSM document that tunnels an SSH connection through Session Manager
+const sshSessionDocument = "AWS-StartSSHSession"
+
+// Set by the -ssh-config flag; a full ~/.ssh/config Host block is printed
+// instead of a bare ProxyCommand line
+var sshConfigOutput bool
+
+// Set by -user: the OS user to log in as over SSH, overriding the profile's
+// entry under ssh_users in the config
+var sshUser string
+
+// Login names accepted by -user and ssh_users, as useradd allows by default
+var sshUserPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]{0,31}$`)
+
+// validateSSHUser rejects names that aren't valid logins, which would also
+// need escaping in the generated SSH config.
+func validateSSHUser(user string) error {
+	if user != "" && !sshUserPattern.MatchString(user) {
+		return fmt.Errorf("invalid SSH user %q", user)
+	}
+	return nil
+}
+
+// sshUserFor returns the OS user for SSH to instances of profile: -user, or
+// the profile's entry under ssh_users, or "" to leave it to ssh.
+func sshUserFor(cfg Config, profile string) string {
+	if sshUser != "" {
+		return sshUser
+	}
+	return cfg.SSHUsers[profile]
+}
+
+// sshProxyCommand returns a ProxyCommand for ~/.ssh/config that tunnels SSH
+// to target through SSM. When target is empty, ssh's %h placeholder is used
+// so the HostName line supplies the instance ID.
+func sshProxyCommand(profile, region, target string) string {
+	return "ProxyCommand " + shellJoin(sshProxyArgs(profile, region, target))
+}
+
+func sshProxyArgs(profile, region, target string) []string {
+	if target == "" {
+		target = "%h"
+	}
+	return []string{"aws", "ssm", "start-session", "--profile", profile, "--region", region, "--target", target, "--document-name", sshSessionDocument, "--parameters", "portNumber=%p"}
+}
+
+// sshCommand returns an ssh invocation that logs in to target as user
+// through SSM, for when the user is known and no config entry is needed.
+func sshCommand(profile, region, user, target string) string {
+	return shellJoin([]string{"ssh", "-o", "ProxyCommand=" + shellJoin(sshProxyArgs(profile, region, target)), user + "@" + target})
+}
+
+// sshHostAlias derives a Host alias from the instance Name tag, falling back
+// to the instance ID.
+func sshHostAlias(inst Instance) string {
+	alias := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r == ' ':
+			return '-'
+		}
+		return -1
+	}, inst.Name)
+	if alias == "" {
+		return inst.ID
+	}
+	return alias
+}
+
+// sshConfigBlock returns a complete ~/.ssh/config Host entry for the
+// instance, logging in as user unless it's empty.
+func sshConfigBlock(profile, region, user string, inst Instance) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", sshHostAlias(inst))
+	fmt.Fprintf(&b, "    HostName %s\n", inst.ID)
+	if user != "" {
+		fmt.Fprintf(&b, "    User %s\n", user)
+	}
+	fmt.Fprintf(&b, "    %s\n", sshProxyCommand(profile, region, ""))
+	return b.String()
+}
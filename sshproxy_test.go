@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test SSH ProxyCommand generation
+func TestSSHProxyCommand(t *testing.T) {
+	assert.Equal(t,
+		"ProxyCommand aws ssm start-session --profile prod --region us-east-1 --target i-123 --document-name AWS-StartSSHSession --parameters 'portNumber=%p'",
+		sshProxyCommand("prod", "us-east-1", "i-123"))
+	assert.Contains(t, sshProxyCommand("prod", "us-east-1", ""), "--target '%h'")
+}
+
+// Test SSH config Host block generation
+func TestSSHConfigBlock(t *testing.T) {
+	block := sshConfigBlock("prod", "us-east-1", "", Instance{ID: "i-123", Name: "web server (old)"})
+
+	assert.Equal(t, "Host web-server-old\n"+
+		"    HostName i-123\n"+
+		"    ProxyCommand aws ssm start-session --profile prod --region us-east-1 --target '%h' --document-name AWS-StartSSHSession --parameters 'portNumber=%p'\n",
+		block)
+	assert.Contains(t, sshConfigBlock("prod", "us-east-1", "", Instance{ID: "i-456"}), "Host i-456\n")
+}
+
+// Test logging in as a given OS user over SSM
+func TestSSHUser(t *testing.T) {
+	assert.NoError(t, validateSSHUser("ec2-user"))
+	assert.NoError(t, validateSSHUser("svc_deploy.1"))
+	assert.Error(t, validateSSHUser("root; rm -rf /"))
+	assert.Error(t, validateSSHUser("-oProxyCommand=x"))
+	assert.Error(t, validateSSHUser("a b"))
+
+	cfg := Config{SSHUsers: map[string]string{"prod": "ubuntu"}}
+	assert.Equal(t, "ubuntu", sshUserFor(cfg, "prod"))
+	assert.Equal(t, "", sshUserFor(cfg, "dev"))
+	sshUser = "admin"
+	t.Cleanup(func() { sshUser = "" })
+	assert.Equal(t, "admin", sshUserFor(cfg, "prod"))
+
+	assert.Contains(t, sshConfigBlock("prod", "us-east-1", "ubuntu", Instance{ID: "i-123"}), "    HostName i-123\n    User ubuntu\n")
+	assert.Equal(t,
+		`ssh -o 'ProxyCommand=aws ssm start-session --profile prod --region us-east-1 --target i-123 --document-name AWS-StartSSHSession --parameters '\''portNumber=%p'\''' ubuntu@i-123`,
+		sshCommand("prod", "us-east-1", "ubuntu", "i-123"))
+}
+
+// Test rejecting invalid users in the config
+func TestSSHUsersConfig(t *testing.T) {
+	setTempHome(t)
+	writeConfig(t, "ssh_users:\n  prod: \"bad user\"\n")
+	_, err := loadConfig()
+	assert.ErrorContains(t, err, `ssh_users prod: invalid SSH user "bad user"`)
+}
+
+// Test the SSH config key binding
+func TestSSHProxyKey(t *testing.T) {
+	m := model{
+		step:              stateInstance,
+		filteredInstances: []Instance{{ID: "i-123", Name: "web-server"}},
+	}
+
+	result := typeKeys(m, altKey('s'))
+
+	assert.Equal(t, stateDone, result.step)
+	assert.Equal(t, sessionSSHProxy, result.sessionMode)
+	assert.Equal(t, "i-123", result.selectedInstance)
+	assert.Equal(t, "web-server", result.selectedName)
+
+	// A plain s starts the filter, e.g. a search for staging
+	result = typeKeys(m, runeKeys("staging")...)
+	assert.Equal(t, stateInstance, result.step)
+	assert.Equal(t, "staging", result.filter)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Older AWS CLI versions write expiresAt with a literal UTC suffix
+const legacySSOExpiryLayout = "2006-01-02T15:04:05UTC"
+
+// ssoCacheKey returns the key the AWS CLI caches the SSO token of profile
+// under: the sso_session name, or the sso_start_url for legacy profiles. ok
+// is false when the profile doesn't use SSO or the config can't be read.
+func ssoCacheKey(profile string) (key string, ok bool) {
+	section, ok := awsProfileSection(profile)
+	if !ok {
+		return "", false
+	}
+	if session := section.Key("sso_session").String(); session != "" {
+		return session, true
+	}
+	if url := section.Key("sso_start_url").String(); url != "" {
+		return url, true
+	}
+	return "", false
+}
+
+// ssoTokenPath returns where the AWS CLI caches the SSO token for key.
+func ssoTokenPath(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(os.ExpandEnv("$HOME/.aws/sso/cache"), hex.EncodeToString(sum[:])+".json")
+}
+
+// ssoTokenValid reports whether a cached SSO token for key exists and has
+// not expired.
+func ssoTokenValid(key string) bool {
+	data, err := os.ReadFile(ssoTokenPath(key))
+	if err != nil {
+		return false
+	}
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresAt   string `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	if err != nil {
+		expiresAt, err = time.Parse(legacySSOExpiryLayout, strings.TrimSpace(token.ExpiresAt))
+		if err != nil {
+			return false
+		}
+	}
+	return time.Now().Before(expiresAt)
+}
+
+// needsSSOLogin reports whether profile uses SSO and has no valid cached
+// token, so `aws sso login` must run before any AWS call can succeed.
+func needsSSOLogin(profile string) bool {
+	if demoMode {
+		return false
+	}
+	key, ok := ssoCacheKey(profile)
+	return ok && !ssoTokenValid(key)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAWSConfig = `[default]
+region = us-east-1
+
+[profile sso-dev]
+sso_session = corp
+sso_account_id = 111111111111
+sso_role_name = Developer
+
+[profile legacy]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+`
+
+// Helper function for writing an AWS config file into a temporary HOME
+func writeAWSConfig(t *testing.T, content string) {
+	home := setTempHome(t)
+	t.Setenv("AWS_CONFIG_FILE", "")
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".aws"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(content), 0644))
+}
+
+// Helper function for caching an SSO token the way the AWS CLI does
+func writeSSOToken(t *testing.T, key, expiresAt string) {
+	path := ssoTokenPath(key)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	token := `{"accessToken": "token", "expiresAt": "` + expiresAt + `"}`
+	require.NoError(t, os.WriteFile(path, []byte(token), 0600))
+}
+
+// Test identifying SSO profiles from the AWS config file
+func TestSSOCacheKey(t *testing.T) {
+	writeAWSConfig(t, testAWSConfig)
+
+	tests := []struct {
+		profile string
+		key     string
+		ok      bool
+	}{
+		{"sso-dev", "corp", true},
+		{"legacy", "https://corp.awsapps.com/start", true},
+		{"default", "", false},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := ssoCacheKey(tt.profile)
+		assert.Equal(t, tt.key, key, tt.profile)
+		assert.Equal(t, tt.ok, ok, tt.profile)
+	}
+}
+
+// Test checking the cached SSO token
+func TestSSOTokenValid(t *testing.T) {
+	setTempHome(t)
+	assert.False(t, ssoTokenValid("corp"), "no cached token")
+
+	writeSSOToken(t, "corp", time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	assert.True(t, ssoTokenValid("corp"))
+
+	writeSSOToken(t, "corp", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+	assert.False(t, ssoTokenValid("corp"), "expired token")
+
+	writeSSOToken(t, "corp", time.Now().Add(time.Hour).UTC().Format(legacySSOExpiryLayout))
+	assert.True(t, ssoTokenValid("corp"), "legacy expiry format")
+}
+
+// Test that selecting an SSO profile without a valid token logs in first
+func TestSSOLoginOnProfileSelect(t *testing.T) {
+	var started []string
+	original := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		started = append([]string{name}, arg...)
+		return exec.CommandContext(ctx, "true")
+	}
+	t.Cleanup(func() { execCommand = original })
+
+	m := model{step: stateProfile, profiles: []string{"sso-dev"}, filteredProfiles: []string{"sso-dev"}}
+
+	t.Run("expired token runs sso login", func(t *testing.T) {
+		writeAWSConfig(t, testAWSConfig)
+		started = nil
+		updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		result := updatedModel.(model)
+		assert.NotNil(t, cmd)
+		assert.NotNil(t, result.retry)
+		assert.Equal(t, stateProfile, result.reauthFrom)
+		assert.Equal(t, []string{"aws", "sso", "login", "--profile", "sso-dev"}, started)
+	})
+
+	t.Run("valid token goes straight to regions", func(t *testing.T) {
+		writeAWSConfig(t, testAWSConfig)
+		writeSSOToken(t, "corp", time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		started = nil
+		updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+		assert.Nil(t, updatedModel.(model).retry)
+		assert.Nil(t, started)
+	})
+}
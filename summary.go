@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Set by -summary: after a session ends, print the command that ran and how
+// long it lasted, ready to paste into a ticket or run again
+var sessionSummary bool
+
+// Set by -quiet or the config's quiet: don't announce the command a session
+// runs, or show the session starting screen
+var quiet bool
+
+// printSummary writes the summary line of a session that ran aws with args
+// for elapsed and ended with err.
+func printSummary(w io.Writer, args []string, elapsed time.Duration, err error) {
+	// Errors other than an exit code, e.g. a missing aws binary, are shown as is
+	var status string
+	if code := exitCode(err); code >= 0 {
+		status = fmt.Sprintf("exit %d", code)
+	} else {
+		status = err.Error()
+	}
+	fmt.Fprintf(w, "Session ended after %s (%s): aws %s\n", elapsed.Round(time.Second), status, shellJoin(args))
+}
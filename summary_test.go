@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the line printed when a session ends
+func TestPrintSummary(t *testing.T) {
+	args := []string{"ssm", "start-session", "--profile", "prod", "--region", "us-east-1", "--target", "i-123"}
+
+	var out bytes.Buffer
+	printSummary(&out, args, 12*time.Minute+3400*time.Millisecond, nil)
+	assert.Equal(t, "Session ended after 12m3s (exit 0): aws ssm start-session --profile prod --region us-east-1 --target i-123\n", out.String())
+
+	out.Reset()
+	printSummary(&out, args, time.Second, exec.Command("sh", "-c", "exit 255").Run())
+	assert.Contains(t, out.String(), "(exit 255)")
+
+	out.Reset()
+	printSummary(&out, args, 0, errors.New("aws not found"))
+	assert.Contains(t, out.String(), "(aws not found)")
+}
+
+// Test that sessions are only summarized with -summary
+func TestRunSessionSummary(t *testing.T) {
+	stubExec(t, "exit 0")
+	run := func() string {
+		return captureStderr(t, func() {
+			require.NoError(t, runSession([]string{"ssm", "start-session", "--target", "i-123"}))
+		})
+	}
+
+	assert.NotContains(t, run(), "Session ended")
+	sessionSummary = true
+	t.Cleanup(func() { sessionSummary = false })
+	assert.Contains(t, run(), "Session ended after 0s (exit 0): aws ssm start-session --target i-123\n")
+}
+
+// Test that -quiet hides the command and the session starting screen
+func TestQuiet(t *testing.T) {
+	stubExec(t, "exit 0")
+	run := func() string {
+		return captureStdout(t, func() {
+			require.NoError(t, runSession([]string{"ssm", "start-session", "--target", "i-123"}))
+		})
+	}
+	m := model{step: stateDone, selectedInstance: "i-123"}
+
+	assert.Equal(t, "Running: aws ssm start-session --target i-123\n", run())
+	assert.Contains(t, m.View(), "Starting SSM session...")
+	quiet = true
+	t.Cleanup(func() { quiet = false })
+	assert.Empty(t, run())
+	assert.Empty(t, m.View())
+}
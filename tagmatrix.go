@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"goversion/pkg/awsssm"
+)
+
+// Tag keys shown as tag matrix columns unless SSMSSH_TAG_COLUMNS or
+// tag_columns is set
+var defaultTagColumns = []string{"Environment", "Team", "Owner"}
+
+// Set from tag_columns in the config file
+var configTagColumns []string
+
+// tagColumns returns the tag matrix columns: the comma-separated
+// SSMSSH_TAG_COLUMNS, e.g. "Environment,Service", else tag_columns from the
+// config file, else the defaults.
+func tagColumns() []string {
+	cols := []string{}
+	for _, c := range strings.Split(os.Getenv("SSMSSH_TAG_COLUMNS"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) > 0 {
+		return cols
+	}
+	if len(configTagColumns) > 0 {
+		return configTagColumns
+	}
+	return defaultTagColumns
+}
+
+// getTagsForInstances fetches tags for several instances with a single
+// batched describe-instances call, keyed by instance ID.
+func getTagsForInstances(ctx context.Context, profile, region string, instanceIds []string) (map[string][]Tag, error) {
+	args := []string{"ec2", "describe-instances", "--profile", profile, "--region", region, "--instance-ids"}
+	args = append(args, instanceIds...)
+	args = append(args, "--output", "json")
+	out, err := describeAWS(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceId string `json:"InstanceId"`
+				Tags       []Tag  `json:"Tags"`
+			}
+		}
+	}
+	if err := awsssm.DecodeOutput(out, &result); err != nil {
+		return nil, err
+	}
+	tags := map[string][]Tag{}
+	for _, res := range result.Reservations {
+		for _, inst := range res.Instances {
+			tags[inst.InstanceId] = inst.Tags
+		}
+	}
+	return tags, nil
+}
+
+// tagMatrixCmd fetches tags for instances grouped by region, one batched
+// call per region. Every requested ID gets an entry, so instances without
+// tags aren't requested again.
+func tagMatrixCmd(ctx context.Context, profile string, instanceIdsByRegion map[string][]string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		tags := map[string][]Tag{}
+		var err error
+		for region, ids := range instanceIdsByRegion {
+			var regionTags map[string][]Tag
+			if regionTags, err = getTagsForInstances(ctx, profile, region, ids); err != nil {
+				tags = nil
+				break
+			}
+			for _, id := range ids {
+				tags[id] = regionTags[id]
+			}
+		}
+		if lookupCancelled(ctx) {
+			return nil
+		}
+		return struct {
+			matrixTags map[string][]Tag
+			err        error
+		}{tags, lookupErr(ctx, err, "tag matrix", loadTimeout)}
+	}
+}
+
+// missingMatrixIds groups the IDs of the rendered window's instances whose
+// tags haven't been fetched yet by region.
+func (m model) missingMatrixIds() map[string][]string {
+	missing := map[string][]string{}
+	for region, ids := range m.visibleInstanceIdsByRegion() {
+		for _, id := range ids {
+			if _, ok := m.tagMatrix[id]; !ok {
+				missing[region] = append(missing[region], id)
+			}
+		}
+	}
+	return missing
+}
+
+// fetchTagMatrix fetches the tags of the rows in the tag matrix that don't
+// have them yet, e.g. after scrolling. A fetch already running is left to
+// finish first.
+func (m model) fetchTagMatrix() (model, tea.Cmd) {
+	if !m.showTagMatrix || m.tagMatrixLoading || m.step != stateInstance {
+		return m, nil
+	}
+	ids := m.missingMatrixIds()
+	if len(ids) == 0 {
+		return m, nil
+	}
+	m.tagMatrixLoading = true
+	return m, tagMatrixCmd(m.lookupContext(), m.selectedProfile, ids)
+}
+
+// updateTagMatrix adds fetched tags to the matrix and fetches the rows that
+// scrolled into view meanwhile. A failed fetch is reported below the list.
+func (m model) updateTagMatrix(tags map[string][]Tag, err error) (tea.Model, tea.Cmd) {
+	m.tagMatrixLoading = false
+	if err != nil {
+		m.inlineErr = "Could not load tags: " + err.Error()
+		return m, nil
+	}
+	if m.tagMatrix == nil {
+		m.tagMatrix = map[string][]Tag{}
+	}
+	for id, t := range tags {
+		m.tagMatrix[id] = t
+	}
+	return m.fetchTagMatrix()
+}
+
+// renderTagMatrix lays out one row per instance ID with a column per tag key.
+func renderTagMatrix(instanceIds []string, columns []string, tags map[string][]Tag) []string {
+	header := append([]string{"Instance"}, columns...)
+	rows := [][]string{header}
+	for _, id := range instanceIds {
+		row := []string{id}
+		for _, col := range columns {
+			v := awsssm.TagValue(tags[id], col)
+			if v == "" {
+				v = "-"
+			}
+			row = append(row, v)
+		}
+		rows = append(rows, row)
+	}
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	lines := []string{}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	return lines
+}
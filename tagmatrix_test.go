@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goversion/pkg/awsssm"
+)
+
+// Test that the tag matrix batch-fetches the windowed instances
+func TestTagMatrixBatchFetch(t *testing.T) {
+	t.Setenv("SSMSSH_TAG_COLUMNS", "Environment,Team")
+	instances := []Instance{}
+	for i := 0; i < 30; i++ {
+		instances = append(instances, Instance{ID: fmt.Sprintf("i-%03d", i), Name: fmt.Sprintf("host-%d", i)})
+	}
+	var requested []string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		requested = nil
+		for i, a := range args {
+			if a == "--instance-ids" {
+				for _, id := range args[i+1:] {
+					if id == "--output" {
+						break
+					}
+					requested = append(requested, id)
+				}
+			}
+		}
+		return []byte(`{"Reservations":[{"Instances":[
+			{"InstanceId":"i-000","Tags":[{"Key":"Environment","Value":"production"},{"Key":"Team","Value":"payments"}]},
+			{"InstanceId":"i-001","Tags":[{"Key":"Environment","Value":"staging"}]}
+		]}]}`), nil
+	})
+
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+	}
+
+	updatedModel, cmd := m.Update(altKey('m'))
+	result := updatedModel.(model)
+	require.NotNil(t, cmd)
+	assert.True(t, result.showTagMatrix)
+	assert.True(t, result.tagMatrixLoading)
+
+	updatedModel, _ = result.Update(cmd())
+	result = updatedModel.(model)
+
+	// Only the rendered window is requested
+	assert.Len(t, requested, listWindowSize)
+	assert.Equal(t, "i-000", requested[0])
+	assert.False(t, result.tagMatrixLoading)
+	assert.Equal(t, "production", awsssm.TagValue(result.tagMatrix["i-000"], "Environment"))
+	assert.Equal(t, "payments", awsssm.TagValue(result.tagMatrix["i-000"], "Team"))
+
+	lines := renderTagMatrix([]string{"i-000", "i-001"}, tagColumns(), result.tagMatrix)
+	assert.Equal(t, []string{
+		"Instance  Environment  Team",
+		"i-000     production   payments",
+		"i-001     staging      -",
+	}, lines)
+	assert.Contains(t, result.View(), "Tag Matrix")
+}
+
+// Test that rows scrolled into the tag matrix get their tags, and that a
+// failed fetch is reported below the list
+func TestTagMatrixScroll(t *testing.T) {
+	instances := []Instance{}
+	for i := 0; i < 30; i++ {
+		instances = append(instances, Instance{ID: fmt.Sprintf("i-%03d", i), Name: fmt.Sprintf("host-%d", i)})
+	}
+	var requested []string
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		requested = nil
+		for i, a := range args {
+			if a == "--instance-ids" {
+				for _, id := range args[i+1:] {
+					if id == "--output" {
+						break
+					}
+					requested = append(requested, id)
+				}
+			}
+		}
+		return []byte(`{"Reservations":[]}`), nil
+	})
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		selectedProfile:   "default",
+		selectedRegion:    "us-east-1",
+		config:            Config{HidePreview: true},
+	}
+	updatedModel, cmd := m.Update(altKey('m'))
+	updatedModel, cmd = updatedModel.(model).Update(cmd())
+	assert.Nil(t, cmd, "the window has all its tags")
+	require.Len(t, requested, listWindowSize)
+
+	updatedModel, cmd = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyEnd})
+	require.NotNil(t, cmd)
+	updatedModel, _ = updatedModel.(model).Update(cmd())
+	assert.Equal(t, []string{"i-020", "i-021", "i-022", "i-023", "i-024", "i-025", "i-026", "i-027", "i-028", "i-029"}, requested)
+	assert.Len(t, updatedModel.(model).tagMatrix, 30)
+
+	updatedModel, _ = updatedModel.(model).Update(struct {
+		matrixTags map[string][]Tag
+		err        error
+	}{nil, fmt.Errorf("AccessDenied")})
+	result := updatedModel.(model)
+	assert.NoError(t, result.err)
+	assert.Equal(t, "Could not load tags: AccessDenied", result.inlineErr)
+	assert.Contains(t, result.View(), "Tag Matrix")
+}
+
+// Test tag matrix column configuration
+func TestTagColumns(t *testing.T) {
+	t.Setenv("SSMSSH_TAG_COLUMNS", "")
+	assert.Equal(t, defaultTagColumns, tagColumns())
+
+	t.Setenv("SSMSSH_TAG_COLUMNS", " Service , CostCenter,")
+	assert.Equal(t, []string{"Service", "CostCenter"}, tagColumns())
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Most lines one tag takes up in the preview pane; v shows the rest
+const maxTagLines = 3
+
+// Indent of the continuation lines of a wrapped tag value
+const tagIndent = "  "
+
+// wrapCells splits s into lines of at most width terminal cells, breaking
+// anywhere since tag values such as ARNs and JSON rarely have spaces. A width
+// of 0 or less leaves s on one line.
+func wrapCells(s string, width int) []string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return []string{s}
+	}
+	lines := []string{}
+	line := []rune{}
+	used := 0
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if used+w > width && len(line) > 0 {
+			lines = append(lines, string(line))
+			line, used = nil, 0
+		}
+		line = append(line, r)
+		used += w
+	}
+	return append(lines, string(line))
+}
+
+// tagLines renders tag as "Key: value" wrapped to width, continuation lines
+// indented, and cut to maxTagLines with an ellipsis.
+func tagLines(tag Tag, width int) []string {
+	lines := wrapCells(tag.Key+": "+tag.Value, width)
+	if len(lines) == 1 {
+		return lines
+	}
+	rest := wrapCells(strings.Join(lines[1:], ""), max(width-len(tagIndent), 1))
+	lines = lines[:1]
+	for _, line := range rest {
+		lines = append(lines, tagIndent+line)
+	}
+	if len(lines) > maxTagLines {
+		lines = lines[:maxTagLines]
+		lines[maxTagLines-1] = truncate(lines[maxTagLines-1]+"…", width)
+	}
+	return lines
+}
+
+// previewTextWidth returns the width tag lines are wrapped to in a pane of
+// width rw, bounding them by previewMaxWidth before the terminal size is known
+// so a long value can't stretch the pane.
+func previewTextWidth(rw int) int {
+	if rw <= 0 {
+		rw = previewMaxWidth
+	}
+	return max(rw-2, 1)
+}
+
+// showTagValues opens the full tag values of the previewed instance.
+func (m model) showTagValues() (tea.Model, tea.Cmd) {
+	if m.previewDetails == nil || len(m.previewDetails.Tags) == 0 {
+		return m, nil
+	}
+	m.tagValuesOpen = true
+	m.tagScroll = 0
+	return m, nil
+}
+
+// tagValueLines returns the lines of the tag value popup: each key, then its
+// value wrapped to the box.
+func (m model) tagValueLines() []string {
+	lines := []string{}
+	for _, tag := range m.previewDetails.Tags {
+		lines = append(lines, groupStyle.Render(fit(tag.Key, m.contentWidth(), 2)))
+		for _, line := range wrapCells(tag.Value, max(m.contentWidth()-2, 0)) {
+			lines = append(lines, infoStyle.Render(line))
+		}
+	}
+	return lines
+}
+
+// tagValueRows returns how many lines of the popup fit the terminal.
+func (m model) tagValueRows() int {
+	if m.height == 0 {
+		return listWindowSize
+	}
+	// Header and footer lines
+	return max(m.height-borderFrameHeight-2, 1)
+}
+
+// updateTagValues handles key input while the tag value popup is open: the
+// movement keys scroll it, alt+v and the back or filter-clear keys close it.
+func (m model) updateTagValues(s string) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	last := max(len(m.tagValueLines())-m.tagValueRows(), 0)
+	switch {
+	case s == "alt+v", keys.matches(actionBack, s), keys.matches(actionFilterClear, s):
+		m.tagValuesOpen = false
+	case keys.matches(actionQuit, s):
+		return m, tea.Quit
+	case keys.matches(actionUp, s):
+		m.tagScroll = max(m.tagScroll-1, 0)
+	case keys.matches(actionDown, s):
+		m.tagScroll = min(m.tagScroll+1, last)
+	case s == "pgup":
+		m.tagScroll = max(m.tagScroll-m.tagValueRows(), 0)
+	case s == "pgdown":
+		m.tagScroll = min(m.tagScroll+m.tagValueRows(), last)
+	}
+	return m, nil
+}
+
+func (m model) tagValuesView() string {
+	w := m.contentWidth()
+	inst := m.previewInstance()
+	title := "Tags of " + inst.ID
+	if inst.Name != "" {
+		title = "Tags of " + inst.Name
+	}
+	lines := m.tagValueLines()
+	start := min(m.tagScroll, max(len(lines)-m.tagValueRows(), 0))
+	end := min(start+m.tagValueRows(), len(lines))
+	content := headerStyle.Render(fit(title, w, 2)) + "\n"
+	content += strings.Join(lines[start:end], "\n") + "\n"
+	content += quitStyle.Render(fit("↑/↓, pgup/pgdn: scroll • alt+v/esc: close", w, 0))
+	return borderStyle.Render(content)
+}
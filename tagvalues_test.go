@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A tag value far wider than any preview pane
+var longTag = Tag{Key: "Policy", Value: "arn:aws:iam::123456789012:policy/" + strings.Repeat("very-long-policy-name-", 10)}
+
+// Test wrapping tag values to the pane width
+func TestTagLines(t *testing.T) {
+	assert.Equal(t, []string{"Team: web"}, tagLines(Tag{Key: "Team", Value: "web"}, 20))
+	assert.Equal(t, []string{"Key: abcdefgh", "  ijklmnop"}, tagLines(Tag{Key: "Key", Value: "abcdefghijklmnop"}, 13))
+
+	lines := tagLines(longTag, 20)
+	require.Len(t, lines, maxTagLines)
+	for _, line := range lines {
+		assert.LessOrEqual(t, lipgloss.Width(line), 20, line)
+	}
+	assert.True(t, strings.HasSuffix(lines[maxTagLines-1], "…"))
+}
+
+// Test that long tag values don't push the preview past the terminal, and
+// are bounded before the terminal size is known
+func TestPreviewWideTags(t *testing.T) {
+	instances := []Instance{{ID: "i-123", Name: "web-1", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		previewInstanceId: "i-123",
+		previewDetails:    &InstanceDetails{Tags: []Tag{longTag, {Key: "Team", Value: "web"}}},
+		width:             100,
+		height:            40,
+	}
+	for _, line := range strings.Split(m.View(), "\n") {
+		assert.LessOrEqual(t, lipgloss.Width(line), m.width, line)
+	}
+
+	m.width, m.height = 0, 0
+	assert.NotContains(t, m.View(), longTag.Value, "the value is wrapped")
+}
+
+// Test opening, scrolling and closing the full tag values
+func TestTagValuesPopup(t *testing.T) {
+	instances := []Instance{{ID: "i-123", Name: "web-1", State: "running"}}
+	m := model{
+		step:              stateInstance,
+		instances:         instances,
+		filteredInstances: instances,
+		previewInstanceId: "i-123",
+		previewDetails:    &InstanceDetails{Tags: []Tag{longTag, {Key: "Team", Value: "web"}}},
+		width:             60,
+		height:            10,
+	}
+	updatedModel, _ := m.Update(altKey('v'))
+	result := updatedModel.(model)
+	require.True(t, result.tagValuesOpen)
+	view := result.View()
+	assert.Contains(t, view, "Tags of web-1")
+	assert.Contains(t, view, "arn:aws:iam::123456789012:policy/")
+	assert.NotContains(t, view, "Team")
+
+	// Scrolling stops at the last line
+	for range 20 {
+		updatedModel, _ = updatedModel.(model).Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	result = updatedModel.(model)
+	assert.Contains(t, result.View(), "Team")
+	assert.Equal(t, len(result.tagValueLines())-result.tagValueRows(), result.tagScroll)
+
+	updatedModel, _ = result.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	result = updatedModel.(model)
+	assert.False(t, result.tagValuesOpen)
+	assert.Equal(t, stateInstance, result.step)
+}
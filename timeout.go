@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Time allowed for region, instance, and tag matrix lookups (-timeout / SSMSSH_TIMEOUT)
+var loadTimeout = 15 * time.Second
+
+// Time allowed for the tag preview of the highlighted instance
+// (-preview-timeout / SSMSSH_PREVIEW_TIMEOUT)
+var previewTimeout = 5 * time.Second
+
+// timeoutError reports an AWS lookup that did not finish in time.
+type timeoutError struct {
+	what  string
+	after time.Duration
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s loading %s", e.after, e.what)
+}
+
+// lookupTimedOut returns the error for a lookup that exceeded its timeout,
+// recording it in the debug log since no command finished to be logged.
+func lookupTimedOut(what string, after time.Duration) error {
+	if logger := debugLog(); logger != nil {
+		logger.Warn("timeout", "lookup", what, "after", after.String())
+	}
+	return timeoutError{what, after}
+}
+
+// timeoutHint is shown beneath timeout errors in the View.
+const timeoutHint = "The AWS CLI did not respond in time. Check that your credentials are valid " +
+	"(e.g. run `aws sso login`) and that you can reach AWS (VPN, proxy). " +
+	"On slow links raise the limit with -timeout or SSMSSH_TIMEOUT (e.g. 45s)."
+
+func isTimeout(err error) bool {
+	var te timeoutError
+	return errors.As(err, &te)
+}
+
+// applyTimeoutEnv reads SSMSSH_TIMEOUT and SSMSSH_PREVIEW_TIMEOUT, ignoring
+// values that aren't positive durations.
+func applyTimeoutEnv() {
+	if d, err := time.ParseDuration(os.Getenv("SSMSSH_TIMEOUT")); err == nil && d > 0 {
+		loadTimeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("SSMSSH_PREVIEW_TIMEOUT")); err == nil && d > 0 {
+		previewTimeout = d
+	}
+}
+
+// lookupErr returns err from a lookup run under ctx, replaced by the timeout
+// error when ctx's deadline is what ended it, or by the deadline error once
+// the -deadline has passed.
+func lookupErr(ctx context.Context, err error, what string, timeout time.Duration) error {
+	if deadlineExceeded() {
+		return deadlineErr(err)
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return lookupTimedOut(what, timeout)
+	}
+	return err
+}
+
+// lookupCancelled reports whether ctx was cancelled because the user left the
+// screen that started the lookup, in which case its result is dropped.
+func lookupCancelled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
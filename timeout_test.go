@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a short timeout produces the timeout error path and kills the
+// hung AWS CLI process
+func TestLoadTimeout(t *testing.T) {
+	original := loadTimeout
+	loadTimeout = 10 * time.Millisecond
+	defer func() { loadTimeout = original }()
+	originalExec := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "30")
+	}
+	t.Cleanup(func() { execCommand = originalExec })
+
+	setTempHome(t)
+	resetDebugLog(t)
+	t.Setenv("SSMSSH_DEBUG", "1")
+
+	var msg tea.Msg
+	start := time.Now()
+	stderr := captureStderr(t, func() { msg = regionsCmd(context.Background(), "default", false)() })
+	assert.Empty(t, stderr)
+	assert.Less(t, time.Since(start), 5*time.Second, "the lookup waited for the process")
+	m := model{step: stateProfile, loading: true}
+	updatedModel, _ := m.Update(msg)
+	result := updatedModel.(model)
+
+	require.Error(t, result.err)
+	assert.True(t, isTimeout(result.err))
+	assert.Equal(t, "timed out after 10ms loading regions", result.err.Error())
+	view := result.View()
+	assert.Contains(t, view, "timed out after 10ms")
+	assert.Contains(t, view, "SSMSSH_TIMEOUT")
+	assert.Contains(t, view, "aws sso login")
+
+	// The timeout is recorded in the debug log instead
+	logs, err := filepath.Glob(filepath.Join(debugLogDir(), "*.log"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	data, err := os.ReadFile(logs[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"lookup":"regions"`)
+}
+
+// Helper function for capturing what fn writes to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	original := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = original }()
+	fn()
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+// Helper function for capturing what fn writes to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+	fn()
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+// Test that failed lookups are reported only through the message
+func TestLookupErrorsStayOffStderr(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, assert.AnError
+	})
+
+	var regionsMsg, instancesMsg tea.Msg
+	stderr := captureStderr(t, func() {
+		regionsMsg = regionsCmd(context.Background(), "default", false)()
+		instancesMsg = instancesCmd(context.Background(), "default", "us-east-1", true)()
+	})
+	assert.Empty(t, stderr)
+
+	m, _ := model{step: stateProfile, loading: true}.Update(regionsMsg)
+	assert.ErrorIs(t, m.(model).err, assert.AnError)
+	m, _ = model{step: stateRegion, loading: true}.Update(instancesMsg)
+	assert.ErrorIs(t, m.(model).err, assert.AnError)
+}
+
+// Test timeout configuration from the environment
+func TestApplyTimeoutEnv(t *testing.T) {
+	originalLoad, originalPreview := loadTimeout, previewTimeout
+	defer func() { loadTimeout, previewTimeout = originalLoad, originalPreview }()
+
+	t.Setenv("SSMSSH_TIMEOUT", "45s")
+	t.Setenv("SSMSSH_PREVIEW_TIMEOUT", "bogus")
+	applyTimeoutEnv()
+
+	assert.Equal(t, 45*time.Second, loadTimeout)
+	assert.Equal(t, originalPreview, previewTimeout)
+}
+
+// Test that leaving the instance list cancels its lookups and drops their results
+func TestBackCancelsLookups(t *testing.T) {
+	stubAWS(t, func(args ...string) ([]byte, error) {
+		return nil, assert.AnError
+	})
+	instances := []Instance{{ID: "i-123", State: "running"}}
+	m := model{step: stateInstance, instances: instances, filteredInstances: instances}.leaveScreen()
+	ctx := m.lookupContext()
+	preview := previewDetailsCmd(ctx, "default", "us-east-1", "i-123")
+
+	m = m.back()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.NoError(t, m.lookupContext().Err(), "the region list gets a fresh context")
+	assert.Nil(t, preview())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Set by -tmux: open sessions in a new tmux window instead of in this terminal
+var useTmux bool
+
+// Name of the tmux window the next session opens in, set from the selection
+var tmuxWindow string
+
+// validateTmux checks that -tmux can work before the TUI starts: ssmssh must
+// run inside a tmux session and tmux must be installed.
+func validateTmux() error {
+	if !useTmux {
+		return nil
+	}
+	if os.Getenv("TMUX") == "" {
+		return errors.New("-tmux only works when ssmssh runs inside a tmux session")
+	}
+	if _, err := lookPath("tmux"); err != nil {
+		return errors.New("-tmux needs tmux, which was not found in PATH")
+	}
+	if keepaliveInterval > 0 {
+		// The keepalive would stop as soon as ssmssh exits
+		return errors.New("-keepalive can't be combined with -tmux")
+	}
+	return nil
+}
+
+// tmuxWindowName names a window after the session's target, e.g.
+// prod/us-east-1/web-server, using the instance ID when it has no Name tag.
+func tmuxWindowName(profile, region string, target Instance) string {
+	name := target.Name
+	if name == "" {
+		name = target.ID
+	}
+	return profile + "/" + region + "/" + name
+}
+
+// tmuxArgs returns the tmux arguments that open window running cmd. Windows
+// get the tmux server's environment rather than ours, so the AWS variables
+// set on cmd, such as assumed role credentials, are passed with -e.
+func tmuxArgs(window string, cmd *exec.Cmd) []string {
+	args := []string{"new-window", "-n", window}
+	for _, kv := range cmd.Env {
+		if strings.HasPrefix(kv, "AWS_") {
+			args = append(args, "-e", kv)
+		}
+	}
+	return append(args, shellJoin(cmd.Args))
+}
+
+// openTmuxWindow starts cmd in a new tmux window and returns once the window
+// is open; the session lives on in tmux after ssmssh exits.
+func openTmuxWindow(window string, cmd *exec.Cmd) error {
+	args := tmuxArgs(window, cmd)
+	start := time.Now()
+	out, err := execCommand(context.Background(), "tmux", args...).CombinedOutput()
+	logCommand("tmux", args, start, err, string(out))
+	if err != nil {
+		return fmt.Errorf("tmux new-window failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	fmt.Printf("Opened %s in a new tmux window\n", window)
+	return nil
+}
+
+// runAttached runs a session subprocess in this terminal, or in a new tmux
+// window with -tmux.
+func runAttached(cmd *exec.Cmd) error {
+	if useTmux {
+		return openTmuxWindow(tmuxWindow, cmd)
+	}
+	return runInteractive(cmd)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function for enabling -tmux
+func withTmux(t *testing.T) {
+	useTmux = true
+	t.Cleanup(func() { useTmux = false })
+}
+
+// Test the checks -tmux makes before starting
+func TestValidateTmux(t *testing.T) {
+	assert.NoError(t, validateTmux())
+
+	withTmux(t)
+	t.Setenv("TMUX", "")
+	stubLookPath(t, "aws", "tmux")
+	assert.ErrorContains(t, validateTmux(), "inside a tmux session")
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	assert.NoError(t, validateTmux())
+
+	original := keepaliveInterval
+	keepaliveInterval = time.Minute
+	assert.ErrorContains(t, validateTmux(), "-keepalive")
+	keepaliveInterval = original
+
+	stubLookPath(t, "aws")
+	assert.ErrorContains(t, validateTmux(), "tmux, which was not found")
+}
+
+// Test naming windows after the target
+func TestTmuxWindowName(t *testing.T) {
+	assert.Equal(t, "prod/us-east-1/web-server", tmuxWindowName("prod", "us-east-1", Instance{ID: "i-123", Name: "web-server"}))
+	assert.Equal(t, "prod/us-east-1/i-123", tmuxWindowName("prod", "us-east-1", Instance{ID: "i-123"}))
+}
+
+// Test the tmux invocation, including credentials from an assumed role
+func TestTmuxArgs(t *testing.T) {
+	cmd := exec.Command("aws", "ssm", "start-session", "--profile", "prod", "--region", "us-east-1", "--target", "i-123")
+	assert.Equal(t, []string{"new-window", "-n", "prod/us-east-1/web", "aws ssm start-session --profile prod --region us-east-1 --target i-123"}, tmuxArgs("prod/us-east-1/web", cmd))
+
+	cmd.Env = []string{"HOME=/home/me", "AWS_ACCESS_KEY_ID=AKIA", "AWS_SESSION_TOKEN=token"}
+	assert.Equal(t, []string{"new-window", "-n", "w", "-e", "AWS_ACCESS_KEY_ID=AKIA", "-e", "AWS_SESSION_TOKEN=token", "aws ssm start-session --profile prod --region us-east-1 --target i-123"}, tmuxArgs("w", cmd))
+}
+
+// Test that -tmux hands the session to tmux instead of running it here
+func TestRunSessionInTmux(t *testing.T) {
+	setTempHome(t)
+	withTmux(t)
+	var ran []string
+	original := execCommand
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		if name != "tmux" {
+			// Only the tmux invocation runs; the session command is its argument
+			return exec.CommandContext(ctx, name, arg...)
+		}
+		ran = append([]string{name}, arg...)
+		return exec.CommandContext(ctx, "true")
+	}
+	t.Cleanup(func() { execCommand = original })
+	tmuxWindow = "prod/us-east-1/web"
+	t.Cleanup(func() { tmuxWindow = "" })
+
+	require.NoError(t, startSession("prod", "us-east-1", "i-123"))
+	require.NotEmpty(t, ran)
+	assert.Equal(t, []string{"tmux", "new-window", "-n", "prod/us-east-1/web"}, ran[:4])
+	assert.Contains(t, ran[len(ran)-1], "--target i-123")
+
+	stubExec(t, `echo "no current client" >&2; exit 1`)
+	assert.ErrorContains(t, startSession("prod", "us-east-1", "i-123"), "no current client")
+}
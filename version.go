@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, injected with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234"
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// Set by -version: print the build information and exit
+var showVersion bool
+
+// versionInfo describes the running build, e.g.
+// "ssmssh v1.2.3 (commit abc1234, go1.24.1 linux/amd64)". Builds without
+// ldflags, such as `go install`, fall back to what the Go toolchain recorded.
+func versionInfo() string {
+	v, c := version, commit
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && c == "" {
+				c = s.Value
+			}
+		}
+	}
+	if len(c) > 7 {
+		c = c[:7]
+	}
+	if c == "" {
+		c = "unknown"
+	}
+	return fmt.Sprintf("ssmssh %s (commit %s, %s %s/%s)", v, c, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
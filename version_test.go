@@ -0,0 +1,20 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test the version line with injected build information
+func TestVersionInfo(t *testing.T) {
+	originalVersion, originalCommit := version, commit
+	defer func() { version, commit = originalVersion, originalCommit }()
+
+	version, commit = "v1.2.3", "abc1234def5678"
+	assert.Equal(t, "ssmssh v1.2.3 (commit abc1234, "+runtime.Version()+" "+runtime.GOOS+"/"+runtime.GOARCH+")", versionInfo())
+
+	version, commit = "dev", ""
+	assert.Contains(t, versionInfo(), "ssmssh dev (commit ")
+}